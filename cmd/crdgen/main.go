@@ -0,0 +1,49 @@
+// Copyright 2019 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// crdgen writes the printer columns declared in pkg/apis into the application CRD, run from the repository root
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/stolostron/multicloud-operators-application/pkg/apis"
+)
+
+func main() {
+	path := apis.ApplicationCRDFile
+	if len(os.Args) > 1 {
+		path = os.Args[1]
+	}
+
+	in, err := ioutil.ReadFile(filepath.Clean(path))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "failed to read the application CRD:", err)
+		os.Exit(1)
+	}
+
+	out, err := apis.GenerateApplicationCRD(in)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "failed to generate the application CRD:", err)
+		os.Exit(1)
+	}
+
+	if err := ioutil.WriteFile(filepath.Clean(path), out, 0600); err != nil {
+		fmt.Fprintln(os.Stderr, "failed to write the application CRD:", err)
+		os.Exit(1)
+	}
+}
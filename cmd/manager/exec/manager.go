@@ -17,12 +17,15 @@ package exec
 import (
 	"context"
 	"fmt"
+	"io/ioutil"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/stolostron/multicloud-operators-application/pkg/apis"
 	"github.com/stolostron/multicloud-operators-application/pkg/controller"
+	appController "github.com/stolostron/multicloud-operators-application/pkg/controller/application"
 	"github.com/stolostron/multicloud-operators-application/utils"
 	appWebhook "github.com/stolostron/multicloud-operators-application/webhook"
 
@@ -34,7 +37,11 @@ import (
 	subapis "github.com/open-cluster-management/multicloud-operators-subscription/pkg/apis"
 	subv1 "github.com/open-cluster-management/multicloud-operators-subscription/pkg/apis/apps/v1"
 
+	crdv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation"
 	"k8s.io/client-go/rest"
 	"k8s.io/klog"
 	ctrl "sigs.k8s.io/controller-runtime"
@@ -66,8 +73,16 @@ func RunManager() {
 		os.Exit(1)
 	}
 
+	if !appController.IsMaintainedCondition(options.ReadyPrinterCondition) {
+		klog.Error("invalid --ready-printer-condition ", options.ReadyPrinterCondition,
+			", the controller does not maintain this condition type")
+		os.Exit(1)
+	}
+
 	// Register application CRD into hub kubernetes cluster
-	err = utils.CheckAndInstallCRD(cfg, options.ApplicationCRDFile)
+	err = utils.CheckAndInstallCRD(cfg, options.ApplicationCRDFile, func(crd *crdv1.CustomResourceDefinition) {
+		apis.SetReadyPrinterColumn(crd, options.ReadyPrinterCondition)
+	})
 	if err != nil {
 		klog.Infof("unable to install placementrule crd in hub: %s", err)
 		os.Exit(1)
@@ -86,6 +101,13 @@ func RunManager() {
 	leaseDuration := time.Duration(options.LeaderElectionLeaseDurationSeconds) * time.Second
 	renewDeadline := time.Duration(options.RenewDeadlineSeconds) * time.Second
 	retryPeriod := time.Duration(options.RetryPeriodSeconds) * time.Second
+
+	if options.SyncPeriod <= 0 {
+		klog.Error("the sync period must be positive, got ", options.SyncPeriod)
+		os.Exit(1)
+	}
+
+	syncPeriod := options.SyncPeriod
 	// Create a new Cmd to provide shared dependencies and start components
 	mgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(), ctrl.Options{
 		MetricsBindAddress:      fmt.Sprintf("%s:%d", metricsHost, metricsPort),
@@ -96,6 +118,7 @@ func RunManager() {
 		LeaseDuration:           &leaseDuration,
 		RenewDeadline:           &renewDeadline,
 		RetryPeriod:             &retryPeriod,
+		SyncPeriod:              &syncPeriod,
 		WebhookServer:           &k8swebhook.Server{TLSMinVersion: "1.2"},
 	})
 	if err != nil {
@@ -105,7 +128,16 @@ func RunManager() {
 
 	klog.Info("Registering Components.")
 
-	// Setup Scheme for all resources
+	// Setup Scheme for all resources, the Application types under the configured group version
+	if err := apis.SetApplicationGroupVersion(options.ApplicationGroupVersion); err != nil {
+		klog.Error(err, "")
+		os.Exit(1)
+	}
+
+	if options.ApplicationGroupVersion != appapis.GroupVersion.String() {
+		klog.Info("Watching the applications of ", options.ApplicationGroupVersion, " in place of ", appapis.GroupVersion.String())
+	}
+
 	if err := apis.AddToScheme(mgr.GetScheme()); err != nil {
 		klog.Error(err, "")
 		os.Exit(1)
@@ -122,12 +154,6 @@ func RunManager() {
 		os.Exit(1)
 	}
 
-	//append application api to scheme
-	if err = appapis.AddToScheme(mgr.GetScheme()); err != nil {
-		klog.Error("unable add mcm APIs to scheme: ", err)
-		os.Exit(1)
-	}
-
 	dpllist := &dplv1.DeployableList{}
 	err = runtimeClient.List(context.TODO(), dpllist, &client.ListOptions{})
 
@@ -144,6 +170,186 @@ func RunManager() {
 		os.Exit(1)
 	}
 
+	unknownGates, err := utils.DefaultFeatureGates.Set(options.FeatureGates)
+	if err != nil {
+		klog.Error("unable to parse the feature gates: ", err)
+		os.Exit(1)
+	}
+
+	if len(unknownGates) > 0 {
+		klog.Warning("Ignoring the unknown feature gates ", unknownGates)
+	}
+
+	klog.Info("Feature gates: ", utils.DefaultFeatureGates)
+
+	if options.ReadOnly {
+		klog.Warning("The operator is running in READ-ONLY mode, no owner references or labels are written to application components")
+	}
+
+	appController.Options.ReadOnly = options.ReadOnly
+
+	if options.ApplicationLabelSelector != "" {
+		scope, err := metav1.ParseToLabelSelector(options.ApplicationLabelSelector)
+		if err != nil {
+			klog.Error("invalid application label selector: ", err)
+			os.Exit(1)
+		}
+
+		selector, err := metav1.LabelSelectorAsSelector(scope)
+		if err != nil {
+			klog.Error("invalid application label selector: ", err)
+			os.Exit(1)
+		}
+
+		klog.Info("Scoping the operator to the applications labeled ", selector.String())
+
+		appController.Options.ApplicationSelector = selector
+		appWebhook.Options.ObjectSelector = scope
+	}
+
+	appController.Options.TerminatingGracePeriod = options.TerminatingGracePeriod
+	appController.Options.NewComponentGracePeriod = options.NewComponentGracePeriod
+
+	if options.HealthMaxComponents < 0 {
+		klog.Error("the health max components must not be negative, got ", options.HealthMaxComponents)
+		os.Exit(1)
+	}
+
+	appController.Options.HealthMaxComponents = options.HealthMaxComponents
+	appController.Options.ComponentsGracePeriod = options.ComponentsGracePeriod
+	appController.Options.HealthMetricsByNamespace = options.HealthMetricsByNamespace
+	appController.Options.ExportedComponentsMaxBytes = options.ExportedComponentsMaxBytes
+
+	if options.MinReconcileInterval < time.Second {
+		klog.Error("the minimum reconcile interval must be at least 1s, got ", options.MinReconcileInterval)
+		os.Exit(1)
+	}
+
+	appController.Options.ReconcileInterval = options.ReconcileInterval
+	appController.Options.MinReconcileInterval = options.MinReconcileInterval
+	appController.Options.ReconcilerID = reconcilerID()
+
+	if err := utils.ValidateFieldManager(options.FieldManager); err != nil {
+		klog.Error("invalid field manager: ", err)
+		os.Exit(1)
+	}
+
+	appController.Options.FieldManager = options.FieldManager
+	appController.Options.CacheComponents = options.CacheComponents
+	appController.Options.ReconcileHistorySize = options.ReconcileHistorySize
+	appController.Options.OrderedStartup = options.OrderedStartup
+	appController.Options.PrioritizeStaleStatus = options.PrioritizeStaleStatus
+	appController.Options.FailOnForbidden = options.FailOnForbidden
+	appController.Options.ImpersonateServiceAccounts = options.ImpersonateServiceAccounts
+
+	if options.NoAdoptAnnotation != "" {
+		if errs := validation.IsQualifiedName(options.NoAdoptAnnotation); len(errs) > 0 {
+			klog.Error("invalid no-adopt annotation ", options.NoAdoptAnnotation, ": ", strings.Join(errs, ", "))
+			os.Exit(1)
+		}
+	}
+
+	appController.Options.NoAdoptAnnotation = options.NoAdoptAnnotation
+
+	if options.WriteBudget < 0 {
+		klog.Error("the write budget must not be negative, got ", options.WriteBudget)
+		os.Exit(1)
+	}
+
+	if options.WriteBudget > 0 && options.WriteBudgetRequeueDelay <= 0 {
+		klog.Error("the write budget requeue delay must be positive, got ", options.WriteBudgetRequeueDelay)
+		os.Exit(1)
+	}
+
+	appController.Options.WriteBudget = options.WriteBudget
+	appController.Options.WriteBudgetRequeueDelay = options.WriteBudgetRequeueDelay
+	appController.Options.ServiceAccount = utils.ConfigIdentity(cfg)
+
+	if len(options.ExcludedNamespaces) > 0 || options.ExcludedNamespaceSelector != "" {
+		exclusion, err := appController.NewNamespaceExclusion(options.ExcludedNamespaces, options.ExcludedNamespaceSelector)
+		if err != nil {
+			klog.Error(err)
+			os.Exit(1)
+		}
+
+		klog.Info("Excluding the namespaces ", options.ExcludedNamespaces, " and the namespaces matching \"",
+			options.ExcludedNamespaceSelector, "\" from the application resolution")
+
+		appController.Options.ExcludedNamespaces = exclusion
+	}
+
+	if options.KindBreakerThreshold > 0 && options.KindBreakerCooldown < time.Second {
+		klog.Error("the kind breaker cooldown must be at least 1s, got ", options.KindBreakerCooldown)
+		os.Exit(1)
+	}
+
+	appController.Options.KindBreakerThreshold = options.KindBreakerThreshold
+	appController.Options.KindBreakerCooldown = options.KindBreakerCooldown
+
+	if options.EventAggregationWindow < time.Second {
+		klog.Error("the event aggregation window must be at least 1s, got ", options.EventAggregationWindow)
+		os.Exit(1)
+	}
+
+	appController.Options.EventAggregationWindow = options.EventAggregationWindow
+
+	if options.AuditLogPath != "" {
+		out := os.Stdout
+
+		if options.AuditLogPath != "-" {
+			out, err = os.OpenFile(filepath.Clean(options.AuditLogPath), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+			if err != nil {
+				klog.Error("unable to open the audit log: ", err)
+				os.Exit(1)
+			}
+		}
+
+		klog.Info("Writing the audit log to ", options.AuditLogPath)
+
+		appController.Options.AuditLog = appController.NewAuditLog(out, appController.Options.ReconcilerID)
+	}
+
+	if options.StatusSinkURL != "" {
+		token := ""
+
+		if options.StatusSinkTokenFile != "" {
+			data, err := ioutil.ReadFile(filepath.Clean(options.StatusSinkTokenFile))
+			if err != nil {
+				klog.Error("unable to read the status sink token file: ", err)
+				os.Exit(1)
+			}
+
+			token = strings.TrimSpace(string(data))
+		}
+
+		klog.Info("Publishing application status to ", options.StatusSinkURL)
+
+		appController.Options.StatusSink = appController.NewHTTPStatusSink(options.StatusSinkURL, token)
+	}
+
+	if options.DashboardLinksConfigMap != "" {
+		key := types.NamespacedName{Namespace: os.Getenv("POD_NAMESPACE"), Name: options.DashboardLinksConfigMap}
+
+		links, err := appController.LoadDashboardLinks(context.TODO(), runtimeClient, key)
+		if err != nil {
+			klog.Error("unable to load the dashboard links: ", err)
+			os.Exit(1)
+		}
+
+		klog.Info("Loaded dashboard links ", links.Names(), " from configmap ", key)
+
+		appController.Options.DashboardLinks = links
+	}
+
+	if options.TriggerConfigMap != "" {
+		appController.Options.TriggerConfigMap = types.NamespacedName{
+			Namespace: os.Getenv("POD_NAMESPACE"),
+			Name:      options.TriggerConfigMap,
+		}
+
+		klog.Info("Watching configmap ", appController.Options.TriggerConfigMap, " for the applications to reconcile")
+	}
+
 	// Setup all Controllers
 	if err := controller.AddToManager(mgr); err != nil {
 		klog.Error(err, "")
@@ -161,6 +367,85 @@ func RunManager() {
 		os.Exit(1)
 	}
 
+	appWebhook.Options.Warnings = options.WebhookWarnings
+	appWebhook.Options.SelectorTermsWarning = options.WebhookSelectorTermsWarning
+	appWebhook.Options.Validation.MaxSelectorTerms = options.WebhookMaxSelectorTerms
+
+	for _, key := range options.WebhookRequiredLabels {
+		if errs := validation.IsQualifiedName(key); len(errs) > 0 {
+			klog.Error("invalid webhook required label ", key, ": ", strings.Join(errs, ", "))
+			os.Exit(1)
+		}
+	}
+
+	appWebhook.Options.Validation.RequiredLabels = options.WebhookRequiredLabels
+	appWebhook.Options.NormalizeComponentKinds = !options.WebhookSkipKindNormalization
+	appWebhook.Options.NormalizeKeywords = !options.WebhookSkipKeywordNormalization
+	appWebhook.Options.LowercaseKeywords = options.WebhookLowercaseKeywords
+
+	if options.WebhookLowercaseKeywords && options.WebhookSkipKeywordNormalization {
+		klog.Error("the webhook keywords cannot be lowercased without their normalization")
+		os.Exit(1)
+	}
+
+	if options.WebhookMaxKeywords < 0 {
+		klog.Error("the webhook max keywords must not be negative, got ", options.WebhookMaxKeywords)
+		os.Exit(1)
+	}
+
+	appWebhook.Options.Validation.MaxKeywords = options.WebhookMaxKeywords
+	appWebhook.Options.SelfTest = options.WebhookSelfTest
+	appWebhook.Options.StrictDecoding = options.WebhookStrictDecoding
+
+	if err := appWebhook.ValidateAdmissionReviewVersions(options.WebhookAdmissionReviewVersions); err != nil {
+		klog.Error("invalid webhook admission review versions: ", err)
+		os.Exit(1)
+	}
+
+	appWebhook.Options.AdmissionReviewVersions = options.WebhookAdmissionReviewVersions
+
+	if options.WebhookCELRulesConfigMap != "" {
+		key := types.NamespacedName{Namespace: os.Getenv("POD_NAMESPACE"), Name: options.WebhookCELRulesConfigMap}
+
+		policy, err := appWebhook.LoadCELPolicy(context.TODO(), clt, key)
+		if err != nil {
+			klog.Error("unable to load the webhook CEL rules: ", err)
+			os.Exit(1)
+		}
+
+		klog.Info("Loaded webhook CEL rules ", policy.Names(), " from configmap ", key)
+
+		appWebhook.Options.CELPolicy = policy
+	}
+
+	if options.WebhookDescriptorTypeConfigMap != "" {
+		if os.Getenv("POD_NAMESPACE") == "" {
+			klog.Error("the webhook descriptor type configmap requires the POD_NAMESPACE env var")
+			os.Exit(1)
+		}
+
+		key := types.NamespacedName{Namespace: os.Getenv("POD_NAMESPACE"), Name: options.WebhookDescriptorTypeConfigMap}
+
+		klog.Info("Validating the application descriptor types against the catalog in configmap ", key)
+
+		// the catalog is read from the API server, the cached client would cache every ConfigMap of the cluster
+		appWebhook.RegisterValidator(appWebhook.DescriptorTypeCatalogValidatorName,
+			appWebhook.DescriptorTypeCatalogValidator(mgr.GetAPIReader(), key))
+	}
+
+	if ns, deployLabel := os.Getenv("POD_NAMESPACE"), os.Getenv("DEPLOYMENT_LABEL"); ns != "" && deployLabel != "" {
+		appWebhook.RegisterValidator(appWebhook.OperatorWorkloadsValidatorName,
+			appWebhook.OperatorWorkloadsValidator(ns, deployLabel))
+	}
+
+	appWebhook.RegisterValidator(appWebhook.ApplicationHierarchyValidatorName,
+		appWebhook.ApplicationHierarchyValidator(mgr.GetClient()))
+
+	if !options.WebhookAllowClusterScopedKinds {
+		appWebhook.RegisterValidator(appWebhook.NamespacedComponentKindsValidatorName,
+			appWebhook.NamespacedComponentKindsValidator(mgr.GetRESTMapper()))
+	}
+
 	hookServer := mgr.GetWebhookServer()
 	certDir := filepath.Join(os.TempDir(), "k8s-webhook-server", "application-serving-certs")
 
@@ -170,6 +455,41 @@ func RunManager() {
 		os.Exit(1)
 	}
 
+	if options.ResyncTokenFile != "" {
+		data, err := ioutil.ReadFile(filepath.Clean(options.ResyncTokenFile))
+		if err != nil {
+			klog.Error("unable to read the resync token file: ", err)
+			os.Exit(1)
+		}
+
+		if err := appController.RegisterResyncHandler(mgr, strings.TrimSpace(string(data))); err != nil {
+			klog.Error(err, "failed to register the resync endpoint")
+			os.Exit(1)
+		}
+
+		klog.Info("serving application resync at ", appController.ResyncPath)
+	}
+
+	if options.EnableDebugEndpoints {
+		if options.DebugTokenFile == "" {
+			klog.Error("the debug endpoints require a debug token file")
+			os.Exit(1)
+		}
+
+		data, err := ioutil.ReadFile(filepath.Clean(options.DebugTokenFile))
+		if err != nil {
+			klog.Error("unable to read the debug token file: ", err)
+			os.Exit(1)
+		}
+
+		if err := appWebhook.RegisterDebugHandler(mgr, strings.TrimSpace(string(data))); err != nil {
+			klog.Error(err, "failed to register webhook debug endpoint")
+			os.Exit(1)
+		}
+
+		klog.Info("serving webhook configuration at ", appWebhook.DebugConfigPath)
+	}
+
 	go appWebhook.WireUpWebhookSupplymentryResource(sig, mgr, appWebhook.WebhookServiceName,
 		appWebhook.WebhookValidatorName, certDir, caCert)
 
@@ -181,3 +501,17 @@ func RunManager() {
 		os.Exit(1)
 	}
 }
+
+// reconcilerID identifies this replica by the POD_NAME set through the downward API, or the hostname
+func reconcilerID() string {
+	if podName := os.Getenv("POD_NAME"); podName != "" {
+		return podName
+	}
+
+	hostname, err := os.Hostname()
+	if err != nil {
+		klog.Warning("unable to get the hostname, the applications will not record the replica reconciling them: ", err)
+	}
+
+	return hostname
+}
@@ -15,25 +15,105 @@
 package exec
 
 import (
+	"os"
+	"time"
+
 	pflag "github.com/spf13/pflag"
+
+	appController "github.com/stolostron/multicloud-operators-application/pkg/controller/application"
+	"github.com/stolostron/multicloud-operators-application/utils"
+	appWebhook "github.com/stolostron/multicloud-operators-application/webhook"
 )
 
 // ControllerRunOptions for the hcm controller.
 type ControllerRunOptions struct {
 	MetricsAddr                        string
 	ApplicationCRDFile                 string
+	ApplicationGroupVersion            string
 	LeaderElect                        bool
 	LeaderElectionLeaseDurationSeconds int
 	RenewDeadlineSeconds               int
 	RetryPeriodSeconds                 int
+	EnableDebugEndpoints               bool
+	DebugTokenFile                     string
+	ReadOnly                           bool
+	WebhookWarnings                    []string
+	StatusSinkURL                      string
+	AuditLogPath                       string
+	StatusSinkTokenFile                string
+	TerminatingGracePeriod             time.Duration
+	NewComponentGracePeriod            time.Duration
+	HealthMaxComponents                int
+	SyncPeriod                         time.Duration
+	ResyncTokenFile                    string
+	WebhookCELRulesConfigMap           string
+	WebhookDescriptorTypeConfigMap     string
+	EventAggregationWindow             time.Duration
+	ComponentsGracePeriod              time.Duration
+	DashboardLinksConfigMap            string
+	TriggerConfigMap                   string
+	WebhookAllowClusterScopedKinds     bool
+	WebhookSkipKindNormalization       bool
+	WebhookSkipKeywordNormalization    bool
+	WebhookLowercaseKeywords           bool
+	WebhookMaxKeywords                 int
+	KindBreakerThreshold               int
+	KindBreakerCooldown                time.Duration
+	FeatureGates                       string
+	FieldManager                       string
+	CacheComponents                    bool
+	ReconcileHistorySize               int
+	HealthMetricsByNamespace           bool
+	WebhookSelectorTermsWarning        int
+	WebhookMaxSelectorTerms            int
+	WebhookRequiredLabels              []string
+	WebhookSelfTest                    bool
+	WebhookStrictDecoding              bool
+	ApplicationLabelSelector           string
+	ExportedComponentsMaxBytes         int
+	ReconcileInterval                  time.Duration
+	MinReconcileInterval               time.Duration
+	ReadyPrinterCondition              string
+	OrderedStartup                     bool
+	PrioritizeStaleStatus              bool
+	ExcludedNamespaces                 []string
+	ExcludedNamespaceSelector          string
+	WebhookAdmissionReviewVersions     []string
+	FailOnForbidden                    bool
+	NoAdoptAnnotation                  string
+	WriteBudget                        int
+	ImpersonateServiceAccounts         bool
+	WriteBudgetRequeueDelay            time.Duration
 }
 
 var options = ControllerRunOptions{
 	MetricsAddr:                        "",
 	ApplicationCRDFile:                 "/usr/local/etc/application/crds/app.k8s.io_applications_crd_v1.yaml",
+	ApplicationGroupVersion:            "app.k8s.io/v1beta1",
 	LeaderElectionLeaseDurationSeconds: 137,
 	RenewDeadlineSeconds:               107,
 	RetryPeriodSeconds:                 26,
+	ReadOnly:                           os.Getenv("READ_ONLY") == "true",
+	WebhookWarnings:                    appWebhook.AllWarnings,
+	TerminatingGracePeriod:             appController.DefaultTerminatingGracePeriod,
+	NewComponentGracePeriod:            appController.DefaultNewComponentGracePeriod,
+	SyncPeriod:                         10 * time.Hour,
+	EventAggregationWindow:             utils.DefaultEventAggregationWindow,
+	ComponentsGracePeriod:              appController.DefaultComponentsGracePeriod,
+	FeatureGates:                       os.Getenv("FEATURE_GATES"),
+	ApplicationLabelSelector:           os.Getenv("LABEL_SELECTOR"),
+	FieldManager:                       fieldManagerFromEnv(),
+	HealthMetricsByNamespace:           true,
+	WebhookSelectorTermsWarning:        appWebhook.DefaultSelectorTermsWarning,
+	ExportedComponentsMaxBytes:         appController.DefaultExportedComponentsMaxBytes,
+	MinReconcileInterval:               appController.DefaultMinReconcileInterval,
+	KindBreakerThreshold:               appController.DefaultKindBreakerThreshold,
+	KindBreakerCooldown:                appController.DefaultKindBreakerCooldown,
+	ReconcileHistorySize:               appController.DefaultReconcileHistorySize,
+	ReadyPrinterCondition:              "Ready",
+	WebhookAdmissionReviewVersions:     appWebhook.DefaultAdmissionReviewVersions,
+	NoAdoptAnnotation:                  utils.AnnotationNoAdopt,
+	WriteBudgetRequeueDelay:            appController.DefaultWriteBudgetRequeueDelay,
 }
 
 // ProcessFlags parses command line parameters into options
@@ -55,6 +135,22 @@ func ProcessFlags() {
 		"Application CRD Yaml File",
 	)
 
+	flag.StringVar(
+		&options.ApplicationGroupVersion,
+		"application-group-version",
+		options.ApplicationGroupVersion,
+		"Group version of the Application CRD the controller and the webhook watch, for clusters where a CRD with "+
+			"the same schema and the applications plural is served under another group next to app.k8s.io.",
+	)
+
+	flag.StringVar(
+		&options.ApplicationLabelSelector,
+		"application-label-selector",
+		options.ApplicationLabelSelector,
+		"Optional label selector of the applications the operator reconciles and validates, for several operator "+
+			"instances to share a cluster. The other applications are ignored. Defaults to the LABEL_SELECTOR env var.",
+	)
+
 	flag.BoolVar(
 		&options.LeaderElect,
 		"leader-elect",
@@ -82,4 +178,391 @@ func ProcessFlags() {
 		options.RetryPeriodSeconds,
 		"The retry period in seconds.",
 	)
+
+	flag.BoolVar(
+		&options.EnableDebugEndpoints,
+		"enable-debug-endpoints",
+		false,
+		"Serve read-only debug endpoints, such as the effective webhook configuration, on the metrics address. "+
+			"Requires --debug-token-file.",
+	)
+
+	flag.StringVar(
+		&options.DebugTokenFile,
+		"debug-token-file",
+		options.DebugTokenFile,
+		"The file holding the bearer token of the debug endpoints, required by --enable-debug-endpoints.",
+	)
+
+	flag.BoolVar(
+		&options.ReadOnly,
+		"read-only",
+		options.ReadOnly,
+		"Never write to component resources, only compute and publish the application status. Defaults to the READ_ONLY env var.",
+	)
+
+	flag.StringVar(
+		&options.FeatureGates,
+		"feature-gates",
+		options.FeatureGates,
+		"Comma separated Feature=bool pairs enabling or disabling the optional behaviors, unknown features are ignored. "+
+			"Defaults to the FEATURE_GATES env var.",
+	)
+
+	flag.IntVar(
+		&options.ReconcileHistorySize,
+		"reconcile-history-size",
+		options.ReconcileHistorySize,
+		"The number of reconcile outcomes, errors and success markers, kept in the reconcile history annotation of "+
+			"the applications. 0 disables the history.",
+	)
+
+	flag.BoolVar(
+		&options.CacheComponents,
+		"cache-components",
+		options.CacheComponents,
+		"List the components from shared informer caches updated by watches rather than from the API server on "+
+			"every reconcile. The operator caches every component kind cluster-wide and needs the RBAC to watch them.",
+	)
+
+	flag.StringVar(
+		&options.NoAdoptAnnotation,
+		"no-adopt-annotation",
+		options.NoAdoptAnnotation,
+		"The annotation key opting the resources that set it to \"true\" out of every application, they are neither "+
+			"resolved nor adopted whatever the selectors matching them. Pass an empty value to adopt every resource.",
+	)
+
+	flag.IntVar(
+		&options.WriteBudget,
+		"write-budget",
+		options.WriteBudget,
+		"The maximum number of component patches a reconcile makes, the remaining patches are deferred to the next "+
+			"reconcile of the application. 0 leaves the patches unbounded.",
+	)
+
+	flag.DurationVar(
+		&options.WriteBudgetRequeueDelay,
+		"write-budget-requeue-delay",
+		options.WriteBudgetRequeueDelay,
+		"How long after running out of its write budget an application is reconciled again to make the deferred patches.",
+	)
+
+	flag.BoolVar(
+		&options.ImpersonateServiceAccounts,
+		"impersonate-service-accounts",
+		options.ImpersonateServiceAccounts,
+		"Let the applications resolve their components as a ServiceAccount of their namespace named in the "+
+			"apps.open-cluster-management.io/resolve-as annotation. The operator needs the RBAC to impersonate the "+
+			"service accounts.",
+	)
+
+	flag.BoolVar(
+		&options.FailOnForbidden,
+		"fail-on-forbidden",
+		options.FailOnForbidden,
+		"Report the components the controller is forbidden to resolve or patch in the Degraded condition of every "+
+			"application, naming the kind, the verb and the service account denied. The reconciles are still retried.",
+	)
+
+	flag.StringSliceVar(
+		&options.ExcludedNamespaces,
+		"excluded-namespaces",
+		options.ExcludedNamespaces,
+		"The namespaces excluded from the resolution of every application, their components are neither listed nor "+
+			"adopted whatever the application selectors.",
+	)
+
+	flag.StringVar(
+		&options.ExcludedNamespaceSelector,
+		"excluded-namespace-selector",
+		options.ExcludedNamespaceSelector,
+		"The label selector of the namespaces excluded from the resolution of every application, such as "+
+			"openshift.io/run-level. Empty excludes no namespace by label.",
+	)
+
+	flag.BoolVar(
+		&options.OrderedStartup,
+		"ordered-startup",
+		options.OrderedStartup,
+		"Reconcile the applications leaves first at startup, the child applications before their parents, for the "+
+			"parents to roll up reconciled children. The ordering is best effort, the later events are queued as usual.",
+	)
+
+	flag.BoolVar(
+		&options.PrioritizeStaleStatus,
+		"prioritize-stale-status",
+		options.PrioritizeStaleStatus,
+		"Reconcile first at startup the applications whose status is empty or was written for another generation, "+
+			"as the applications restored from a backup, for their status to converge ahead of the others.",
+	)
+
+	flag.StringVar(
+		&options.ReadyPrinterCondition,
+		"ready-printer-condition",
+		options.ReadyPrinterCondition,
+		"The condition type the Ready printer column of the installed application CRD shows. Ready shows the "+
+			"number of ready components of the aggregated health, any other condition the controller maintains "+
+			"shows its status.",
+	)
+
+	flag.StringVar(
+		&options.FieldManager,
+		"field-manager",
+		options.FieldManager,
+		"The field manager the controller writes the applications and their components with. Defaults to the "+
+			"FIELD_MANAGER env var, or "+utils.DefaultFieldManager+".",
+	)
+
+	flag.StringSliceVar(
+		&options.WebhookWarnings,
+		"webhook-warnings",
+		options.WebhookWarnings,
+		"The non-blocking warning checks run by the validating webhook. Pass an empty value to disable all of them.",
+	)
+
+	flag.StringVar(
+		&options.WebhookCELRulesConfigMap,
+		"webhook-cel-rules-configmap",
+		options.WebhookCELRulesConfigMap,
+		"Optional configmap in the operator namespace holding CEL rules the validating webhook enforces. "+
+			"The operator exits at startup when a rule is invalid.",
+	)
+
+	flag.StringVar(
+		&options.WebhookDescriptorTypeConfigMap,
+		"webhook-descriptor-type-configmap",
+		options.WebhookDescriptorTypeConfigMap,
+		"Optional configmap in the operator namespace holding the catalog of the allowed spec.descriptor.type values. "+
+			"Any type is allowed when it is not set.",
+	)
+
+	flag.IntVar(
+		&options.WebhookSelectorTermsWarning,
+		"webhook-selector-terms-warning",
+		options.WebhookSelectorTermsWarning,
+		"The number of label keys and expressions of a selector past which the validating webhook warns, 0 disables the warning.",
+	)
+
+	flag.IntVar(
+		&options.WebhookMaxSelectorTerms,
+		"webhook-max-selector-terms",
+		options.WebhookMaxSelectorTerms,
+		"The number of label keys and expressions of a selector past which the validating webhook denies the application, "+
+			"0 sets no limit.",
+	)
+
+	flag.StringSliceVar(
+		&options.WebhookRequiredLabels,
+		"webhook-required-labels",
+		options.WebhookRequiredLabels,
+		"Comma separated label keys every application must carry in its metadata, the validating webhook denies the "+
+			"applications missing any of them.",
+	)
+
+	flag.BoolVar(
+		&options.WebhookSelfTest,
+		"webhook-self-test",
+		options.WebhookSelfTest,
+		"Create a dry-run application once the webhook is wired up and log an error when the validating webhook did "+
+			"not deny it, as when its service or certificates are misconfigured and the API server fails open.",
+	)
+
+	flag.BoolVar(
+		&options.WebhookStrictDecoding,
+		"webhook-strict-decoding",
+		options.WebhookStrictDecoding,
+		"Reject the applications carrying fields unknown to the Application type, naming the field. The API server "+
+			"prunes the unknown fields of a structural CRD before the webhook sees them.",
+	)
+
+	flag.BoolVar(
+		&options.WebhookAllowClusterScopedKinds,
+		"webhook-allow-cluster-scoped-kinds",
+		options.WebhookAllowClusterScopedKinds,
+		"Let the validating webhook accept cluster-scoped kinds in spec.componentKinds, they never match any component. "+
+			"The Namespace kind is always accepted, it resolves the namespace of the application itself.",
+	)
+
+	flag.BoolVar(
+		&options.WebhookSkipKindNormalization,
+		"webhook-skip-kind-normalization",
+		options.WebhookSkipKindNormalization,
+		"Do not register the mutating webhook rewriting spec.componentKinds to the kind and group casing the cluster serves.",
+	)
+
+	flag.BoolVar(
+		&options.WebhookSkipKeywordNormalization,
+		"webhook-skip-keyword-normalization",
+		options.WebhookSkipKeywordNormalization,
+		"Do not trim and deduplicate spec.descriptor.keywords in the mutating webhook.",
+	)
+
+	flag.BoolVar(
+		&options.WebhookLowercaseKeywords,
+		"webhook-lowercase-keywords",
+		options.WebhookLowercaseKeywords,
+		"Lowercase spec.descriptor.keywords in the mutating webhook along their normalization.",
+	)
+
+	flag.IntVar(
+		&options.WebhookMaxKeywords,
+		"webhook-max-keywords",
+		options.WebhookMaxKeywords,
+		"The number of distinct spec.descriptor.keywords past which the validating webhook denies the application, "+
+			"0 sets no limit.",
+	)
+
+	flag.StringSliceVar(
+		&options.WebhookAdmissionReviewVersions,
+		"webhook-admission-review-versions",
+		options.WebhookAdmissionReviewVersions,
+		"The AdmissionReview versions the webhooks advertise in order of preference, among v1 and v1beta1.",
+	)
+
+	flag.BoolVar(
+		&options.HealthMetricsByNamespace,
+		"health-metrics-by-namespace",
+		options.HealthMetricsByNamespace,
+		"Label the application health count metric with the namespace, disable it to bound the metric cardinality.",
+	)
+
+	flag.IntVar(
+		&options.ExportedComponentsMaxBytes,
+		"exported-components-max-bytes",
+		options.ExportedComponentsMaxBytes,
+		"The size cap of the exported components annotation, a truncation marker is written in its place past it.",
+	)
+
+	flag.StringVar(
+		&options.DashboardLinksConfigMap,
+		"dashboard-links-configmap",
+		options.DashboardLinksConfigMap,
+		"Optional configmap in the operator namespace holding the dashboard URL templates, keyed by the link name, "+
+			"rendered into the dashboard links annotation of every application.",
+	)
+
+	flag.StringVar(
+		&options.TriggerConfigMap,
+		"trigger-configmap",
+		options.TriggerConfigMap,
+		"Optional configmap in the operator namespace listing applications to reconcile, one namespace/name per line "+
+			"in any of its entries. The entries are removed once the applications are enqueued.",
+	)
+
+	flag.StringVar(
+		&options.AuditLogPath,
+		"audit-log",
+		options.AuditLogPath,
+		"Optional file the owner references and the labels the controller writes on the components are appended to "+
+			"as JSON lines, \"-\" writes them to stdout. The audit log is disabled when empty.",
+	)
+
+	flag.StringVar(
+		&options.StatusSinkURL,
+		"status-sink-url",
+		options.StatusSinkURL,
+		"Optional HTTP endpoint the computed application status is posted to after every reconcile.",
+	)
+
+	flag.StringVar(
+		&options.StatusSinkTokenFile,
+		"status-sink-token-file",
+		options.StatusSinkTokenFile,
+		"Optional file holding the bearer token sent to the status sink.",
+	)
+
+	flag.DurationVar(
+		&options.TerminatingGracePeriod,
+		"terminating-grace-period",
+		options.TerminatingGracePeriod,
+		"How long terminating components are left out of the application health before they count as degraded.",
+	)
+
+	flag.DurationVar(
+		&options.NewComponentGracePeriod,
+		"new-component-grace-period",
+		options.NewComponentGracePeriod,
+		"How long after their creation degraded components count as progressing in the application health. "+
+			"0 evaluates them normally from the start.",
+	)
+
+	flag.IntVar(
+		&options.HealthMaxComponents,
+		"health-max-components",
+		options.HealthMaxComponents,
+		"The number of components above which the health of an application is not evaluated and reported as Unknown, "+
+			"only the inventory is kept. 0 always evaluates the health. The applications set their own with the "+
+			"health-max-components annotation.",
+	)
+
+	flag.DurationVar(
+		&options.ReconcileInterval,
+		"reconcile-interval",
+		options.ReconcileInterval,
+		"How long after a successful reconcile the applications are reconciled again to refresh their health, 0 "+
+			"disables it. The applications override it with the reconcile-interval annotation.",
+	)
+
+	flag.DurationVar(
+		&options.MinReconcileInterval,
+		"min-reconcile-interval",
+		options.MinReconcileInterval,
+		"The shortest reconcile interval, the shorter intervals of the operator or the applications are raised to it.",
+	)
+
+	flag.IntVar(
+		&options.KindBreakerThreshold,
+		"kind-breaker-threshold",
+		options.KindBreakerThreshold,
+		"The number of consecutive list failures of a component kind across the applications after which the kind "+
+			"is no longer listed until it is probed again. 0 never suspends a kind.",
+	)
+
+	flag.DurationVar(
+		&options.KindBreakerCooldown,
+		"kind-breaker-cooldown",
+		options.KindBreakerCooldown,
+		"How long a component kind suspended by its failures is skipped before a list probes it again.",
+	)
+
+	flag.DurationVar(
+		&options.EventAggregationWindow,
+		"event-aggregation-window",
+		options.EventAggregationWindow,
+		"The window the repeated events on the same application are coalesced within, at least 1s.",
+	)
+
+	flag.DurationVar(
+		&options.ComponentsGracePeriod,
+		"components-grace-period",
+		options.ComponentsGracePeriod,
+		"How long a new application without components is waiting for them before a warning is reported.",
+	)
+
+	// The watches reconcile the applications on every relevant change, the periodic resync is only a safety
+	// net against missed events. A short period bounds how long a missed event goes unnoticed at the cost of
+	// reconciling every application of the cluster each period, clusters trusting the watches can use days.
+	flag.DurationVar(
+		&options.SyncPeriod,
+		"sync-period",
+		options.SyncPeriod,
+		"The period of the full resync of every application on top of the event driven reconciles.",
+	)
+
+	flag.StringVar(
+		&options.ResyncTokenFile,
+		"resync-token-file",
+		options.ResyncTokenFile,
+		"Optional file holding the bearer token of the endpoint forcing the resync of every application of a namespace. "+
+			"The endpoint is served on the metrics address only when it is set.",
+	)
+}
+
+func fieldManagerFromEnv() string {
+	if fieldManager := os.Getenv("FIELD_MANAGER"); fieldManager != "" {
+		return fieldManager
+	}
+
+	return utils.DefaultFieldManager
 }
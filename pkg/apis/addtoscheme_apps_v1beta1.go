@@ -15,12 +15,47 @@
 package apis
 
 import (
+	"fmt"
+
 	dplv1 "github.com/open-cluster-management/multicloud-operators-deployable/pkg/apis/apps/v1"
 	subv1 "github.com/open-cluster-management/multicloud-operators-subscription/pkg/apis/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	v1beta1 "sigs.k8s.io/application/api/v1beta1"
 )
 
+// ApplicationGroupVersion is the group version the Application types are registered, watched and written under
+var ApplicationGroupVersion = v1beta1.GroupVersion
+
+// SetApplicationGroupVersion registers the Application types under the group version of another CRD with the
+// same schema, such as a vendored copy of app.k8s.io during a migration, in place of app.k8s.io/v1beta1. The
+// CRD must name its resource applications. It must be called before AddToScheme, a type is registered
+// under a single group version.
+func SetApplicationGroupVersion(gv string) error {
+	parsed, err := schema.ParseGroupVersion(gv)
+	if err != nil {
+		return err
+	}
+
+	if parsed.Group == "" || parsed.Version == "" {
+		return fmt.Errorf("invalid application group version %q, expected <group>/<version>", gv)
+	}
+
+	ApplicationGroupVersion = parsed
+
+	return nil
+}
+
+// addApplicationToScheme registers the Application types under ApplicationGroupVersion
+func addApplicationToScheme(s *runtime.Scheme) error {
+	s.AddKnownTypes(ApplicationGroupVersion, &v1beta1.Application{}, &v1beta1.ApplicationList{})
+	metav1.AddToGroupVersion(s, ApplicationGroupVersion)
+
+	return nil
+}
+
 func init() {
 	// Register the types with the Scheme so the components can map objects to GroupVersionKinds and back
-	AddToSchemes = append(AddToSchemes, v1beta1.SchemeBuilder.AddToScheme, dplv1.SchemeBuilder.AddToScheme, subv1.SchemeBuilder.AddToScheme)
+	AddToSchemes = append(AddToSchemes, addApplicationToScheme, dplv1.SchemeBuilder.AddToScheme, subv1.SchemeBuilder.AddToScheme)
 }
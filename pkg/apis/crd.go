@@ -0,0 +1,96 @@
+// Copyright 2019 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apis
+
+import (
+	"github.com/ghodss/yaml"
+	crdv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// ApplicationCRDFile is the application CRD installed by the operator, relative to the repository root
+const ApplicationCRDFile = "deploy/crds/app.k8s.io_applications_crd_v1.yaml"
+
+// ApplicationPrinterColumns are the printer columns of the application CRD. The Application types belong to
+// sigs.k8s.io/application and carry its kubebuilder markers, the columns over the status this operator computes
+// are declared here and written into the CRD with `make generate-crd`.
+var ApplicationPrinterColumns = []crdv1.CustomResourceColumnDefinition{
+	{Name: "Type", Type: "string", Description: "The type of the application", JSONPath: ".spec.descriptor.type"},
+	{Name: "Version", Type: "string", Description: "The creation date", JSONPath: ".spec.descriptor.version"},
+	{Name: "Owner", Type: "boolean", Description: "The application object owns the matched resources", JSONPath: ".spec.addOwnerRef"},
+	ReadyPrinterColumn(""),
+	{Name: "Health", Type: "string", Description: "The aggregated health of the components", Priority: 1,
+		JSONPath: `.status.conditions[?(@.type=="Ready")].reason`},
+	{Name: "Healthy", Type: "date", Description: "How long the application has been healthy", Priority: 1,
+		JSONPath: `.status.conditions[?(@.type=="Healthy")].lastTransitionTime`},
+	{Name: "Age", Type: "date", Description: "The creation date", JSONPath: ".metadata.creationTimestamp"},
+}
+
+// readyColumnName is the printer column showing whether the application is ready
+const readyColumnName = "Ready"
+
+// ReadyPrinterColumn returns the Ready printer column driven by the condition type. The Ready condition, the
+// aggregated health, is shown as its number of ready components, the other conditions as their status.
+func ReadyPrinterColumn(conditionType string) crdv1.CustomResourceColumnDefinition {
+	if conditionType == "" || conditionType == "Ready" {
+		return crdv1.CustomResourceColumnDefinition{Name: readyColumnName, Type: "string",
+			Description: "Numbers of components ready", JSONPath: ".status.componentsReady"}
+	}
+
+	return crdv1.CustomResourceColumnDefinition{Name: readyColumnName, Type: "string",
+		Description: "The status of the " + conditionType + " condition",
+		JSONPath:    `.status.conditions[?(@.type=="` + conditionType + `")].status`}
+}
+
+// SetReadyPrinterColumn replaces the Ready printer column of every version of the application CRD, so the
+// operator installs the CRD with the condition it is configured with
+func SetReadyPrinterColumn(crd *crdv1.CustomResourceDefinition, conditionType string) {
+	column := ReadyPrinterColumn(conditionType)
+
+	for i := range crd.Spec.Versions {
+		columns := crd.Spec.Versions[i].AdditionalPrinterColumns
+
+		for j := range columns {
+			if columns[j].Name == readyColumnName {
+				columns[j] = column
+			}
+		}
+	}
+}
+
+// GenerateApplicationCRD sets the printer columns declared in code on every version of the application CRD,
+// the OpenAPI schema generated from the sigs.k8s.io/application types is kept as it is
+func GenerateApplicationCRD(in []byte) ([]byte, error) {
+	crd := &crdv1.CustomResourceDefinition{}
+	if err := yaml.Unmarshal(in, crd); err != nil {
+		return nil, err
+	}
+
+	for i := range crd.Spec.Versions {
+		crd.Spec.Versions[i].AdditionalPrinterColumns = ApplicationPrinterColumns
+	}
+
+	obj, err := runtime.DefaultUnstructuredConverter.ToUnstructured(crd)
+	if err != nil {
+		return nil, err
+	}
+
+	// the fields set by the API server are left out of the manifest, as controller-gen does
+	unstructured.RemoveNestedField(obj, "metadata", "creationTimestamp")
+	unstructured.RemoveNestedField(obj, "status")
+
+	return yaml.Marshal(obj)
+}
@@ -0,0 +1,63 @@
+// Copyright 2019 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apis
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/ghodss/yaml"
+	"github.com/onsi/gomega"
+	crdv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+)
+
+func TestApplicationCRDInSync(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	in, err := ioutil.ReadFile(filepath.Join("..", "..", ApplicationCRDFile))
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	out, err := GenerateApplicationCRD(in)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(string(out)).To(gomega.Equal(string(in)), "the application CRD is out of date, run make generate-crd")
+}
+
+func TestSetReadyPrinterColumn(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	in, err := ioutil.ReadFile(filepath.Join("..", "..", ApplicationCRDFile))
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	crd := &crdv1.CustomResourceDefinition{}
+	g.Expect(yaml.Unmarshal(in, crd)).To(gomega.Succeed())
+
+	readyColumn := func() crdv1.CustomResourceColumnDefinition {
+		for _, c := range crd.Spec.Versions[0].AdditionalPrinterColumns {
+			if c.Name == "Ready" {
+				return c
+			}
+		}
+
+		return crdv1.CustomResourceColumnDefinition{}
+	}
+
+	SetReadyPrinterColumn(crd, "Ready")
+	g.Expect(readyColumn().JSONPath).To(gomega.Equal(".status.componentsReady"))
+
+	SetReadyPrinterColumn(crd, "Degraded")
+	g.Expect(readyColumn().JSONPath).To(gomega.Equal(`.status.conditions[?(@.type=="Degraded")].status`))
+	g.Expect(crd.Spec.Versions[0].AdditionalPrinterColumns).To(gomega.HaveLen(len(ApplicationPrinterColumns)))
+}
@@ -0,0 +1,46 @@
+// Copyright 2019 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package apis
+
+import (
+	"testing"
+
+	"github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	v1beta1 "sigs.k8s.io/application/api/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+)
+
+func TestSetApplicationGroupVersion(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	defer func() { ApplicationGroupVersion = v1beta1.GroupVersion }()
+
+	g.Expect(SetApplicationGroupVersion("v1")).NotTo(gomega.Succeed())
+	g.Expect(SetApplicationGroupVersion("app.k8s.io/")).NotTo(gomega.Succeed())
+	g.Expect(ApplicationGroupVersion).To(gomega.Equal(v1beta1.GroupVersion))
+
+	g.Expect(SetApplicationGroupVersion("app.example.io/v1beta1")).To(gomega.Succeed())
+
+	s := runtime.NewScheme()
+	g.Expect(AddToScheme(s)).To(gomega.Succeed())
+
+	gvk, err := apiutil.GVKForObject(&v1beta1.Application{}, s)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(gvk).To(gomega.Equal(schema.GroupVersionKind{Group: "app.example.io", Version: "v1beta1", Kind: "Application"}))
+
+	g.Expect(s.Recognizes(v1beta1.GroupVersion.WithKind("Application"))).To(gomega.BeFalse())
+}
@@ -16,6 +16,9 @@ package application
 
 import (
 	"context"
+	"fmt"
+	"sync"
+	"time"
 
 	dplv1 "github.com/open-cluster-management/multicloud-operators-deployable/pkg/apis/apps/v1"
 	subv1 "github.com/open-cluster-management/multicloud-operators-subscription/pkg/apis/apps/v1"
@@ -23,19 +26,148 @@ import (
 
 	"strings"
 
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/klog"
 	appv1beta1 "sigs.k8s.io/application/api/v1beta1"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/event"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 	"sigs.k8s.io/controller-runtime/pkg/source"
 )
 
+// ReconcileOptions are the operator level settings of the application controller
+type ReconcileOptions struct {
+	// ReadOnly disables every write to component resources, such as owner references and labels, regardless of
+	// the application spec, and the fan-out of template applications. The status of the applications
+	// is still computed and published.
+	ReadOnly bool
+	// StatusSink optionally receives the computed status in addition to the application CR
+	StatusSink StatusSink
+	// AuditLog optionally records the owner references and the labels the controller writes on the components
+	AuditLog *AuditLog
+	// ReconcilerID identifies the operator replica, it is recorded on the applications it updates
+	ReconcilerID string
+	// TerminatingGracePeriod is how long past their deletion timestamp the terminating components are left
+	// out of the application health before they count as degraded
+	TerminatingGracePeriod time.Duration
+	// NewComponentGracePeriod is how long after their creation the degraded components count as progressing
+	// in the application health, 0 evaluates them normally from the start
+	NewComponentGracePeriod time.Duration
+	// HealthMaxComponents is the number of components above which the health of an application is not evaluated
+	// and reported as Unknown, to keep the reconciles of the huge applications fast. 0 always evaluates it.
+	// The applications set their own with the health max components annotation.
+	HealthMaxComponents int
+	// ComponentsGracePeriod is how long after its creation an application without components is waiting for
+	// them, rather than reported with a warning
+	ComponentsGracePeriod time.Duration
+	// EventAggregationWindow is the window the repeated events on the same application are coalesced within
+	EventAggregationWindow time.Duration
+	// DashboardLinks optionally renders the dashboard URLs recorded on the applications
+	DashboardLinks *DashboardLinks
+	// TriggerConfigMap optionally names the ConfigMap listing the applications to reconcile, its entries are
+	// removed once the applications are enqueued
+	TriggerConfigMap types.NamespacedName
+	// ReconcileInterval requeues the applications after every successful reconcile, for the health to refresh
+	// without component watches, 0 disables it. The applications set their own with the reconcile interval
+	// annotation.
+	ReconcileInterval time.Duration
+	// MinReconcileInterval bounds the reconcile intervals to protect the API server
+	MinReconcileInterval time.Duration
+	// ExportedComponentsMaxBytes caps the exported components annotation, a truncation marker is written past it
+	ExportedComponentsMaxBytes int
+	// HealthMetricsByNamespace labels the application health count metric with the namespace, it is disabled
+	// to bound the metric cardinality on clusters with many namespaces
+	HealthMetricsByNamespace bool
+	// KindBreakerThreshold is the number of consecutive list failures of a component kind, across the
+	// applications, after which the kind is no longer listed for KindBreakerCooldown. 0 never suspends a kind.
+	KindBreakerThreshold int
+	// KindBreakerCooldown is how long a suspended kind is skipped before a list probes it again
+	KindBreakerCooldown time.Duration
+	// CacheComponents lists the components from a shared informer cache kept up to date by watches, rather than
+	// from the API server on every reconcile. Each component kind is then cached in every namespace, it costs
+	// the operator memory and the RBAC to watch the kinds.
+	CacheComponents bool
+	// ReconcileHistorySize is the number of reconcile outcomes, the errors and the success markers, kept in the
+	// reconcile history annotation of the applications. 0 disables the history.
+	ReconcileHistorySize int
+	// FieldManager is the field manager of the writes of the controller, the API server derives one from the
+	// user agent when empty
+	FieldManager string
+	// OrderedStartup reconciles the applications leaves first at startup, the children before their parents,
+	// for the parents to converge faster. The ordering is best effort and only applies to the initial pass.
+	OrderedStartup bool
+	// PrioritizeStaleStatus reconciles first at startup the applications whose status is empty or was written
+	// for another generation, as the applications restored from a backup, ahead of the routine reconciles
+	PrioritizeStaleStatus bool
+	// ExcludedNamespaces optionally trims the namespaces excluded by the operator from the resolution of every
+	// application, after the selection of the application
+	ExcludedNamespaces *NamespaceExclusion
+	// FailOnForbidden reports the components the controller is forbidden to resolve or patch in the
+	// Degraded and AccessForbidden conditions of every application, the applications opt in on their own with the fail-on-forbidden annotation
+	FailOnForbidden bool
+	// NoAdoptAnnotation is the annotation key opting the resources set to "true" out of every application, they
+	// are neither resolved nor adopted. Empty lets every resource be adopted.
+	NoAdoptAnnotation string
+	// ImpersonateServiceAccounts lets the applications resolve their components as a ServiceAccount of their
+	// namespace named in the resolve-as annotation, the operator impersonating it. It needs the RBAC to
+	// impersonate the service accounts.
+	ImpersonateServiceAccounts bool
+	// WriteBudget caps the component patches of a reconcile, the remaining patches are deferred to a reconcile
+	// WriteBudgetRequeueDelay later. 0 leaves the patches unbounded.
+	WriteBudget int
+	// WriteBudgetRequeueDelay is how long after running out of its write budget an application is reconciled again
+	WriteBudgetRequeueDelay time.Duration
+	// ApplicationSelector scopes the controller to the applications whose labels match it, for several operator
+	// instances to share a cluster. The other applications are ignored, their status is not written. Nil
+	// reconciles every application.
+	ApplicationSelector labels.Selector
+	// ServiceAccount is the identity of the operator named in the forbidden accesses the API server does not
+	// name the user of
+	ServiceAccount string
+}
+
+// DefaultMinReconcileInterval is the shortest reconcile interval of an application
+const DefaultMinReconcileInterval = 10 * time.Second
+
+// DefaultComponentsGracePeriod covers the first rollout of the workloads of a new application
+const DefaultComponentsGracePeriod = 10 * time.Minute
+
+// DefaultTerminatingGracePeriod covers the rollouts of workloads with the default pod termination grace period
+const DefaultTerminatingGracePeriod = 5 * time.Minute
+
+// DefaultNewComponentGracePeriod covers the scheduling of the first replicas of a new workload
+const DefaultNewComponentGracePeriod = time.Minute
+
+// Options is populated from the command line before the controller is added to the manager
+var Options = ReconcileOptions{
+	TerminatingGracePeriod:     DefaultTerminatingGracePeriod,
+	NewComponentGracePeriod:    DefaultNewComponentGracePeriod,
+	ComponentsGracePeriod:      DefaultComponentsGracePeriod,
+	EventAggregationWindow:     utils.DefaultEventAggregationWindow,
+	HealthMetricsByNamespace:   true,
+	ExportedComponentsMaxBytes: DefaultExportedComponentsMaxBytes,
+	MinReconcileInterval:       DefaultMinReconcileInterval,
+	KindBreakerThreshold:       DefaultKindBreakerThreshold,
+	KindBreakerCooldown:        DefaultKindBreakerCooldown,
+	FieldManager:               utils.DefaultFieldManager,
+	ReconcileHistorySize:       DefaultReconcileHistorySize,
+	NoAdoptAnnotation:          utils.AnnotationNoAdopt,
+	WriteBudgetRequeueDelay:    DefaultWriteBudgetRequeueDelay,
+}
+
 // Add creates a new Application Controller and adds it to the Manager. The Manager will set fields on the Controller
 // and Start it when the Manager is Started.
 func Add(mgr manager.Manager) error {
@@ -44,13 +176,27 @@ func Add(mgr manager.Manager) error {
 
 // newReconciler returns a new reconcile.Reconciler
 func newReconciler(mgr manager.Manager) reconcile.Reconciler {
-	erecorder, _ := utils.NewEventRecorder(mgr.GetConfig(), mgr.GetScheme())
+	erecorder, _ := utils.NewEventRecorderWithWindow(mgr.GetConfig(), mgr.GetScheme(), Options.EventAggregationWindow)
 
-	return &ReconcileApplication{
-		Client:        mgr.GetClient(),
+	r := &ReconcileApplication{
+		Client:        withFieldOwner(mgr.GetClient(), Options.FieldManager),
+		apiReader:     mgr.GetAPIReader(),
 		scheme:        mgr.GetScheme(),
+		mapper:        mgr.GetRESTMapper(),
 		eventRecorder: erecorder,
+		options:       Options,
+		breaker:       newKindBreaker(Options.KindBreakerThreshold, Options.KindBreakerCooldown, componentKindBreakerOpen),
+	}
+
+	if Options.CacheComponents {
+		r.componentReader = mgr.GetCache()
+	}
+
+	if Options.ImpersonateServiceAccounts {
+		r.impersonate = newImpersonatingReaders(mgr.GetConfig(), mgr.GetScheme(), mgr.GetRESTMapper()).reader
 	}
+
+	return r
 }
 
 type deployableMapper struct {
@@ -142,20 +288,197 @@ func (mapper *subscriptionMapper) Map(obj client.Object) []reconcile.Request {
 	return requests
 }
 
+// controllerAnnotations are the operator annotations written by the controllers themselves, on the application
+// or on the applications it is a component of. Their updates carry no new setting and do not need a reconcile.
+var controllerAnnotations = map[string]bool{
+	utils.AnnotationLastReconciledBy:                true,
+	utils.AnnotationReconcileHistory:                true,
+	utils.AnnotationRebuildStatus:                   true,
+	utils.AnnotationDashboardLinks:                  true,
+	utils.AnnotationExportedComponents:              true,
+	utils.AnnotationClusterComponents:               true,
+	utils.AnnotationPropagatedLabelKeys:             true,
+	utils.AnnotationPropagatedAnnotationKeys:        true,
+	utils.AnnotationOwnerApplication:                true,
+	"apps.open-cluster-management.io/subscriptions": true,
+	"apps.open-cluster-management.io/deployables":   true,
+}
+
+// settingsAnnotationsChanged returns whether an operator annotation not written by the controllers, such as the
+// required components or the health aggregation setting, differs between the old and new annotations
+func settingsAnnotationsChanged(oldAnnotations, newAnnotations map[string]string) bool {
+	changed := func(from, to map[string]string) bool {
+		for k, v := range from {
+			if !strings.HasPrefix(k, utils.ReservedKeyPrefix) || controllerAnnotations[k] {
+				continue
+			}
+
+			if w, ok := to[k]; !ok || w != v {
+				return true
+			}
+		}
+
+		return false
+	}
+
+	return changed(oldAnnotations, newAnnotations) || changed(newAnnotations, oldAnnotations)
+}
+
+// applicationPredicateFunc skips the application updates that leave the spec generation unchanged, such as
+// metadata changes and the status and annotation updates made by this controller. The components are
+// still re-resolved on their own events. The annotations do not bump the generation, an update of the operator
+// annotations the application is configured with, a new reconcile request or pause value among them, forces a
+// reconcile. The periodic resyncs replay the cached object unchanged and are let through, they are the safety net
+// against missed events, and the work queue collapses them with any pending event driven reconcile.
+var applicationPredicateFunc = predicate.Funcs{
+	UpdateFunc: func(e event.UpdateEvent) bool {
+		if e.ObjectNew.GetResourceVersion() == e.ObjectOld.GetResourceVersion() {
+			return true
+		}
+
+		if e.ObjectNew.GetGeneration() != e.ObjectOld.GetGeneration() {
+			return true
+		}
+
+		if settingsAnnotationsChanged(e.ObjectOld.GetAnnotations(), e.ObjectNew.GetAnnotations()) {
+			return true
+		}
+
+		if _, ok := e.ObjectNew.GetAnnotations()[utils.AnnotationRebuildStatus]; ok {
+			return true
+		}
+
+		return e.ObjectNew.GetDeletionTimestamp() != nil
+	},
+}
+
+// primaryApplicationPredicate is applicationPredicateFunc counting the updates it skips, it is set on the watch
+// of the applications themselves only so each skipped update is counted once
+var primaryApplicationPredicate = predicate.Funcs{
+	UpdateFunc: func(e event.UpdateEvent) bool {
+		if applicationPredicateFunc.Update(e) {
+			return true
+		}
+
+		shortCircuitedReconciles.Inc()
+
+		return false
+	},
+}
+
 // add adds a new Controller to mgr with r as the reconcile.Reconciler
 func add(mgr manager.Manager, r reconcile.Reconciler) error {
 	// Create a new controller
-	c, err := controller.New("application-controller", mgr, controller.Options{Reconciler: r})
+	c, err := controller.New(controllerName, mgr, controller.Options{Reconciler: r})
 	if err != nil {
 		return err
 	}
 
+	registerApplicationReadiness()
+
+	// The initial pass of the ordered startup holds back the create events of the applications
+	startup := predicate.Funcs{}
+
+	if Options.OrderedStartup || Options.PrioritizeStaleStatus {
+		order := &startupOrder{
+			cache:       mgr.GetCache(),
+			reader:      mgr.GetClient(),
+			events:      startupEvents,
+			leavesFirst: Options.OrderedStartup,
+			staleFirst:  Options.PrioritizeStaleStatus,
+		}
+		startup = order.predicate()
+
+		if err := mgr.Add(manager.RunnableFunc(order.Start)); err != nil {
+			return err
+		}
+
+		err = c.Watch(&source.Channel{Source: startupEvents}, &handler.EnqueueRequestForObject{})
+		if err != nil {
+			return err
+		}
+	}
+
+	scope := scopePredicate(Options.ApplicationSelector)
+
 	// Watch for changes to primary resource Application
-	err = c.Watch(&source.Kind{Type: &appv1beta1.Application{}}, &handler.EnqueueRequestForObject{})
+	err = c.Watch(&source.Kind{Type: &appv1beta1.Application{}}, &handler.EnqueueRequestForObject{}, scope, primaryApplicationPredicate, startup)
+	if err != nil {
+		return err
+	}
+
+	if Options.ApplicationSelector != nil {
+		err = c.Watch(&source.Kind{Type: &appv1beta1.Application{}}, &handler.EnqueueRequestForObject{},
+			scopeEnteredPredicate(Options.ApplicationSelector))
+		if err != nil {
+			return err
+		}
+	}
+
+	// Watch for changes to the child applications, their status included, to roll their health up in the parent
+	err = c.Watch(&source.Kind{Type: &appv1beta1.Application{}}, handler.EnqueueRequestsFromMapFunc(mapParent), startup)
+	if err != nil {
+		return err
+	}
+
+	// Watch for the applications enqueued through the resync endpoint
+	err = c.Watch(&source.Channel{Source: resyncEvents}, &handler.EnqueueRequestForObject{})
+	if err != nil {
+		return err
+	}
+
+	// Watch for changes to the copies of template applications
+	err = c.Watch(&source.Kind{Type: &appv1beta1.Application{}}, handler.EnqueueRequestsFromMapFunc(mapTemplateCopy), scope,
+		applicationPredicateFunc)
 	if err != nil {
 		return err
 	}
 
+	// Watch for changes to the ConfigMaps the applications resolve their components from, only their metadata
+	// is cached and the content is read from the API server on reconcile
+	configMaps := &metav1.PartialObjectMetadata{}
+	configMaps.SetGroupVersionKind(corev1.SchemeGroupVersion.WithKind("ConfigMap"))
+
+	err = c.Watch(&source.Kind{Type: configMaps}, handler.EnqueueRequestsFromMapFunc(configMapReferences.Map))
+	if err != nil {
+		return err
+	}
+
+	// Watch for the applications listed in the trigger ConfigMap
+	if Options.TriggerConfigMap.Name != "" {
+		trigger := &triggerMapper{reader: mgr.GetAPIReader(), writer: mgr.GetClient(), key: Options.TriggerConfigMap}
+
+		err = c.Watch(&source.Kind{Type: configMaps}, handler.EnqueueRequestsFromMapFunc(trigger.Map),
+			triggerPredicate(Options.TriggerConfigMap))
+		if err != nil {
+			return err
+		}
+	}
+
+	// Watch for new and relabeled namespaces to materialize the template applications into
+	tmapper := &templateMapper{mgr.GetClient()}
+
+	err = c.Watch(
+		&source.Kind{Type: &corev1.Namespace{}},
+		handler.EnqueueRequestsFromMapFunc(tmapper.Map),
+		predicate.LabelChangedPredicate{})
+	if err != nil {
+		return err
+	}
+
+	// Watch for the CustomResourceDefinitions becoming established to resolve the kinds installed after the
+	// applications referencing them, provided the RBAC of the operator lets it watch them
+	if canWatchCRDs(context.TODO(), mgr.GetClient()) {
+		crds := &unstructured.Unstructured{}
+		crds.SetGroupVersionKind(crdGVK)
+
+		err = c.Watch(&source.Kind{Type: crds}, handler.EnqueueRequestsFromMapFunc((&crdMapper{mgr.GetClient()}).Map),
+			crdEstablishedPredicate)
+		if err != nil {
+			return err
+		}
+	}
+
 	// Watch for changes to Deployable
 	dmapper := &deployableMapper{mgr.GetClient()}
 
@@ -189,8 +512,21 @@ type ReconcileApplication struct {
 	// This client, initialized using mgr.Client() above, is a split client
 	// that reads objects from the cache and writes to the apiserver
 	client.Client
+	// apiReader reads objects that are not worth caching, such as the ConfigMaps referenced by applications
+	apiReader     client.Reader
 	scheme        *runtime.Scheme
+	mapper        meta.RESTMapper
 	eventRecorder *utils.EventRecorder
+	options       ReconcileOptions
+	// conversionFailures holds the time the applications failed to convert since, keyed by the namespaced name
+	conversionFailures sync.Map
+	// componentReader lists the components from the informer cache, nil to list them from the API server
+	componentReader client.Reader
+	// breaker is shared by the reconciles to skip the component kinds failing to list, nil to list every kind
+	breaker *kindBreaker
+	// impersonate returns the reader impersonating a user, nil when the applications cannot be resolved as a
+	// ServiceAccount
+	impersonate func(user string) (client.Reader, error)
 }
 
 // Reconcile reads that state of the cluster for a Application object and makes changes based on the state read
@@ -199,6 +535,24 @@ type ReconcileApplication struct {
 // The Controller will requeue the Request to be processed again if the returned error is non-nil or
 // Result.Requeue is true, otherwise upon completion it will remove the work from the queue.
 func (r *ReconcileApplication) Reconcile(ctx context.Context, request reconcile.Request) (reconcile.Result, error) {
+	summary := newReconcileSummary()
+
+	result, err := r.reconcile(ctx, request, summary)
+	summary.log(request.NamespacedName, err)
+
+	partial := summary.partial
+	if ctx.Err() != nil {
+		partial = fmt.Errorf("reconcile interrupted: %w", ctx.Err())
+	}
+
+	r.recordReconcileOutcome(request.NamespacedName, newReconcileOutcome(err, partial))
+
+	return result, err
+}
+
+// reconcile is the body of Reconcile, it records the timing of its phases in the summary
+func (r *ReconcileApplication) reconcile(ctx context.Context, request reconcile.Request,
+	summary *reconcileSummary) (reconcile.Result, error) {
 	// Fetch the Deployable instance
 	instance := &appv1beta1.Application{}
 	err := r.Get(ctx, request.NamespacedName, instance)
@@ -206,25 +560,265 @@ func (r *ReconcileApplication) Reconcile(ctx context.Context, request reconcile.
 
 	if err != nil {
 		if errors.IsNotFound(err) {
+			appHealth.forget(request.NamespacedName)
+			configMapReferences.forget(request.NamespacedName)
+
 			// Object not found, return.  Created objects are automatically garbage collected.
 			// validate all deployables, remove the deployables whose hosting deployables are gone
 			klog.Info("Reconciling - finished.", request.NamespacedName, " with Get err:", err)
 
 			return reconcile.Result{}, err
 		}
+		if isConversionError(err) {
+			return r.backOffConversion(request.NamespacedName, err)
+		}
+
 		// Error reading the object - requeue the request.
 		klog.Info("Reconciling - finished.", request.NamespacedName, " with Get err:", err)
 
 		return reconcile.Result{}, err
 	}
 
-	oldInstance := instance.DeepCopy()
+	// the requests mapped from other resources may name the applications of another operator instance
+	if !inScope(r.options.ApplicationSelector, instance) {
+		klog.V(1).Info("Reconciling - skipped application ", request.NamespacedName, " out of the scope of the operator")
+
+		return reconcile.Result{}, nil
+	}
+
+	if instance.DeletionTimestamp != nil {
+		return r.finalizeTemplate(ctx, instance)
+	}
+
+	pause, err := utils.GetPauseMode(instance)
+	if err != nil {
+		klog.Error("Freezing application ", request.NamespacedName, " error: ", err)
+	}
 
-	r.doAppHubReconcile(instance)
+	if pause == utils.PauseAll {
+		klog.Info("Reconciling - skipped paused application ", request.NamespacedName)
+
+		return reconcile.Result{}, nil
+	}
+
+	// the components are left untouched in read-only mode and while the mutations are paused
+	mutate := !r.options.ReadOnly && pause != utils.PauseMutations
 
 	result := reconcile.Result{}
 
-	if utils.UpdateAppInstance(oldInstance, instance) {
+	var terminatingNamespaces []string
+
+	if utils.IsTemplate(instance) {
+		// the copies are left as they are while the components are not written
+		if mutate {
+			var err error
+
+			terminatingNamespaces, err = r.reconcileTemplate(ctx, instance)
+			if err != nil {
+				klog.Error("Failed to sync the copies of template application ", request.NamespacedName, " error: ", err)
+
+				result.Requeue = true
+			}
+		}
+	} else if controllerutil.ContainsFinalizer(instance, templateCleanupFinalizer) {
+		// the application is no longer a template, its copies are left in place
+		controllerutil.RemoveFinalizer(instance, templateCleanupFinalizer)
+
+		if err := r.Update(ctx, instance); err != nil {
+			return reconcile.Result{}, err
+		}
+	}
+
+	if err := ctx.Err(); err != nil {
+		return r.interruptReconcile(instance, "template fan-out", err)
+	}
+
+	oldInstance := instance.DeepCopy()
+
+	clusters := r.doAppHubReconcile(instance)
+
+	configMapReferences.trackConfigMapReference(instance)
+
+	done := summary.time(phaseResolve)
+	resolution := r.resolveComponents(ctx, instance)
+	if resolution.interrupted == nil {
+		r.resolveChildren(ctx, instance, resolution)
+	}
+	done()
+
+	summary.components = len(resolution.components)
+	summary.failures = len(resolution.failures)
+
+	if len(resolution.failures) > 0 {
+		summary.partial = fmt.Errorf("%s", resolution.failureMessage())
+	}
+
+	if resolution.interrupted != nil {
+		return r.interruptReconcile(instance, "component resolution", resolution.interrupted)
+	}
+
+	r.recordSelectorSuggestions(ctx, instance, resolution)
+
+	done = summary.time(phasePatch)
+
+	var ownerRefsForbidden []string
+
+	budget := newWriteBudget(r.options.WriteBudget)
+
+	if instance.Spec.AddOwnerRef && mutate {
+		ownerRefsForbidden = r.setOwnerRefs(ctx, instance, resolution.components, budget)
+	} else if mutate {
+		r.removeOwnerRefs(ctx, instance, resolution.components, budget)
+	}
+
+	if utils.IsSoftOwner(instance) && mutate {
+		r.setSoftOwner(ctx, instance, resolution.components, budget)
+	}
+
+	propagated, err := utils.GetPropagatedLabels(instance)
+	if err != nil {
+		klog.Error("Failed to get the labels to propagate of application ", request.NamespacedName, " error: ", err)
+	}
+
+	var (
+		propagatedAnnotations map[string]string
+		annotationsErr        error
+	)
+
+	if utils.FeatureEnabled(utils.AnnotationPropagation) {
+		propagatedAnnotations, annotationsErr = utils.GetPropagatedAnnotations(instance)
+		if annotationsErr != nil {
+			klog.Error("Failed to get the annotations to propagate of application ", request.NamespacedName, " error: ", annotationsErr)
+		}
+	}
+
+	// nothing is propagated while either is invalid, the labels and annotations in place are left untouched
+	if err == nil && annotationsErr == nil && mutate {
+		policy, err := utils.GetLabelCleanupPolicy(instance)
+		if err != nil {
+			klog.Error("Keeping the stale propagated labels of application ", request.NamespacedName, " error: ", err)
+		}
+
+		r.propagateLabels(ctx, instance, resolution.components, propagated, propagatedAnnotations, policy, budget)
+	}
+
+	done()
+
+	if err := ctx.Err(); err != nil {
+		return r.interruptReconcile(instance, "component updates", err)
+	}
+
+	done = summary.time(phaseResolve)
+	required, requiredErr := r.checkRequiredComponents(ctx, instance)
+	done()
+
+	if err := ctx.Err(); err != nil {
+		return r.interruptReconcile(instance, "required components check", err)
+	}
+
+	done = summary.time(phaseHealth)
+
+	_, rebuildStatus := instance.GetAnnotations()[utils.AnnotationRebuildStatus]
+
+	newStatus := instance.Status.DeepCopy()
+	if rebuildStatus {
+		// the prior status is not trusted, every field and condition is recomputed from the cluster state
+		klog.Info("Rebuilding the status of application ", request.NamespacedName)
+
+		newStatus = &appv1beta1.ApplicationStatus{}
+	}
+
+	policy := r.healthPolicy(instance)
+	rollup := updateComponentStatus(newStatus, resolution, policy)
+	clusterHealth := clusters.rollup(policy)
+	appHealth.record(request.NamespacedName, rollup.state, r.options.HealthMetricsByNamespace)
+	forbidden := ""
+	if r.failsOnForbidden(instance) {
+		forbidden = r.forbiddenMessage(resolution, ownerRefsForbidden)
+	}
+
+	updateRequiredComponentsStatus(newStatus, required, requiredErr)
+	updateForbiddenCondition(newStatus, forbidden)
+
+	updateOwnerRefStatus(newStatus, ownerRefsForbidden)
+	updateTerminatingNamespacesCondition(newStatus, terminatingNamespaces)
+	updateSuspendedKindsCondition(newStatus, resolution.suspended)
+	updatePausedCondition(newStatus, pause)
+	updateClustersCondition(newStatus, clusterHealth, policy)
+	r.updateSelectorDiagnosticsCondition(ctx, instance, resolution, newStatus)
+	updateWriteBudgetCondition(newStatus, budget)
+
+	ttl, hasTTL, err := utils.GetTTLAfterSucceeded(instance)
+	if err != nil {
+		klog.Error("Ignoring the TTL after succeeded of application ", request.NamespacedName, " error: ", err)
+	}
+
+	updateAssemblySucceededCondition(newStatus, instance, hasTTL && err == nil)
+	r.updateConversionCondition(request.NamespacedName, newStatus)
+	newStatus.ObservedGeneration = instance.Generation
+
+	grace := r.options.ComponentsGracePeriod
+
+	waitFor, escalated := updateWaitingCondition(newStatus, resolution, instance.CreationTimestamp.Time, grace)
+	if escalated {
+		msg := "The app still has no components " + grace.String() + " after its creation. App:" +
+			instance.Namespace + "/" + instance.Name
+		r.eventRecorder.RecordEvent(instance, noComponentsAfterGracePeriod, msg, fmt.Errorf("no components"))
+	}
+
+	// reconcile again as the grace period elapses, the components may never show up to trigger it
+	result.RequeueAfter = waitFor
+
+	// the health is refreshed by polling as well, for the components without watches
+	interval := r.reconcileInterval(instance)
+	if interval > 0 && (result.RequeueAfter == 0 || interval < result.RequeueAfter) {
+		result.RequeueAfter = interval
+	}
+
+	// the suspended kinds are listed again once their breaker lets a probe through
+	if retryIn := resolution.retryIn; retryIn > 0 && (result.RequeueAfter == 0 || retryIn < result.RequeueAfter) {
+		result.RequeueAfter = retryIn
+	}
+
+	// the deferred patches are made shortly after, spread over the next reconciles
+	if budget.exhausted() {
+		delay := r.options.WriteBudgetRequeueDelay
+		if result.RequeueAfter == 0 || delay < result.RequeueAfter {
+			result.RequeueAfter = delay
+		}
+	}
+
+	if len(resolution.failures) > 0 || (required != nil && len(required.failed) > 0) || forbidden != "" {
+		// the components of the kinds that succeeded are still reported, requeue to retry the failed kinds
+		result.Requeue = true
+	}
+
+	done()
+
+	done = summary.time(phaseStatus)
+	defer done()
+
+	statusChanged := !equality.Semantic.DeepEqual(newStatus, &instance.Status)
+	annotationsChanged := utils.UpdateAppInstance(oldInstance, instance)
+
+	if r.setDashboardLinks(instance) {
+		annotationsChanged = true
+	}
+
+	if r.setExportedComponents(instance, resolution.components) {
+		annotationsChanged = true
+	}
+
+	if r.setClusterComponents(instance, clusterHealth) {
+		annotationsChanged = true
+	}
+
+	// the replica is only recorded along genuine changes, and written on its own when another replica wrote last
+	if (annotationsChanged || statusChanged) && r.recordReconciler(instance) {
+		annotationsChanged = true
+	}
+
+	if annotationsChanged {
 		klog.V(1).Infoln("Update app annotation", instance.Annotations)
 
 		addtionalMsg := "The app annotations updated. App:" + instance.Namespace + "/" + instance.Name
@@ -232,10 +826,134 @@ func (r *ReconcileApplication) Reconcile(ctx context.Context, request reconcile.
 
 		err = r.Update(ctx, instance)
 		if err != nil {
+			if isConversionError(err) {
+				return r.backOffConversion(request.NamespacedName, err)
+			}
+
 			klog.Error("Error returned when updating application :", err, "instance:", instance.GetNamespace()+"/"+instance.GetName())
 			return reconcile.Result{}, err
 		}
 	}
 
+	r.publishStatus(ctx, instance, newStatus, rollup)
+
+	if statusChanged {
+		transition := healthTransition(&instance.Status, newStatus)
+		instance.Status = *newStatus
+
+		err = r.Status().Update(ctx, instance)
+		if err != nil {
+			if isConversionError(err) {
+				return r.backOffConversion(request.NamespacedName, err)
+			}
+
+			klog.Error("Error returned when updating application status :", err, "instance:", instance.GetNamespace()+"/"+instance.GetName())
+			return reconcile.Result{}, err
+		}
+
+		// the automation waiting on the applications reacts to the events rather than polling the status
+		if transition != "" {
+			r.eventRecorder.RecordEvent(instance, string(transition), "The app is "+string(transition)+": "+
+				rollup.message()+". App:"+instance.Namespace+"/"+instance.Name, nil)
+		}
+	}
+
+	if rebuildStatus {
+		// consumed only once the rebuilt status is written, a failed status update rebuilds again on retry
+		if err := r.consumeRebuildStatus(ctx, instance); err != nil {
+			klog.Error("Failed to remove the rebuild status annotation of application ", request.NamespacedName, " error: ", err)
+			return reconcile.Result{}, err
+		}
+	}
+
+	// the Succeeded applications are deleted past their TTL, unless the writes of the controller are held back
+	if remaining, ok := ttlRemaining(&instance.Status, ttl, time.Now()); ok && mutate {
+		if remaining <= 0 {
+			return reconcile.Result{}, r.deleteExpired(ctx, instance)
+		}
+
+		if result.RequeueAfter == 0 || remaining < result.RequeueAfter {
+			result.RequeueAfter = remaining
+		}
+	}
+
 	return result, nil
 }
+
+// consumeRebuildStatus removes the rebuild status annotation so the rebuild is not repeated
+func (r *ReconcileApplication) consumeRebuildStatus(ctx context.Context, app *appv1beta1.Application) error {
+	orig := app.DeepCopy()
+	delete(app.Annotations, utils.AnnotationRebuildStatus)
+
+	return r.Patch(ctx, app, client.MergeFrom(orig))
+}
+
+// recordReconciler sets the last reconciled by annotation to this replica, it returns true when it changed
+func (r *ReconcileApplication) recordReconciler(app *appv1beta1.Application) bool {
+	if r.options.ReconcilerID == "" || app.GetAnnotations()[utils.AnnotationLastReconciledBy] == r.options.ReconcilerID {
+		return false
+	}
+
+	if app.Annotations == nil {
+		app.Annotations = map[string]string{}
+	}
+
+	app.Annotations[utils.AnnotationLastReconciledBy] = r.options.ReconcilerID
+
+	return true
+}
+
+// reconcileInterval returns how long after a successful reconcile the application is reconciled again, 0 when
+// it is only reconciled on changes
+func (r *ReconcileApplication) reconcileInterval(app *appv1beta1.Application) time.Duration {
+	interval, found, err := utils.GetReconcileInterval(app)
+	if err != nil {
+		klog.Error("Falling back to the operator reconcile interval for application ", app.Namespace+"/"+app.Name, " error: ", err)
+	}
+
+	if !found || err != nil {
+		interval = r.options.ReconcileInterval
+	}
+
+	if interval > 0 && interval < r.options.MinReconcileInterval {
+		interval = r.options.MinReconcileInterval
+	}
+
+	return interval
+}
+
+// healthPolicy combines the operator and application settings of the health rollup
+func (r *ReconcileApplication) healthPolicy(app *appv1beta1.Application) healthPolicy {
+	mode, err := utils.GetMode(app)
+	if err != nil {
+		klog.Error("Falling back to the default mode for application ", app.Namespace+"/"+app.Name, " error: ", err)
+	}
+
+	aggregation, err := utils.GetHealthAggregation(app)
+	if err != nil {
+		klog.Error("Falling back to the All health aggregation for application ", app.Namespace+"/"+app.Name, " error: ", err)
+	}
+
+	maxComponents, found, err := utils.GetHealthMaxComponents(app)
+	if err != nil {
+		klog.Error("Falling back to the operator health max components for application ", app.Namespace+"/"+app.Name, " error: ", err)
+	}
+
+	if !found || err != nil {
+		maxComponents = r.options.HealthMaxComponents
+	}
+
+	weights, err := utils.GetHealthWeights(app)
+	if err != nil {
+		klog.Error("Falling back to the same health weight for every component of application ", app.Namespace+"/"+app.Name, " error: ", err)
+	}
+
+	return healthPolicy{
+		terminatingGrace:  r.options.TerminatingGracePeriod,
+		newComponentGrace: r.options.NewComponentGracePeriod,
+		aggregation:       aggregation,
+		maxComponents:     maxComponents,
+		weights:           weights,
+		inventory:         mode == utils.ModeInventory,
+	}
+}
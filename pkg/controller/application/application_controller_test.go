@@ -26,10 +26,12 @@ import (
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/klog"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
 
 	dplv1 "github.com/open-cluster-management/multicloud-operators-deployable/pkg/apis/apps/v1"
 	subv1 "github.com/open-cluster-management/multicloud-operators-subscription/pkg/apis/apps/v1"
+	"github.com/stolostron/multicloud-operators-application/utils"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	appv1beta1 "sigs.k8s.io/application/api/v1beta1"
 )
@@ -170,3 +172,69 @@ func TestReconcile(t *testing.T) {
 	g.Expect(instanceApp.Annotations["apps.open-cluster-management.io/deployables"]).To(gomega.Equal(deployableKey.String()))
 	g.Expect(instanceApp.Annotations["apps.open-cluster-management.io/subscriptions"]).To(gomega.Equal(subscriptionKey.String()))
 }
+
+func TestApplicationPredicate(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	oldApp := &appv1beta1.Application{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-app", Namespace: "default", Generation: 1, ResourceVersion: "1"},
+	}
+
+	// periodic resync
+	g.Expect(applicationPredicateFunc.Update(event.UpdateEvent{ObjectOld: oldApp, ObjectNew: oldApp.DeepCopy()})).To(gomega.BeTrue())
+
+	newApp := oldApp.DeepCopy()
+	newApp.ResourceVersion = "2"
+	newApp.Labels = map[string]string{"team": "a"}
+	g.Expect(applicationPredicateFunc.Update(event.UpdateEvent{ObjectOld: oldApp, ObjectNew: newApp})).To(gomega.BeFalse())
+
+	newApp.Annotations = map[string]string{utils.AnnotationReconcileRequest: "1"}
+	g.Expect(applicationPredicateFunc.Update(event.UpdateEvent{ObjectOld: oldApp, ObjectNew: newApp})).To(gomega.BeTrue())
+
+	newApp = oldApp.DeepCopy()
+	newApp.Generation = 2
+	g.Expect(applicationPredicateFunc.Update(event.UpdateEvent{ObjectOld: oldApp, ObjectNew: newApp})).To(gomega.BeTrue())
+
+	newApp = oldApp.DeepCopy()
+	newApp.ResourceVersion = "2"
+	newApp.Annotations = map[string]string{utils.AnnotationRebuildStatus: ""}
+	g.Expect(applicationPredicateFunc.Update(event.UpdateEvent{ObjectOld: oldApp, ObjectNew: newApp})).To(gomega.BeTrue())
+
+	// the settings annotations do not bump the generation
+	newApp = oldApp.DeepCopy()
+	newApp.ResourceVersion = "2"
+	newApp.Annotations = map[string]string{utils.AnnotationHealthAggregation: "percentage"}
+	g.Expect(applicationPredicateFunc.Update(event.UpdateEvent{ObjectOld: oldApp, ObjectNew: newApp})).To(gomega.BeTrue())
+
+	oldApp.Annotations = map[string]string{utils.AnnotationRequiredComponents: `["a"]`}
+	newApp = oldApp.DeepCopy()
+	newApp.ResourceVersion = "2"
+	newApp.Annotations[utils.AnnotationRequiredComponents] = `["a","b"]`
+	g.Expect(applicationPredicateFunc.Update(event.UpdateEvent{ObjectOld: oldApp, ObjectNew: newApp})).To(gomega.BeTrue())
+
+	newApp = oldApp.DeepCopy()
+	newApp.ResourceVersion = "2"
+	delete(newApp.Annotations, utils.AnnotationRequiredComponents)
+	g.Expect(applicationPredicateFunc.Update(event.UpdateEvent{ObjectOld: oldApp, ObjectNew: newApp})).To(gomega.BeTrue())
+
+	// the annotations written by the controller and the foreign ones do not
+	newApp = oldApp.DeepCopy()
+	newApp.ResourceVersion = "2"
+	newApp.Annotations[utils.AnnotationReconcileHistory] = "[]"
+	newApp.Annotations[utils.AnnotationLastReconciledBy] = "replica-1"
+	newApp.Annotations["example.com/note"] = "x"
+	g.Expect(applicationPredicateFunc.Update(event.UpdateEvent{ObjectOld: oldApp, ObjectNew: newApp})).To(gomega.BeFalse())
+}
+
+func TestRecordReconciler(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	app := &appv1beta1.Application{ObjectMeta: metav1.ObjectMeta{Name: "test-app", Namespace: "default"}}
+
+	g.Expect((&ReconcileApplication{}).recordReconciler(app)).To(gomega.BeFalse())
+
+	r := &ReconcileApplication{options: ReconcileOptions{ReconcilerID: "operator-0"}}
+	g.Expect(r.recordReconciler(app)).To(gomega.BeTrue())
+	g.Expect(app.Annotations[utils.AnnotationLastReconciledBy]).To(gomega.Equal("operator-0"))
+	g.Expect(r.recordReconciler(app)).To(gomega.BeFalse())
+}
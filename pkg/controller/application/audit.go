@@ -0,0 +1,132 @@
+// Copyright 2019 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package application
+
+import (
+	"encoding/json"
+	"io"
+	"sort"
+	"sync"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog"
+	appv1beta1 "sigs.k8s.io/application/api/v1beta1"
+)
+
+// The changes to the components recorded in the audit log
+const (
+	AuditOwnerReferenceAdded   = "OwnerReferenceAdded"
+	AuditOwnerReferenceRemoved = "OwnerReferenceRemoved"
+	AuditLabelsPropagated      = "LabelsPropagated"
+)
+
+// AuditTarget is the component an audit entry changed
+type AuditTarget struct {
+	Group     string    `json:"group,omitempty"`
+	Kind      string    `json:"kind"`
+	Namespace string    `json:"namespace,omitempty"`
+	Name      string    `json:"name"`
+	UID       types.UID `json:"uid"`
+}
+
+// AuditEntry is a change the controller made to a component, written as a JSON line
+type AuditEntry struct {
+	Time metav1.Time `json:"time"`
+	// Actor is the pod of the operator replica that made the change
+	Actor                string      `json:"actor"`
+	Action               string      `json:"action"`
+	ApplicationNamespace string      `json:"applicationNamespace"`
+	ApplicationName      string      `json:"applicationName"`
+	ApplicationUID       types.UID   `json:"applicationUID"`
+	Target               AuditTarget `json:"target"`
+	// OwnerUID is the application the owner reference added or removed points at
+	OwnerUID types.UID `json:"ownerUID,omitempty"`
+	// SetLabels and RemovedLabels are the propagated labels written and removed, likewise for the annotations
+	SetLabels          map[string]string `json:"setLabels,omitempty"`
+	RemovedLabels      []string          `json:"removedLabels,omitempty"`
+	SetAnnotations     map[string]string `json:"setAnnotations,omitempty"`
+	RemovedAnnotations []string          `json:"removedAnnotations,omitempty"`
+}
+
+// AuditLog writes the changes the controller made to the components as JSON lines, apart from the operational
+// logs. A nil AuditLog records nothing.
+type AuditLog struct {
+	lock  sync.Mutex
+	enc   *json.Encoder
+	actor string
+}
+
+// NewAuditLog returns an audit log writing to w, the entries name actor, the pod of the operator replica
+func NewAuditLog(w io.Writer, actor string) *AuditLog {
+	return &AuditLog{enc: json.NewEncoder(w), actor: actor}
+}
+
+// record writes the entry for the change of the component made for the application, a failed write is logged
+func (a *AuditLog) record(app *appv1beta1.Application, u *unstructured.Unstructured, entry AuditEntry) {
+	if a == nil {
+		return
+	}
+
+	entry.Time = metav1.Now()
+	entry.Actor = a.actor
+	entry.ApplicationNamespace = app.Namespace
+	entry.ApplicationName = app.Name
+	entry.ApplicationUID = app.UID
+	entry.Target = AuditTarget{
+		Group:     u.GroupVersionKind().Group,
+		Kind:      u.GetKind(),
+		Namespace: u.GetNamespace(),
+		Name:      u.GetName(),
+		UID:       u.GetUID(),
+	}
+
+	a.lock.Lock()
+	defer a.lock.Unlock()
+
+	if err := a.enc.Encode(entry); err != nil {
+		klog.Error("Failed to write the audit entry ", entry.Action, " of application ", app.Namespace+"/"+app.Name, " error: ", err)
+	}
+}
+
+// diffEntries returns the entries of after that are new or changed from before, and the sorted keys of before
+// missing from after
+func diffEntries(before, after map[string]string) (map[string]string, []string) {
+	var (
+		set     map[string]string
+		removed []string
+	)
+
+	for k, v := range after {
+		if cur, ok := before[k]; !ok || cur != v {
+			if set == nil {
+				set = map[string]string{}
+			}
+
+			set[k] = v
+		}
+	}
+
+	for k := range before {
+		if _, ok := after[k]; !ok {
+			removed = append(removed, k)
+		}
+	}
+
+	sort.Strings(removed)
+
+	return set, removed
+}
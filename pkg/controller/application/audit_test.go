@@ -0,0 +1,98 @@
+// Copyright 2019 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package application
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/onsi/gomega"
+	"github.com/stolostron/multicloud-operators-application/utils"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func decodeAuditEntries(g *gomega.WithT, buf *bytes.Buffer) []AuditEntry {
+	var entries []AuditEntry
+
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		entry := AuditEntry{}
+		g.Expect(json.Unmarshal([]byte(line), &entry)).To(gomega.Succeed())
+
+		entries = append(entries, entry)
+	}
+
+	buf.Reset()
+
+	return entries
+}
+
+func TestAuditLog(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	r := newTestReconciler(newTestConfigMap("matched", map[string]string{"app": "test-app"}))
+
+	buf := &bytes.Buffer{}
+	r.options.AuditLog = NewAuditLog(buf, "application-manager-0")
+
+	app := newTestApplication(configMapGK)
+	app.UID = types.UID("test-app-uid")
+	app.Annotations = map[string]string{utils.AnnotationPropagateLabels: `{"team":"a"}`}
+
+	res := r.resolveComponents(context.TODO(), app)
+	g.Expect(res.components).To(gomega.HaveLen(1))
+
+	r.setOwnerRefs(context.TODO(), app, res.components, nil)
+
+	entries := decodeAuditEntries(g, buf)
+	g.Expect(entries).To(gomega.HaveLen(1))
+	g.Expect(entries[0].Action).To(gomega.Equal(AuditOwnerReferenceAdded))
+	g.Expect(entries[0].Actor).To(gomega.Equal("application-manager-0"))
+	g.Expect(entries[0].ApplicationUID).To(gomega.Equal(app.UID))
+	g.Expect(entries[0].OwnerUID).To(gomega.Equal(app.UID))
+	g.Expect(entries[0].Target).To(gomega.Equal(AuditTarget{Kind: "ConfigMap", Namespace: "default", Name: "matched"}))
+	g.Expect(entries[0].Time.IsZero()).To(gomega.BeFalse())
+
+	propagated, err := utils.GetPropagatedLabels(app)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	r.propagateLabels(context.TODO(), app, res.components, propagated, nil, utils.LabelCleanupPolicyRemove, nil)
+
+	entries = decodeAuditEntries(g, buf)
+	g.Expect(entries).To(gomega.HaveLen(1))
+	g.Expect(entries[0].Action).To(gomega.Equal(AuditLabelsPropagated))
+	g.Expect(entries[0].SetLabels).To(gomega.Equal(map[string]string{"team": "a", utils.LabelPropagatedBy: "test-app-uid"}))
+	g.Expect(entries[0].SetAnnotations).To(gomega.HaveKeyWithValue(utils.AnnotationPropagatedLabelKeys, "team"))
+
+	// the labels no longer propagated are recorded as removed
+	app.Annotations[utils.AnnotationPropagateLabels] = `{}`
+	propagated, err = utils.GetPropagatedLabels(app)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	r.propagateLabels(context.TODO(), app, r.resolveComponents(context.TODO(), app).components, propagated, nil,
+		utils.LabelCleanupPolicyRemove, nil)
+
+	entries = decodeAuditEntries(g, buf)
+	g.Expect(entries).To(gomega.HaveLen(1))
+	g.Expect(entries[0].RemovedLabels).To(gomega.Equal([]string{utils.LabelPropagatedBy, "team"}))
+	g.Expect(entries[0].RemovedAnnotations).To(gomega.Equal([]string{utils.AnnotationPropagatedLabelKeys}))
+
+	// nothing is recorded without an audit log
+	r.options.AuditLog = nil
+	r.setOwnerRefs(context.TODO(), newTestApplication(configMapGK), res.components, nil)
+	g.Expect(buf.Len()).To(gomega.BeZero())
+}
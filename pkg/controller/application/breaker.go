@@ -0,0 +1,162 @@
+// Copyright 2019 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package application
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/klog"
+	appv1beta1 "sigs.k8s.io/application/api/v1beta1"
+)
+
+// DefaultKindBreakerThreshold is the number of consecutive list failures of a kind opening its breaker
+const DefaultKindBreakerThreshold = 5
+
+// DefaultKindBreakerCooldown is how long the breaker of a kind stays open before a list probes the kind again
+const DefaultKindBreakerCooldown = time.Minute
+
+// kindBreaker suspends listing a component kind after consecutive failures across the applications, a kind
+// whose lists keep failing or timing out does not hold up the reconciles of every application listing it.
+// Once the cooldown elapsed, a single list probes the kind, closing the breaker on success and keeping it open
+// for another cooldown on failure.
+type kindBreaker struct {
+	lock      sync.Mutex
+	threshold int
+	cooldown  time.Duration
+	gauge     *prometheus.GaugeVec
+	kinds     map[schema.GroupKind]*kindBreakerState
+	now       func() time.Time
+}
+
+type kindBreakerState struct {
+	failures int
+	openedAt time.Time
+	open     bool
+}
+
+// newKindBreaker returns the breaker of the component kinds, nil when threshold is 0 to never suspend any kind
+func newKindBreaker(threshold int, cooldown time.Duration, gauge *prometheus.GaugeVec) *kindBreaker {
+	if threshold <= 0 {
+		return nil
+	}
+
+	return &kindBreaker{
+		threshold: threshold,
+		cooldown:  cooldown,
+		gauge:     gauge,
+		kinds:     map[schema.GroupKind]*kindBreakerState{},
+		now:       time.Now,
+	}
+}
+
+// allow returns true if the kind can be listed, otherwise the time left until it is probed again
+func (b *kindBreaker) allow(gk schema.GroupKind) (bool, time.Duration) {
+	if b == nil {
+		return true, 0
+	}
+
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	state, ok := b.kinds[gk]
+	if !ok || !state.open {
+		return true, 0
+	}
+
+	if left := state.openedAt.Add(b.cooldown).Sub(b.now()); left > 0 {
+		return false, left
+	}
+
+	// the probe restarts the cooldown, the other reconciles keep skipping the kind meanwhile and a probe lost to
+	// an interrupted reconcile is made again after another cooldown
+	state.openedAt = b.now()
+
+	return true, 0
+}
+
+// record counts the outcome of a list of the kind, a nil error closes its breaker
+func (b *kindBreaker) record(gk schema.GroupKind, err error) {
+	if b == nil {
+		return
+	}
+
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	state, ok := b.kinds[gk]
+
+	if err == nil {
+		if ok {
+			if state.open {
+				klog.Info("Closing the circuit breaker of kind ", gk.String(), ", its components are listed again")
+			}
+
+			delete(b.kinds, gk)
+			b.gauge.WithLabelValues(gk.String()).Set(0)
+		}
+
+		return
+	}
+
+	if !ok {
+		state = &kindBreakerState{}
+		b.kinds[gk] = state
+	}
+
+	state.failures++
+
+	if state.open {
+		// the probe failed
+		state.openedAt = b.now()
+		return
+	}
+
+	if state.failures >= b.threshold {
+		klog.Warning("Opening the circuit breaker of kind ", gk.String(), " after ", state.failures,
+			" consecutive list failures, error: ", err)
+
+		state.open = true
+		state.openedAt = b.now()
+
+		b.gauge.WithLabelValues(gk.String()).Set(1)
+	}
+}
+
+// suspendedKindError is the resolution error of a kind whose breaker is open
+func suspendedKindError(gk schema.GroupKind, retryIn time.Duration) error {
+	return fmt.Errorf("listing kind %s is suspended after consecutive failures across the applications, retried in %s",
+		gk.String(), retryIn.Round(time.Second))
+}
+
+// updateSuspendedKindsCondition names the component kinds that were not listed, their breaker being open
+func updateSuspendedKindsCondition(status *appv1beta1.ApplicationStatus, suspended []string) {
+	if len(suspended) == 0 {
+		clearCondition(status, ComponentKindsSuspended, "NoKindSuspended", "every component kind was listed")
+		return
+	}
+
+	sort.Strings(suspended)
+
+	setCondition(status, ComponentKindsSuspended, corev1.ConditionTrue, "CircuitBreakerOpen",
+		"the components of the kinds "+strings.Join(suspended, ", ")+" are not listed after consecutive failures "+
+			"across the applications, they are probed again periodically")
+}
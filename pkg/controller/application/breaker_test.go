@@ -0,0 +1,111 @@
+// Copyright 2019 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package application
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// failingListClient fails the lists while failing is set, as a kind whose API service is unavailable
+type failingListClient struct {
+	client.Client
+	failing bool
+}
+
+func (c *failingListClient) List(ctx context.Context, list client.ObjectList, opts ...client.ListOption) error {
+	if c.failing {
+		return errors.New("the server is currently unable to handle the request")
+	}
+
+	return c.Client.List(ctx, list, opts...)
+}
+
+func TestKindBreaker(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	gauge := prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: "test_component_kind_breaker_open"}, []string{"kind"})
+	now := time.Now()
+
+	r := newTestReconciler(newTestConfigMap("matched", map[string]string{"app": "test-app"}))
+	r.breaker = newKindBreaker(2, time.Minute, gauge)
+	r.breaker.now = func() time.Time { return now }
+
+	c := &failingListClient{Client: r.Client, failing: true}
+	r.Client = c
+
+	app := newTestApplication(configMapGK)
+	kind := schema.GroupKind{Kind: "ConfigMap"}
+
+	for i := 0; i < 2; i++ {
+		res := r.resolveComponents(context.TODO(), app)
+		g.Expect(res.failures).To(gomega.HaveKey(configMapGK.String()))
+		g.Expect(res.suspended).To(gomega.BeEmpty())
+	}
+
+	g.Expect(testutil.ToFloat64(gauge.WithLabelValues(kind.String()))).To(gomega.Equal(1.0))
+
+	// the kind is skipped without listing it
+	c.failing = false
+	now = now.Add(30 * time.Second)
+
+	res := r.resolveComponents(context.TODO(), app)
+	g.Expect(res.failures).To(gomega.BeEmpty())
+	g.Expect(res.suspended).To(gomega.Equal([]string{configMapGK.String()}))
+	g.Expect(res.retryIn).To(gomega.Equal(30 * time.Second))
+
+	updateSuspendedKindsCondition(&app.Status, res.suspended)
+	g.Expect(getCondition(&app.Status, ComponentKindsSuspended).Status).To(gomega.Equal(corev1.ConditionTrue))
+
+	// the failed probe keeps the breaker open for another cooldown
+	c.failing = true
+	now = now.Add(time.Minute)
+
+	res = r.resolveComponents(context.TODO(), app)
+	g.Expect(res.failures).To(gomega.HaveKey(configMapGK.String()))
+
+	allowed, retryIn := r.breaker.allow(kind)
+	g.Expect(allowed).To(gomega.BeFalse())
+	g.Expect(retryIn).To(gomega.Equal(time.Minute))
+
+	// the successful probe closes the breaker
+	c.failing = false
+	now = now.Add(time.Minute)
+
+	res = r.resolveComponents(context.TODO(), app)
+	g.Expect(res.components).To(gomega.HaveLen(1))
+	g.Expect(testutil.ToFloat64(gauge.WithLabelValues(kind.String()))).To(gomega.Equal(0.0))
+
+	updateSuspendedKindsCondition(&app.Status, res.suspended)
+	g.Expect(getCondition(&app.Status, ComponentKindsSuspended).Status).To(gomega.Equal(corev1.ConditionFalse))
+
+	// an unknown kind does not count
+	r.breaker = newKindBreaker(1, time.Minute, gauge)
+	res = r.resolveComponents(context.TODO(), newTestApplication(unknownGK))
+	g.Expect(res.failures).To(gomega.HaveLen(1))
+	g.Expect(r.breaker.kinds).To(gomega.BeEmpty())
+
+	g.Expect(newKindBreaker(0, time.Minute, gauge)).To(gomega.BeNil())
+	g.Expect((*kindBreaker)(nil).allow(kind)).To(gomega.BeTrue())
+}
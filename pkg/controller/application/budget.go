@@ -0,0 +1,76 @@
+// Copyright 2019 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package application
+
+import (
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	appv1beta1 "sigs.k8s.io/application/api/v1beta1"
+)
+
+// DefaultWriteBudgetRequeueDelay spreads the deferred patches of an application over a few reconciles a second
+const DefaultWriteBudgetRequeueDelay = time.Second
+
+// writeBudget counts the component patches of a reconcile, a nil budget is unbounded
+type writeBudget struct {
+	remaining int
+	// deferred counts the patches refused once the budget ran out
+	deferred int
+}
+
+// newWriteBudget returns the budget of a reconcile, nil when size is 0
+func newWriteBudget(size int) *writeBudget {
+	if size <= 0 {
+		return nil
+	}
+
+	return &writeBudget{remaining: size}
+}
+
+// take spends one patch of the budget, it returns false once the budget ran out and the patch is to be deferred
+func (b *writeBudget) take() bool {
+	if b == nil {
+		return true
+	}
+
+	if b.remaining == 0 {
+		b.deferred++
+		return false
+	}
+
+	b.remaining--
+
+	return true
+}
+
+// exhausted returns true if patches were deferred to the next reconcile
+func (b *writeBudget) exhausted() bool {
+	return b != nil && b.deferred > 0
+}
+
+// updateWriteBudgetCondition marks the reconcile incomplete while patches are deferred, the rest of the status
+// is current. The condition is cleared once a reconcile completes within its budget.
+func updateWriteBudgetCondition(appStatus *appv1beta1.ApplicationStatus, budget *writeBudget) {
+	if budget.exhausted() {
+		setCondition(appStatus, ReconcileIncomplete, corev1.ConditionTrue, "WriteBudgetExhausted",
+			fmt.Sprintf("%d component patches deferred to the next reconcile", budget.deferred))
+
+		return
+	}
+
+	clearCondition(appStatus, ReconcileIncomplete, "Completed", "the last reconcile completed")
+}
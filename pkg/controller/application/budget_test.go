@@ -0,0 +1,79 @@
+// Copyright 2019 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package application
+
+import (
+	"context"
+	"testing"
+
+	"github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	appv1beta1 "sigs.k8s.io/application/api/v1beta1"
+)
+
+func TestWriteBudget(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	r := newTestReconciler(
+		newTestConfigMap("first", map[string]string{"app": "test-app"}),
+		newTestConfigMap("second", map[string]string{"app": "test-app"}),
+		newTestConfigMap("third", map[string]string{"app": "test-app"}),
+	)
+
+	app := newTestApplication(configMapGK)
+	app.UID = types.UID("test-app-uid")
+
+	owned := func() int {
+		n := 0
+
+		for _, name := range []string{"first", "second", "third"} {
+			cm := &corev1.ConfigMap{}
+			g.Expect(r.Get(context.TODO(), types.NamespacedName{Namespace: "default", Name: name}, cm)).To(gomega.Succeed())
+
+			if hasOwnerRef(cm, string(app.UID)) {
+				n++
+			}
+		}
+
+		return n
+	}
+
+	status := &appv1beta1.ApplicationStatus{}
+
+	budget := newWriteBudget(2)
+	r.setOwnerRefs(context.TODO(), app, r.resolveComponents(context.TODO(), app).components, budget)
+	g.Expect(owned()).To(gomega.Equal(2))
+	g.Expect(budget.exhausted()).To(gomega.BeTrue())
+
+	updateWriteBudgetCondition(status, budget)
+	cond := getCondition(status, ReconcileIncomplete)
+	g.Expect(cond).NotTo(gomega.BeNil())
+	g.Expect(cond.Status).To(gomega.Equal(corev1.ConditionTrue))
+	g.Expect(cond.Reason).To(gomega.Equal("WriteBudgetExhausted"))
+	g.Expect(cond.Message).To(gomega.HavePrefix("1 component patches deferred"))
+
+	// the next reconcile only spends its budget on the deferred patch
+	budget = newWriteBudget(2)
+	r.setOwnerRefs(context.TODO(), app, r.resolveComponents(context.TODO(), app).components, budget)
+	g.Expect(owned()).To(gomega.Equal(3))
+	g.Expect(budget.exhausted()).To(gomega.BeFalse())
+
+	updateWriteBudgetCondition(status, budget)
+	g.Expect(getCondition(status, ReconcileIncomplete).Status).To(gomega.Equal(corev1.ConditionFalse))
+
+	g.Expect(newWriteBudget(0)).To(gomega.BeNil())
+	g.Expect(newWriteBudget(0).take()).To(gomega.BeTrue())
+}
@@ -0,0 +1,261 @@
+// Copyright 2019 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package application
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	dplv1 "github.com/open-cluster-management/multicloud-operators-deployable/pkg/apis/apps/v1"
+	subv1 "github.com/open-cluster-management/multicloud-operators-subscription/pkg/apis/apps/v1"
+	"github.com/stolostron/multicloud-operators-application/utils"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog"
+	appv1beta1 "sigs.k8s.io/application/api/v1beta1"
+)
+
+// HealthUnreachable is a managed cluster that reported the status of none of the deployables targeting it
+const HealthUnreachable HealthState = "Unreachable"
+
+// clusterComponent is a deployable of the application as reported by a managed cluster
+type clusterComponent struct {
+	name   string
+	phase  dplv1.DeployablePhase
+	reason string
+}
+
+// clusterView groups the deployables of the application by the managed cluster they target
+type clusterView map[string][]clusterComponent
+
+// add records the deployable in the clusters targeted by statusDpl, the deployable itself or the deployable
+// propagating the subscription of the deployable, along the phase each cluster reported
+func (v clusterView) add(statusDpl *dplv1.Deployable, dpl metav1.Object) {
+	for cluster, status := range statusDpl.Status.PropagatedStatus {
+		c := clusterComponent{name: dpl.GetNamespace() + "/" + dpl.GetName()}
+		if status != nil {
+			c.phase, c.reason = status.Phase, status.Reason
+		}
+
+		v[cluster] = append(v[cluster], c)
+	}
+}
+
+// clusterViewOf groups the deployables of the application and of its subscriptions by the managed cluster they
+// target, a cluster is listed even when the propagation to it failed
+func (r *ReconcileApplication) clusterViewOf(subs []*subv1.Subscription, dpls []*dplv1.Deployable) clusterView {
+	v := clusterView{}
+
+	for _, dpl := range dpls {
+		v.add(dpl, dpl)
+	}
+
+	for _, sub := range subs {
+		subDpl := &dplv1.Deployable{}
+		if err := r.Get(context.TODO(), types.NamespacedName{Namespace: sub.Namespace, Name: sub.Name + "-deployable"}, subDpl); err != nil {
+			continue
+		}
+
+		for _, key := range strings.Split(sub.Annotations[subv1.AnnotationDeployables], ",") {
+			strs := strings.Split(key, "/")
+			if len(strs) != 2 || strs[0] == "" || strs[1] == "" {
+				continue
+			}
+
+			v.add(subDpl, &metav1.ObjectMeta{Namespace: strs[0], Name: strs[1]})
+		}
+	}
+
+	return v
+}
+
+// deployablePhaseHealth maps the phase a cluster reported for a deployable to its health
+func deployablePhaseHealth(phase dplv1.DeployablePhase) HealthState {
+	switch phase {
+	case dplv1.DeployableDeployed:
+		return HealthHealthy
+	case dplv1.DeployableFailed:
+		return HealthDegraded
+	case dplv1.DeployablePropagated:
+		return HealthProgressing
+	}
+
+	return HealthUnknown
+}
+
+// clusterHealth is the health of the deployables of the application on a managed cluster
+type clusterHealth struct {
+	state   HealthState
+	healthy int
+	total   int
+	// phases maps the deployables to the phase the cluster reported, Unknown when it reported none
+	phases map[string]string
+}
+
+// rollup computes the health of every cluster, a cluster is healthy when enough of its deployables are deployed
+// for the aggregation rule and unreachable when it reported the status of none
+func (v clusterView) rollup(policy healthPolicy) map[string]*clusterHealth {
+	clusters := make(map[string]*clusterHealth, len(v))
+
+	for cluster, components := range v {
+		h := &clusterHealth{total: len(components), phases: make(map[string]string, len(components))}
+		counts := map[HealthState]int{}
+		reported := 0
+
+		for _, c := range components {
+			state := deployablePhaseHealth(c.phase)
+			counts[state]++
+
+			phase := string(c.phase)
+			if phase == "" {
+				phase = string(HealthUnknown)
+			} else {
+				reported++
+			}
+
+			h.phases[c.name] = phase
+		}
+
+		h.healthy = counts[HealthHealthy]
+
+		switch {
+		case reported == 0:
+			h.state = HealthUnreachable
+		case policy.healthyByAggregation(h.healthy, h.total):
+			h.state = HealthHealthy
+		case counts[HealthDegraded] > 0:
+			h.state = HealthDegraded
+		case counts[HealthProgressing] > 0:
+			h.state = HealthProgressing
+		default:
+			h.state = HealthUnknown
+		}
+
+		clusters[cluster] = h
+	}
+
+	return clusters
+}
+
+func sortedClusters(clusters map[string]*clusterHealth) []string {
+	names := make([]string, 0, len(clusters))
+	for name := range clusters {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+// updateClustersCondition rolls the health of the managed clusters up with the aggregation rule of the
+// application, naming the health of every cluster. It is cleared once the application targets no cluster.
+func updateClustersCondition(status *appv1beta1.ApplicationStatus, clusters map[string]*clusterHealth, policy healthPolicy) {
+	if len(clusters) == 0 {
+		clearCondition(status, ClustersHealthy, "NoClusters", "the application targets no managed cluster")
+		return
+	}
+
+	names := sortedClusters(clusters)
+	parts := make([]string, 0, len(names))
+	healthy, unreachable := 0, 0
+
+	for _, name := range names {
+		h := clusters[name]
+
+		switch h.state {
+		case HealthHealthy:
+			healthy++
+		case HealthUnreachable:
+			unreachable++
+		}
+
+		parts = append(parts, fmt.Sprintf("%s=%s (%d/%d deployed)", name, h.state, h.healthy, h.total))
+	}
+
+	msg := fmt.Sprintf("%d of %d clusters healthy: %s", healthy, len(names), strings.Join(parts, ", "))
+
+	switch {
+	case policy.healthyByAggregation(healthy, len(names)):
+		setCondition(status, ClustersHealthy, corev1.ConditionTrue, "ClustersHealthy", msg)
+	case unreachable > 0:
+		setCondition(status, ClustersHealthy, corev1.ConditionFalse, "ClustersUnreachable", msg)
+	default:
+		setCondition(status, ClustersHealthy, corev1.ConditionFalse, "ClustersUnhealthy", msg)
+	}
+}
+
+// exportedCluster is a managed cluster in the cluster components annotation
+type exportedCluster struct {
+	Health     HealthState       `json:"health"`
+	Components map[string]string `json:"components,omitempty"`
+}
+
+// setClusterComponents writes the deployables grouped by managed cluster into the cluster components annotation,
+// the annotation is removed once the application targets no cluster. It returns true when the annotation changed.
+func (r *ReconcileApplication) setClusterComponents(app *appv1beta1.Application, clusters map[string]*clusterHealth) bool {
+	current, exists := app.GetAnnotations()[utils.AnnotationClusterComponents]
+
+	if len(clusters) == 0 {
+		if !exists {
+			return false
+		}
+
+		delete(app.Annotations, utils.AnnotationClusterComponents)
+
+		return true
+	}
+
+	exported := make(map[string]*exportedCluster, len(clusters))
+	for name, h := range clusters {
+		exported[name] = &exportedCluster{Health: h.state, Components: h.phases}
+	}
+
+	maxBytes := r.options.ExportedComponentsMaxBytes
+	if maxBytes <= 0 {
+		maxBytes = DefaultExportedComponentsMaxBytes
+	}
+
+	// json sorts the map keys, the annotation is stable across reconciles
+	data, err := json.Marshal(exported)
+	if err == nil && len(data) > maxBytes {
+		for _, c := range exported {
+			c.Components = nil
+		}
+
+		data, err = json.Marshal(exported)
+	}
+
+	if err != nil {
+		klog.Error("Failed to encode the cluster components of application ", app.Namespace+"/"+app.Name, " error: ", err)
+		return false
+	}
+
+	if exists && current == string(data) {
+		return false
+	}
+
+	if app.Annotations == nil {
+		app.Annotations = map[string]string{}
+	}
+
+	app.Annotations[utils.AnnotationClusterComponents] = string(data)
+
+	return true
+}
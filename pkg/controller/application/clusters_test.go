@@ -0,0 +1,110 @@
+// Copyright 2019 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package application
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/onsi/gomega"
+	dplv1 "github.com/open-cluster-management/multicloud-operators-deployable/pkg/apis/apps/v1"
+	"github.com/stolostron/multicloud-operators-application/utils"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	appv1beta1 "sigs.k8s.io/application/api/v1beta1"
+)
+
+func newTestDeployable(name string, phases map[string]dplv1.DeployablePhase) *dplv1.Deployable {
+	dpl := &dplv1.Deployable{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"}}
+	dpl.Status.PropagatedStatus = map[string]*dplv1.ResourceUnitStatus{}
+
+	for cluster, phase := range phases {
+		dpl.Status.PropagatedStatus[cluster] = &dplv1.ResourceUnitStatus{Phase: phase}
+	}
+
+	return dpl
+}
+
+func TestClusterHealth(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	v := clusterView{}
+	v.add(newTestDeployable("web", map[string]dplv1.DeployablePhase{
+		"east": dplv1.DeployableDeployed, "west": dplv1.DeployableDeployed, "north": dplv1.DeployableUnknown,
+	}), &metav1.ObjectMeta{Namespace: "default", Name: "web"})
+
+	db := newTestDeployable("db", map[string]dplv1.DeployablePhase{
+		"east": dplv1.DeployableDeployed, "west": dplv1.DeployableFailed,
+	})
+	v.add(db, db)
+
+	all := healthPolicy{aggregation: utils.HealthAggregationAll}
+	clusters := v.rollup(all)
+
+	g.Expect(clusters).To(gomega.HaveLen(3))
+	g.Expect(clusters["east"].state).To(gomega.Equal(HealthHealthy))
+	g.Expect(clusters["west"].state).To(gomega.Equal(HealthDegraded))
+	g.Expect(clusters["north"].state).To(gomega.Equal(HealthUnreachable))
+	g.Expect(clusters["west"].phases).To(gomega.Equal(map[string]string{"default/web": "Deployed", "default/db": "Failed"}))
+
+	status := &appv1beta1.ApplicationStatus{}
+
+	updateClustersCondition(status, clusters, all)
+	c := getCondition(status, ClustersHealthy)
+	g.Expect(c.Status).To(gomega.Equal(corev1.ConditionFalse))
+	g.Expect(c.Reason).To(gomega.Equal("ClustersUnreachable"))
+	g.Expect(c.Message).To(gomega.Equal("1 of 3 clusters healthy: east=Healthy (2/2 deployed), " +
+		"north=Unreachable (0/1 deployed), west=Degraded (1/2 deployed)"))
+
+	// the aggregation rule applies across the clusters too
+	anyPolicy := healthPolicy{aggregation: utils.HealthAggregationAny}
+	updateClustersCondition(status, v.rollup(anyPolicy), anyPolicy)
+	c = getCondition(status, ClustersHealthy)
+	g.Expect(c.Status).To(gomega.Equal(corev1.ConditionTrue))
+	g.Expect(c.Reason).To(gomega.Equal("ClustersHealthy"))
+
+	updateClustersCondition(status, nil, all)
+	g.Expect(getCondition(status, ClustersHealthy).Reason).To(gomega.Equal("NoClusters"))
+}
+
+func TestSetClusterComponents(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	r := newTestReconciler()
+	app := newTestApplication(configMapGK)
+
+	g.Expect(r.setClusterComponents(app, nil)).To(gomega.BeFalse())
+
+	v := clusterView{}
+	dpl := newTestDeployable("web", map[string]dplv1.DeployablePhase{"east": dplv1.DeployableDeployed})
+	v.add(dpl, dpl)
+
+	clusters := v.rollup(healthPolicy{aggregation: utils.HealthAggregationAll})
+
+	g.Expect(r.setClusterComponents(app, clusters)).To(gomega.BeTrue())
+	g.Expect(app.Annotations[utils.AnnotationClusterComponents]).To(gomega.MatchJSON(
+		`{"east": {"health": "Healthy", "components": {"default/web": "Deployed"}}}`))
+	g.Expect(r.setClusterComponents(app, clusters)).To(gomega.BeFalse())
+
+	r.options.ExportedComponentsMaxBytes = 10
+	g.Expect(r.setClusterComponents(app, clusters)).To(gomega.BeTrue())
+
+	exported := map[string]map[string]interface{}{}
+	g.Expect(json.Unmarshal([]byte(app.Annotations[utils.AnnotationClusterComponents]), &exported)).To(gomega.Succeed())
+	g.Expect(exported["east"]).To(gomega.Equal(map[string]interface{}{"health": "Healthy"}))
+
+	g.Expect(r.setClusterComponents(app, nil)).To(gomega.BeTrue())
+	g.Expect(app.Annotations).NotTo(gomega.HaveKey(utils.AnnotationClusterComponents))
+}
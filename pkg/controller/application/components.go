@@ -0,0 +1,725 @@
+// Copyright 2019 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package application
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/stolostron/multicloud-operators-application/utils"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog"
+	appv1beta1 "sigs.k8s.io/application/api/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// componentCountMessage is parsed back on the next reconcile to carry the last non-zero count forward
+const componentCountMessage = "%d components resolved, last non-zero count %d"
+
+// componentResolution is the outcome of resolving the components of an application.
+// Every kind is resolved independently, a kind that fails is recorded in failures and does not
+// prevent the components of the other kinds from being reported.
+type componentResolution struct {
+	components []*unstructured.Unstructured
+	// kinds are the componentGroupKinds the components were selected from, nil when they are listed in a ConfigMap
+	kinds []metav1.GroupKind
+	// healthComponents are the components the health is evaluated from when the application sets health kinds,
+	// nil when it is evaluated from the listed components
+	healthComponents []*unstructured.Unstructured
+	// children are the applications naming the application as their parent, listed and rolled up in the health
+	// along the components
+	children []*unstructured.Unstructured
+	// suspended are the kinds not listed as their breaker is open, retryIn the shortest time until one is probed
+	suspended []string
+	retryIn   time.Duration
+	// failures are keyed by the kind, or the source, that could not be resolved
+	failures map[string]error
+	// selectors is the number of selectors of the application, selectorIndex the one the components
+	// were resolved with
+	selectors     int
+	selectorIndex int
+	// selectorErr is the error building the selector at selectorIndex, a configuration error rather than a
+	// failure worth retrying
+	selectorErr error
+	// interrupted is the error of the reconcile context done before every kind was resolved, the components
+	// are partial
+	interrupted error
+	// reader reads the components as the ServiceAccount the application is resolved as, nil to read them as
+	// the operator
+	reader client.Reader
+	// resolvedAs is the user the reader impersonates
+	resolvedAs string
+}
+
+// resolveComponents resolves the components listed in the components ConfigMap when the application
+// points at one, otherwise the resources of each componentGroupKind matching the application selector
+func (r *ReconcileApplication) resolveComponents(ctx context.Context, app *appv1beta1.Application) *componentResolution {
+	res := &componentResolution{failures: make(map[string]error)}
+
+	reader, user, err := r.resolutionReader(app)
+	if err != nil {
+		res.failures[utils.AnnotationResolveAs] = err
+		return res
+	}
+
+	res.reader, res.resolvedAs = reader, user
+
+	cmRef, found, err := utils.GetComponentsConfigMap(app)
+	if found {
+		if err != nil {
+			res.failures[utils.AnnotationComponentsConfigMap] = err
+			return res
+		}
+
+		r.resolveComponentList(ctx, app, cmRef, res)
+
+		return res
+	}
+
+	if len(app.Spec.ComponentGroupKinds) == 0 {
+		return res
+	}
+
+	selectors, err := utils.GetSelectors(app)
+	if err != nil {
+		klog.Error("Failed to get the fallback selectors of application: ", app.Name, " err: ", err)
+
+		res.failures[utils.AnnotationFallbackSelectors] = err
+	}
+
+	res.selectors = len(selectors)
+	res.kinds = app.Spec.ComponentGroupKinds
+
+	imageFilter, err := utils.GetImageFilter(app)
+	if err != nil {
+		res.failures[utils.AnnotationComponentImageRegex] = err
+		return res
+	}
+
+	namespaces, err := utils.GetComponentNamespaces(app)
+	if err != nil {
+		res.failures[utils.AnnotationComponentNamespaces] = err
+		return res
+	}
+
+	// the first selector matching any component wins, a selector failing for some kinds does not fall back
+	// as those kinds may hold its matches
+	for i, labelSelector := range selectors {
+		res.selectorIndex = i
+
+		res.components = r.resolveKinds(ctx, app, app.Spec.ComponentGroupKinds, labelSelector, imageFilter, namespaces, res)
+
+		if len(res.components) > 0 || len(res.failures) > 0 || res.selectorErr != nil || res.interrupted != nil {
+			break
+		}
+	}
+
+	res.components = dedupeComponents(app, res.components)
+
+	healthKinds, err := utils.GetHealthKinds(app)
+	if err != nil {
+		// the health falls back to the listed components
+		res.failures[utils.AnnotationHealthKinds] = err
+	} else if healthKinds != nil && res.selectorErr == nil && res.interrupted == nil {
+		res.healthComponents = r.resolveHealthComponents(ctx, app, healthKinds, selectors[res.selectorIndex], imageFilter,
+			namespaces, res)
+	}
+
+	return res
+}
+
+// normalizedGroupKind strips the version some applications set in the group of their kinds
+func normalizedGroupKind(gk metav1.GroupKind) schema.GroupKind {
+	return schema.GroupKind{Group: appv1beta1.StripVersion(gk.Group), Kind: gk.Kind}
+}
+
+// resolveHealthComponents resolves the components of the health kinds with the selector the listed components
+// were resolved with, the listed components of the health kinds are reused rather than listed again
+func (r *ReconcileApplication) resolveHealthComponents(ctx context.Context, app *appv1beta1.Application,
+	healthKinds []metav1.GroupKind, labelSelector *metav1.LabelSelector, imageFilter *utils.ImageFilter,
+	namespaces map[schema.GroupKind]string, res *componentResolution) []*unstructured.Unstructured {
+	listed := make(map[schema.GroupKind]bool, len(app.Spec.ComponentGroupKinds))
+	for _, gk := range app.Spec.ComponentGroupKinds {
+		listed[normalizedGroupKind(gk)] = true
+	}
+
+	wanted := make(map[schema.GroupKind]bool, len(healthKinds))
+
+	var unlisted []metav1.GroupKind
+
+	for _, gk := range healthKinds {
+		wanted[normalizedGroupKind(gk)] = true
+
+		if !listed[normalizedGroupKind(gk)] {
+			unlisted = append(unlisted, gk)
+		}
+	}
+
+	health := make([]*unstructured.Unstructured, 0, len(res.components))
+
+	for _, u := range res.components {
+		if wanted[u.GroupVersionKind().GroupKind()] {
+			health = append(health, u)
+		}
+	}
+
+	if len(unlisted) > 0 {
+		health = append(health, r.resolveKinds(ctx, app, unlisted, labelSelector, imageFilter, namespaces, res)...)
+	}
+
+	return dedupeComponents(app, health)
+}
+
+// componentKey identifies the object behind a component, whatever version of its kind it was read with
+type componentKey struct {
+	group, kind, namespace, name string
+	uid                          types.UID
+}
+
+// dedupeComponents drops the components resolved more than once, such as a kind listed under several versions
+func dedupeComponents(app *appv1beta1.Application, components []*unstructured.Unstructured) []*unstructured.Unstructured {
+	seen := make(map[componentKey]bool, len(components))
+	deduped := components[:0]
+
+	for _, u := range components {
+		gk := u.GroupVersionKind().GroupKind()
+		key := componentKey{group: gk.Group, kind: gk.Kind, namespace: u.GetNamespace(), name: u.GetName(), uid: u.GetUID()}
+
+		if seen[key] {
+			klog.Info("Dropping duplicated component ", gk.String(), " ", u.GetNamespace()+"/"+u.GetName(), " of application ",
+				app.Namespace+"/"+app.Name)
+
+			continue
+		}
+
+		seen[key] = true
+
+		deduped = append(deduped, u)
+	}
+
+	return deduped
+}
+
+// namespaceGK is the kind of the namespaces, they are only resolved when the application lists the kind
+var namespaceGK = schema.GroupKind{Kind: "Namespace"}
+
+// resolveKinds resolves the resources of each kind matching the selector, and the image filter when the
+// application sets one, in the namespace of the kind or else the application namespace. The Namespace kind is
+// only resolved when listed, to the namespace object itself when it matches the selector.
+func (r *ReconcileApplication) resolveKinds(ctx context.Context, app *appv1beta1.Application, kinds []metav1.GroupKind,
+	labelSelector *metav1.LabelSelector, imageFilter *utils.ImageFilter, namespaces map[schema.GroupKind]string,
+	res *componentResolution) []*unstructured.Unstructured {
+	selector, err := utils.ConvertLabels(labelSelector)
+	if err != nil {
+		klog.Error("Failed to set label selector of application: ", app.Name, " err: ", err)
+
+		res.selectorErr = fmt.Errorf("selector %d: %w", res.selectorIndex, err)
+
+		return nil
+	}
+
+	var components []*unstructured.Unstructured
+
+	for _, gk := range kinds {
+		if err := ctx.Err(); err != nil {
+			res.interrupted = err
+			return components
+		}
+
+		ns, ok := namespaces[normalizedGroupKind(gk)]
+		if !ok {
+			ns = app.Namespace
+		}
+
+		excluded, err := r.options.ExcludedNamespaces.excludes(ctx, r, ns)
+		if err != nil {
+			res.failures[gk.String()] = err
+			continue
+		}
+
+		if excluded {
+			klog.Info("Skipping the components of kind ", gk.String(), " of application ", app.Namespace+"/"+app.Name,
+				", namespace ", ns, " is excluded by the operator")
+
+			continue
+		}
+
+		if allowed, retryIn := r.breaker.allow(normalizedGroupKind(gk)); !allowed {
+			res.suspend(gk.String(), retryIn)
+			continue
+		}
+
+		items, err := r.listComponents(ctx, res.reader, gk, ns, selector)
+
+		// the kinds unknown to the cluster and the lists cut short by the reconcile deadline do not count
+		if !meta.IsNoMatchError(err) && ctx.Err() == nil {
+			r.breaker.record(normalizedGroupKind(gk), err)
+		}
+
+		if err != nil {
+			klog.Error("Failed to list components of kind ", gk.String(), " for application ",
+				app.Namespace+"/"+app.Name, " error: ", err)
+
+			res.failures[gk.String()] = err
+
+			continue
+		}
+
+		items = r.filterOptedOutComponents(app, items)
+
+		if imageFilter != nil {
+			items = filterComponentsByImage(items, imageFilter)
+		}
+
+		components = append(components, items...)
+	}
+
+	return components
+}
+
+// suspend records a kind skipped by its breaker
+func (res *componentResolution) suspend(kind string, retryIn time.Duration) {
+	res.suspended = append(res.suspended, kind)
+
+	if res.retryIn == 0 || retryIn < res.retryIn {
+		res.retryIn = retryIn
+	}
+}
+
+// podSpecPaths locate the pod spec of the kinds the image filter is evaluated for
+var podSpecPaths = map[schema.GroupKind][]string{
+	{Kind: "Pod"}:                        {"spec"},
+	{Group: "apps", Kind: "Deployment"}:  {"spec", "template", "spec"},
+	{Group: "apps", Kind: "StatefulSet"}: {"spec", "template", "spec"},
+	{Group: "apps", Kind: "DaemonSet"}:   {"spec", "template", "spec"},
+	{Group: "apps", Kind: "ReplicaSet"}:  {"spec", "template", "spec"},
+	{Group: "batch", Kind: "Job"}:        {"spec", "template", "spec"},
+	{Group: "batch", Kind: "CronJob"}:    {"spec", "jobTemplate", "spec", "template", "spec"},
+}
+
+// isOptedOut returns true if the resource carries the opt-out annotation of the operator set to "true"
+func (r *ReconcileApplication) isOptedOut(u *unstructured.Unstructured) bool {
+	return r.options.NoAdoptAnnotation != "" && u.GetAnnotations()[r.options.NoAdoptAnnotation] == "true"
+}
+
+// filterOptedOutComponents drops the resources opted out of adoption, they belong to no application
+func (r *ReconcileApplication) filterOptedOutComponents(app *appv1beta1.Application,
+	items []*unstructured.Unstructured) []*unstructured.Unstructured {
+	filtered := items[:0]
+
+	for _, u := range items {
+		if r.isOptedOut(u) {
+			klog.V(1).Info("Component ", u.GroupVersionKind().GroupKind().String(), " ", u.GetNamespace()+"/"+u.GetName(),
+				" matching application ", app.Namespace+"/"+app.Name, " opted out of adoption")
+
+			continue
+		}
+
+		filtered = append(filtered, u)
+	}
+
+	return filtered
+}
+
+// filterComponentsByImage keeps the components with a container image matching the filter, the kinds
+// without a pod spec are kept as they are
+func filterComponentsByImage(items []*unstructured.Unstructured, filter *utils.ImageFilter) []*unstructured.Unstructured {
+	filtered := items[:0]
+
+	for _, u := range items {
+		path, ok := podSpecPaths[u.GroupVersionKind().GroupKind()]
+		if !ok || podSpecImageMatches(u, path, filter) {
+			filtered = append(filtered, u)
+		}
+	}
+
+	return filtered
+}
+
+func podSpecImageMatches(u *unstructured.Unstructured, path []string, filter *utils.ImageFilter) bool {
+	for _, field := range []string{"initContainers", "containers"} {
+		containers, _, _ := unstructured.NestedSlice(u.Object, append(append([]string{}, path...), field)...)
+
+		for _, c := range containers {
+			container, ok := c.(map[string]interface{})
+			if !ok {
+				continue
+			}
+
+			if image, ok := container["image"].(string); ok && filter.Match(image) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// componentCacheSyncTimeout bounds the lists of the components from the informer cache
+const componentCacheSyncTimeout = 30 * time.Second
+
+// listComponents lists the components of the kind matching the selector, as the operator when reader is nil
+func (r *ReconcileApplication) listComponents(ctx context.Context, reader client.Reader, gk metav1.GroupKind,
+	namespace string, selector labels.Selector) ([]*unstructured.Unstructured, error) {
+	mapping, err := r.mapper.RESTMapping(schema.GroupKind{Group: appv1beta1.StripVersion(gk.Group), Kind: gk.Kind})
+	if err != nil {
+		return nil, err
+	}
+
+	list := &unstructured.UnstructuredList{}
+	list.SetGroupVersionKind(mapping.GroupVersionKind)
+
+	// the impersonated lists bypass the cache, it is filled with the permissions of the operator
+	if reader == nil {
+		reader = r.Client
+	}
+
+	if normalizedGroupKind(gk) == namespaceGK {
+		if reader == r.Client {
+			reader = r.apiReader
+		}
+
+		return getNamespaceComponent(ctx, reader, mapping, namespace, selector)
+	}
+
+	if reader == r.Client && r.componentReader != nil {
+		// the first list of a kind waits for its informer to sync, it never does without the RBAC to watch the kind
+		var cancel context.CancelFunc
+
+		ctx, cancel = context.WithTimeout(ctx, componentCacheSyncTimeout)
+		defer cancel()
+
+		reader = r.componentReader
+	}
+
+	// the whole selector is sent with the list, the API server evaluates the set based requirements, NotIn and
+	// DoesNotExist included, so only the matching components are transferred and none is filtered here
+	if err := reader.List(ctx, list, client.InNamespace(namespace), client.MatchingLabelsSelector{Selector: selector}); err != nil {
+		return nil, err
+	}
+
+	items := make([]*unstructured.Unstructured, 0, len(list.Items))
+	for i := range list.Items {
+		items = append(items, &list.Items[i])
+	}
+
+	return items, nil
+}
+
+// getNamespaceComponent resolves the Namespace kind to the namespace the kind is resolved in when it matches the
+// selector, the application namespace unless the component namespaces annotation sets another. The other
+// namespaces carrying the labels of the selector are never components, the namespace is read on its own, without
+// caching or listing the namespaces of the cluster.
+func getNamespaceComponent(ctx context.Context, reader client.Reader, mapping *meta.RESTMapping, name string,
+	selector labels.Selector) ([]*unstructured.Unstructured, error) {
+	ns := &unstructured.Unstructured{}
+	ns.SetGroupVersionKind(mapping.GroupVersionKind)
+
+	if err := reader.Get(ctx, types.NamespacedName{Name: name}, ns); err != nil {
+		if errors.IsNotFound(err) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	if !selector.Matches(labels.Set(ns.GetLabels())) {
+		return nil, nil
+	}
+
+	return []*unstructured.Unstructured{ns}, nil
+}
+
+// resolveComponentList resolves exactly the components listed in the components ConfigMap, listed
+// components that do not exist are skipped
+func (r *ReconcileApplication) resolveComponentList(ctx context.Context, app *appv1beta1.Application,
+	cmRef utils.ConfigMapKeyReference, res *componentResolution) {
+	source := "configmap " + app.Namespace + "/" + cmRef.String()
+
+	var reader, cmReader client.Reader = r.Client, r.apiReader
+	if res.reader != nil {
+		reader, cmReader = res.reader, res.reader
+	}
+
+	cm := &corev1.ConfigMap{}
+	if err := cmReader.Get(ctx, types.NamespacedName{Namespace: app.Namespace, Name: cmRef.Name}, cm); err != nil {
+		res.failures[source] = err
+		return
+	}
+
+	data, ok := cm.Data[cmRef.Key]
+	if !ok {
+		res.failures[source] = fmt.Errorf("key %s not found", cmRef.Key)
+		return
+	}
+
+	refs, err := utils.ParseComponentList(data)
+	if err != nil {
+		res.failures[source] = err
+		return
+	}
+
+	for _, ref := range refs {
+		if err := ctx.Err(); err != nil {
+			res.interrupted = err
+			return
+		}
+
+		gk := ref.GroupKind()
+
+		mapping, err := r.mapper.RESTMapping(schema.GroupKind{Group: appv1beta1.StripVersion(gk.Group), Kind: gk.Kind})
+		if err != nil {
+			res.failures[gk.String()] = err
+			continue
+		}
+
+		ns := ref.Namespace
+		if ns == "" {
+			ns = app.Namespace
+		}
+
+		excluded, err := r.options.ExcludedNamespaces.excludes(ctx, r, ns)
+		if err != nil {
+			res.failures[gk.String()] = err
+			continue
+		}
+
+		if excluded {
+			klog.Info("Skipping the listed component ", gk.String(), " ", ns+"/"+ref.Name, " of application ",
+				app.Namespace+"/"+app.Name, ", the namespace is excluded by the operator")
+
+			continue
+		}
+
+		u := &unstructured.Unstructured{}
+		u.SetGroupVersionKind(mapping.GroupVersionKind)
+
+		if err := reader.Get(ctx, types.NamespacedName{Namespace: ns, Name: ref.Name}, u); err != nil {
+			if !errors.IsNotFound(err) {
+				res.failures[gk.String()] = err
+			}
+
+			klog.V(1).Info("Listed component ", gk.String(), " ", ns+"/"+ref.Name, " not resolved: ", err)
+
+			continue
+		}
+
+		if r.isOptedOut(u) {
+			klog.V(1).Info("Listed component ", gk.String(), " ", ns+"/"+ref.Name, " of application ",
+				app.Namespace+"/"+app.Name, " opted out of adoption")
+
+			continue
+		}
+
+		res.components = append(res.components, u)
+	}
+}
+
+// failureMessage renders the failed kinds in a stable order for the status condition
+func (res *componentResolution) failureMessage() string {
+	msgs := make([]string, 0, len(res.failures))
+	for source, err := range res.failures {
+		msgs = append(msgs, fmt.Sprintf("%s: %v", source, err))
+	}
+
+	sort.Strings(msgs)
+
+	return "failed to resolve components of kinds " + strings.Join(msgs, "; ")
+}
+
+// updateComponentStatus writes the resolved components, their health and the resolution conditions into the status
+func updateComponentStatus(status *appv1beta1.ApplicationStatus, res *componentResolution, policy healthPolicy) *healthRollup {
+	listed := append(append([]*unstructured.Unstructured{}, res.components...), res.children...)
+	objects := make([]appv1beta1.ObjectStatus, 0, len(listed))
+
+	for _, u := range listed {
+		objects = append(objects, appv1beta1.ObjectStatus{
+			Group: u.GroupVersionKind().Group,
+			Kind:  u.GetKind(),
+			Name:  u.GetName(),
+		})
+	}
+
+	rollup := rollupHealth(listed, objects, policy)
+
+	if res.healthComponents != nil {
+		// the listed components still report their own health, the application health comes from the health kinds
+		health := append(append([]*unstructured.Unstructured{}, res.healthComponents...), res.children...)
+		rollup = rollupHealth(health, make([]appv1beta1.ObjectStatus, len(health)), policy)
+	}
+
+	status.ComponentList = appv1beta1.ComponentList{Objects: objects}
+
+	updateHealthStatus(status, rollup)
+	updateComponentCountCondition(status, len(objects))
+	updateKindCoverageCondition(status, res)
+	updateSelectorCondition(status, res)
+	updateSelectorInvalidCondition(status, res)
+
+	if len(res.failures) > 0 {
+		setErrorCondition(status, "ListFailed", res.failureMessage())
+	} else {
+		clearErrorCondition(status)
+	}
+
+	return rollup
+}
+
+// updateSelectorCondition records which selector the components were resolved with when the application
+// has fallback selectors
+func updateSelectorCondition(status *appv1beta1.ApplicationStatus, res *componentResolution) {
+	if res.selectors <= 1 {
+		clearCondition(status, ComponentsSelector, "NoFallbackSelectors", "spec.selector is the only selector")
+		return
+	}
+
+	reason := "PrimarySelector"
+	if res.selectorIndex > 0 {
+		reason = "FallbackSelector"
+	}
+
+	setCondition(status, ComponentsSelector, corev1.ConditionTrue, reason,
+		fmt.Sprintf("components resolved with selector %d of %d", res.selectorIndex, res.selectors))
+}
+
+// updateSelectorInvalidCondition surfaces the exact error of the selector that could not be built
+func updateSelectorInvalidCondition(status *appv1beta1.ApplicationStatus, res *componentResolution) {
+	if res.selectorErr == nil {
+		clearCondition(status, SelectorInvalid, "SelectorValid", "the selectors are valid")
+		return
+	}
+
+	setCondition(status, SelectorInvalid, corev1.ConditionTrue, "InvalidSelector", res.selectorErr.Error())
+}
+
+// noComponentsAfterGracePeriod is the reason of the WaitingForComponents condition once the grace period elapsed
+const noComponentsAfterGracePeriod = "NoComponentsAfterGracePeriod"
+
+// updateWaitingCondition tells an application with no components yet from one failing to resolve them. It
+// returns how long until the grace period elapses while waiting, and true when the wait just escalated.
+func updateWaitingCondition(status *appv1beta1.ApplicationStatus, res *componentResolution, created time.Time,
+	grace time.Duration) (time.Duration, bool) {
+	switch {
+	case len(res.components) > 0 || len(res.children) > 0:
+		clearCondition(status, WaitingForComponents, "ComponentsFound", "the application resolved components")
+		return 0, false
+	case len(res.failures) > 0 || res.selectorErr != nil:
+		clearCondition(status, WaitingForComponents, "ResolutionFailed", "the components failed to resolve")
+		return 0, false
+	}
+
+	if remaining := time.Until(created.Add(grace)); remaining > 0 {
+		setCondition(status, WaitingForComponents, corev1.ConditionTrue, "WaitingForComponents",
+			fmt.Sprintf("no components yet, waiting up to %s after the application creation", grace))
+
+		return remaining, false
+	}
+
+	escalated := true
+	if c := getCondition(status, WaitingForComponents); c != nil && c.Status == corev1.ConditionTrue &&
+		c.Reason == noComponentsAfterGracePeriod {
+		escalated = false
+	}
+
+	setCondition(status, WaitingForComponents, corev1.ConditionTrue, noComponentsAfterGracePeriod,
+		fmt.Sprintf("still no components %s after the application creation", grace))
+
+	return 0, escalated
+}
+
+// updateComponentCountCondition publishes the resolved count along with the last non-zero count, so
+// external alerting can detect an application collapsing to few or no components
+func updateComponentCountCondition(status *appv1beta1.ApplicationStatus, count int) {
+	lastNonZero := count
+
+	if count == 0 {
+		if c := getCondition(status, ComponentsResolved); c != nil {
+			var prevCount int
+			if _, err := fmt.Sscanf(c.Message, componentCountMessage, &prevCount, &lastNonZero); err != nil {
+				lastNonZero = 0
+			}
+		}
+	}
+
+	msg := fmt.Sprintf(componentCountMessage, count, lastNonZero)
+
+	if count > 0 {
+		setCondition(status, ComponentsResolved, corev1.ConditionTrue, "ComponentsFound", msg)
+	} else {
+		setCondition(status, ComponentsResolved, corev1.ConditionFalse, "NoComponentsFound", msg)
+	}
+}
+
+// updateKindCoverageCondition reports how many components each componentGroupKind resolved to, so the kinds
+// listed but never matching can be pruned. The kinds failing to resolve or suspended are named as such.
+func updateKindCoverageCondition(status *appv1beta1.ApplicationStatus, res *componentResolution) {
+	if len(res.kinds) == 0 || res.selectorErr != nil {
+		clearCondition(status, ComponentKindsMatched, "NotSelected", "the components are not selected by kind")
+		return
+	}
+
+	counts := map[schema.GroupKind]int{}
+	for _, u := range res.components {
+		counts[u.GroupVersionKind().GroupKind()]++
+	}
+
+	suspended := make(map[string]bool, len(res.suspended))
+	for _, kind := range res.suspended {
+		suspended[kind] = true
+	}
+
+	seen := map[schema.GroupKind]bool{}
+	entries := make([]string, 0, len(res.kinds))
+	matched := 0
+
+	for _, gk := range res.kinds {
+		if seen[normalizedGroupKind(gk)] {
+			continue
+		}
+
+		seen[normalizedGroupKind(gk)] = true
+
+		switch count := counts[normalizedGroupKind(gk)]; {
+		case res.failures[gk.String()] != nil:
+			entries = append(entries, gk.String()+"=failed")
+		case suspended[gk.String()]:
+			entries = append(entries, gk.String()+"=suspended")
+		default:
+			entries = append(entries, fmt.Sprintf("%s=%d", gk.String(), count))
+
+			if count > 0 {
+				matched++
+			}
+		}
+	}
+
+	msg := fmt.Sprintf("%d of %d component kinds matched components: %s", matched, len(entries), strings.Join(entries, ", "))
+
+	if matched == len(entries) {
+		setCondition(status, ComponentKindsMatched, corev1.ConditionTrue, "AllKindsMatched", msg)
+	} else {
+		setCondition(status, ComponentKindsMatched, corev1.ConditionFalse, "UnmatchedKinds", msg)
+	}
+}
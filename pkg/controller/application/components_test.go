@@ -0,0 +1,465 @@
+// Copyright 2019 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package application
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/onsi/gomega"
+	"github.com/stolostron/multicloud-operators-application/utils"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	appv1beta1 "sigs.k8s.io/application/api/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+var (
+	configMapGK = metav1.GroupKind{Kind: "ConfigMap"}
+	unknownGK   = metav1.GroupKind{Group: "example.com", Kind: "Unknown"}
+)
+
+func newTestReconciler(objs ...corev1.ConfigMap) *ReconcileApplication {
+	mapper := meta.NewDefaultRESTMapper([]schema.GroupVersion{corev1.SchemeGroupVersion})
+	mapper.Add(corev1.SchemeGroupVersion.WithKind("ConfigMap"), meta.RESTScopeNamespace)
+
+	builder := fake.NewClientBuilder().WithScheme(scheme.Scheme)
+	for i := range objs {
+		builder = builder.WithObjects(&objs[i])
+	}
+
+	c := builder.Build()
+
+	return &ReconcileApplication{
+		Client:    c,
+		apiReader: c,
+		scheme:    scheme.Scheme,
+		mapper:    mapper,
+	}
+}
+
+func newTestApplication(gks ...metav1.GroupKind) *appv1beta1.Application {
+	return &appv1beta1.Application{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-app", Namespace: "default"},
+		Spec: appv1beta1.ApplicationSpec{
+			ComponentGroupKinds: gks,
+			Selector: &metav1.LabelSelector{
+				MatchLabels: map[string]string{"app": "test-app"},
+			},
+		},
+	}
+}
+
+func newTestConfigMap(name string, labels map[string]string) corev1.ConfigMap {
+	return corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", Labels: labels},
+	}
+}
+
+func TestResolveComponentsPartialFailure(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	r := newTestReconciler(
+		newTestConfigMap("matched", map[string]string{"app": "test-app"}),
+		newTestConfigMap("unmatched", map[string]string{"app": "other"}),
+	)
+
+	res := r.resolveComponents(context.TODO(), newTestApplication(configMapGK, unknownGK))
+
+	g.Expect(res.components).To(gomega.HaveLen(1))
+	g.Expect(res.components[0].GetName()).To(gomega.Equal("matched"))
+	g.Expect(res.failures).To(gomega.HaveLen(1))
+	g.Expect(res.failures).To(gomega.HaveKey(unknownGK.String()))
+
+	status := &appv1beta1.ApplicationStatus{}
+	updateComponentStatus(status, res, defaultTestHealthPolicy)
+
+	g.Expect(status.ComponentList.Objects).To(gomega.HaveLen(1))
+
+	errCond := getCondition(status, appv1beta1.Error)
+	g.Expect(errCond).NotTo(gomega.BeNil())
+	g.Expect(errCond.Status).To(gomega.Equal(corev1.ConditionTrue))
+	g.Expect(errCond.Message).To(gomega.ContainSubstring(unknownGK.String()))
+
+	res = r.resolveComponents(context.TODO(), newTestApplication(configMapGK))
+	updateComponentStatus(status, res, defaultTestHealthPolicy)
+
+	g.Expect(getCondition(status, appv1beta1.Error).Status).To(gomega.Equal(corev1.ConditionFalse))
+}
+
+func TestResolveComponentsInvalidSelector(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	r := newTestReconciler(newTestConfigMap("matched", map[string]string{"app": "test-app"}))
+
+	app := newTestApplication(configMapGK)
+	app.Spec.Selector = &metav1.LabelSelector{
+		MatchExpressions: []metav1.LabelSelectorRequirement{
+			{Key: "app", Operator: metav1.LabelSelectorOpIn, Values: []string{"not a label value"}},
+		},
+	}
+
+	res := r.resolveComponents(context.TODO(), app)
+	g.Expect(res.components).To(gomega.BeEmpty())
+	g.Expect(res.failures).To(gomega.BeEmpty())
+	g.Expect(res.selectorErr).To(gomega.HaveOccurred())
+
+	status := &appv1beta1.ApplicationStatus{}
+	updateComponentStatus(status, res, defaultTestHealthPolicy)
+
+	c := getCondition(status, SelectorInvalid)
+	g.Expect(c.Status).To(gomega.Equal(corev1.ConditionTrue))
+	g.Expect(c.Message).To(gomega.HavePrefix("selector 0: "))
+	g.Expect(c.Message).To(gomega.ContainSubstring(`"not a label value"`))
+
+	app.Spec.Selector.MatchExpressions[0].Values = []string{"test-app"}
+
+	res = r.resolveComponents(context.TODO(), app)
+	g.Expect(res.components).To(gomega.HaveLen(1))
+
+	updateComponentStatus(status, res, defaultTestHealthPolicy)
+	g.Expect(getCondition(status, SelectorInvalid).Status).To(gomega.Equal(corev1.ConditionFalse))
+}
+
+func TestResolveComponentsHealthKinds(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	r := newTestReconciler(newTestConfigMap("settings", map[string]string{"app": "test-app"}))
+	r.mapper.(*meta.DefaultRESTMapper).Add(corev1.SchemeGroupVersion.WithKind("Pod"), meta.RESTScopeNamespace)
+
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "web-0", Namespace: "default", Labels: map[string]string{"app": "test-app"}},
+		Status:     corev1.PodStatus{Phase: corev1.PodFailed, Reason: "Evicted"},
+	}
+	g.Expect(r.Create(context.TODO(), pod)).To(gomega.Succeed())
+
+	app := newTestApplication(configMapGK)
+	app.Annotations = map[string]string{utils.AnnotationHealthKinds: `[{"kind":"Pod"}]`}
+
+	res := r.resolveComponents(context.TODO(), app)
+	g.Expect(res.failures).To(gomega.BeEmpty())
+	g.Expect(res.components).To(gomega.HaveLen(1))
+	g.Expect(res.components[0].GetKind()).To(gomega.Equal("ConfigMap"))
+	g.Expect(res.healthComponents).To(gomega.HaveLen(1))
+	g.Expect(res.healthComponents[0].GetKind()).To(gomega.Equal("Pod"))
+
+	status := &appv1beta1.ApplicationStatus{}
+	rollup := updateComponentStatus(status, res, defaultTestHealthPolicy)
+	g.Expect(rollup.state).To(gomega.Equal(HealthDegraded))
+	g.Expect(status.ComponentList.Objects).To(gomega.HaveLen(1))
+	g.Expect(status.ComponentsReady).To(gomega.Equal("0/1"))
+
+	// without health kinds the health comes from the listed components
+	app.Annotations = nil
+
+	res = r.resolveComponents(context.TODO(), app)
+	g.Expect(res.healthComponents).To(gomega.BeNil())
+	g.Expect(updateComponentStatus(status, res, defaultTestHealthPolicy).state).To(gomega.Equal(HealthHealthy))
+}
+
+func TestResolveComponentsNamespaces(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	labels := map[string]string{"app": "test-app"}
+
+	r := newTestReconciler(newTestConfigMap("matched", labels))
+	r.mapper.(*meta.DefaultRESTMapper).Add(corev1.SchemeGroupVersion.WithKind("Namespace"), meta.RESTScopeRoot)
+
+	for _, name := range []string{"default", "other"} {
+		g.Expect(r.Create(context.TODO(), &corev1.Namespace{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Labels: labels},
+		})).To(gomega.Succeed())
+	}
+
+	// the namespaces carrying the labels of the selector are not components of the kinds listed
+	res := r.resolveComponents(context.TODO(), newTestApplication(configMapGK))
+	g.Expect(res.failures).To(gomega.BeEmpty())
+	g.Expect(res.components).To(gomega.HaveLen(1))
+	g.Expect(res.components[0].GetKind()).To(gomega.Equal("ConfigMap"))
+
+	// listing the kind resolves the application namespace only
+	res = r.resolveComponents(context.TODO(), newTestApplication(configMapGK, metav1.GroupKind{Kind: "Namespace"}))
+	g.Expect(res.failures).To(gomega.BeEmpty())
+	g.Expect(res.components).To(gomega.HaveLen(2))
+	g.Expect(res.components[1].GetKind()).To(gomega.Equal("Namespace"))
+	g.Expect(res.components[1].GetName()).To(gomega.Equal("default"))
+
+	// the namespace is never owned by the application
+	app := newTestApplication(metav1.GroupKind{Kind: "Namespace"})
+	app.UID = "test-app-uid"
+	res = r.resolveComponents(context.TODO(), app)
+	r.setOwnerRefs(context.TODO(), app, res.components, nil)
+
+	ns := &corev1.Namespace{}
+	g.Expect(r.Get(context.TODO(), types.NamespacedName{Name: "default"}, ns)).To(gomega.Succeed())
+	g.Expect(ns.OwnerReferences).To(gomega.BeEmpty())
+
+	// the application namespace not matching the selector is not a component
+	ns.Labels = map[string]string{"app": "other"}
+	g.Expect(r.Update(context.TODO(), ns)).To(gomega.Succeed())
+
+	res = r.resolveComponents(context.TODO(), newTestApplication(metav1.GroupKind{Kind: "Namespace"}))
+	g.Expect(res.failures).To(gomega.BeEmpty())
+	g.Expect(res.components).To(gomega.BeEmpty())
+}
+
+func TestComponentCountCondition(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	status := &appv1beta1.ApplicationStatus{}
+
+	updateComponentCountCondition(status, 20)
+	c := getCondition(status, ComponentsResolved)
+	g.Expect(c.Status).To(gomega.Equal(corev1.ConditionTrue))
+	g.Expect(c.Message).To(gomega.Equal("20 components resolved, last non-zero count 20"))
+
+	updateComponentCountCondition(status, 0)
+	c = getCondition(status, ComponentsResolved)
+	g.Expect(c.Status).To(gomega.Equal(corev1.ConditionFalse))
+	g.Expect(c.Message).To(gomega.Equal("0 components resolved, last non-zero count 20"))
+
+	updateComponentCountCondition(status, 0)
+	g.Expect(getCondition(status, ComponentsResolved).Message).To(gomega.Equal("0 components resolved, last non-zero count 20"))
+
+	updateComponentCountCondition(status, 1)
+	g.Expect(getCondition(status, ComponentsResolved).Message).To(gomega.Equal("1 components resolved, last non-zero count 1"))
+}
+
+func TestKindCoverageCondition(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	r := newTestReconciler(
+		newTestConfigMap("first", map[string]string{"app": "test-app"}),
+		newTestConfigMap("second", map[string]string{"app": "test-app"}),
+	)
+	r.mapper.(*meta.DefaultRESTMapper).Add(corev1.SchemeGroupVersion.WithKind("Secret"), meta.RESTScopeNamespace)
+
+	secretGK := metav1.GroupKind{Kind: "Secret"}
+
+	status := &appv1beta1.ApplicationStatus{}
+	updateComponentStatus(status, r.resolveComponents(context.TODO(), newTestApplication(configMapGK, secretGK, unknownGK)),
+		defaultTestHealthPolicy)
+
+	c := getCondition(status, ComponentKindsMatched)
+	g.Expect(c.Status).To(gomega.Equal(corev1.ConditionFalse))
+	g.Expect(c.Message).To(gomega.Equal("1 of 3 component kinds matched components: ConfigMap=2, Secret=0, Unknown.example.com=failed"))
+
+	updateComponentStatus(status, r.resolveComponents(context.TODO(), newTestApplication(configMapGK, configMapGK)),
+		defaultTestHealthPolicy)
+
+	c = getCondition(status, ComponentKindsMatched)
+	g.Expect(c.Status).To(gomega.Equal(corev1.ConditionTrue))
+	g.Expect(c.Message).To(gomega.Equal("1 of 1 component kinds matched components: ConfigMap=2"))
+
+	updateComponentStatus(status, &componentResolution{failures: map[string]error{}}, defaultTestHealthPolicy)
+	g.Expect(getCondition(status, ComponentKindsMatched).Reason).To(gomega.Equal("NotSelected"))
+}
+
+func TestWaitingForComponentsCondition(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	status := &appv1beta1.ApplicationStatus{}
+	res := &componentResolution{}
+
+	waitFor, escalated := updateWaitingCondition(status, res, time.Now(), time.Hour)
+	g.Expect(waitFor).To(gomega.BeNumerically(">", 59*time.Minute))
+	g.Expect(escalated).To(gomega.BeFalse())
+	g.Expect(getCondition(status, WaitingForComponents).Reason).To(gomega.Equal("WaitingForComponents"))
+
+	created := time.Now().Add(-2 * time.Hour)
+
+	waitFor, escalated = updateWaitingCondition(status, res, created, time.Hour)
+	g.Expect(waitFor).To(gomega.BeZero())
+	g.Expect(escalated).To(gomega.BeTrue())
+	g.Expect(getCondition(status, WaitingForComponents).Reason).To(gomega.Equal(noComponentsAfterGracePeriod))
+
+	_, escalated = updateWaitingCondition(status, res, created, time.Hour)
+	g.Expect(escalated).To(gomega.BeFalse())
+
+	res.failures = map[string]error{"ConfigMap": errors.New("list failed")}
+	updateWaitingCondition(status, res, created, time.Hour)
+	g.Expect(getCondition(status, WaitingForComponents).Status).To(gomega.Equal(corev1.ConditionFalse))
+	g.Expect(getCondition(status, WaitingForComponents).Reason).To(gomega.Equal("ResolutionFailed"))
+
+	fresh := &appv1beta1.ApplicationStatus{}
+	updateWaitingCondition(fresh, &componentResolution{components: []*unstructured.Unstructured{{}}}, created, time.Hour)
+	g.Expect(getCondition(fresh, WaitingForComponents)).To(gomega.BeNil())
+}
+
+func TestResolveComponentsOptedOut(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	optedOut := newTestConfigMap("opted-out", map[string]string{"app": "test-app"})
+	optedOut.Annotations = map[string]string{utils.AnnotationNoAdopt: "true"}
+
+	r := newTestReconciler(newTestConfigMap("adopted", map[string]string{"app": "test-app"}), optedOut)
+
+	app := newTestApplication(configMapGK)
+	g.Expect(r.resolveComponents(context.TODO(), app).components).To(gomega.HaveLen(2))
+
+	r.options.NoAdoptAnnotation = utils.AnnotationNoAdopt
+
+	res := r.resolveComponents(context.TODO(), app)
+	g.Expect(res.components).To(gomega.HaveLen(1))
+	g.Expect(res.components[0].GetName()).To(gomega.Equal("adopted"))
+
+	r.options.NoAdoptAnnotation = "example.com/keep-out"
+	g.Expect(r.resolveComponents(context.TODO(), app).components).To(gomega.HaveLen(2))
+}
+
+func TestReconcileInterval(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	r := newTestReconciler()
+	r.options.MinReconcileInterval = 10 * time.Second
+
+	app := newTestApplication(configMapGK)
+	g.Expect(r.reconcileInterval(app)).To(gomega.BeZero())
+
+	r.options.ReconcileInterval = 5 * time.Minute
+	g.Expect(r.reconcileInterval(app)).To(gomega.Equal(5 * time.Minute))
+
+	app.Annotations = map[string]string{utils.AnnotationReconcileInterval: "30s"}
+	g.Expect(r.reconcileInterval(app)).To(gomega.Equal(30 * time.Second))
+
+	app.Annotations[utils.AnnotationReconcileInterval] = "1s"
+	g.Expect(r.reconcileInterval(app)).To(gomega.Equal(10 * time.Second))
+
+	app.Annotations[utils.AnnotationReconcileInterval] = "often"
+	g.Expect(r.reconcileInterval(app)).To(gomega.Equal(5 * time.Minute))
+}
+
+func TestResolveComponentList(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	list := newTestConfigMap("inventory", nil)
+	list.Data = map[string]string{
+		"components": "- kind: ConfigMap\n  name: listed\n- kind: ConfigMap\n  name: absent\n",
+	}
+
+	r := newTestReconciler(list, newTestConfigMap("listed", nil), newTestConfigMap("selected", map[string]string{"app": "test-app"}))
+
+	app := newTestApplication(configMapGK)
+	app.Annotations = map[string]string{utils.AnnotationComponentsConfigMap: "inventory/components"}
+
+	res := r.resolveComponents(context.TODO(), app)
+	g.Expect(res.failures).To(gomega.BeEmpty())
+	g.Expect(res.components).To(gomega.HaveLen(1))
+	g.Expect(res.components[0].GetName()).To(gomega.Equal("listed"))
+
+	app.Annotations[utils.AnnotationComponentsConfigMap] = "inventory/missing-key"
+	res = r.resolveComponents(context.TODO(), app)
+	g.Expect(res.failures).To(gomega.HaveLen(1))
+	g.Expect(res.components).To(gomega.BeEmpty())
+}
+
+func TestResolveComponentsFallbackSelector(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	r := newTestReconciler(
+		newTestConfigMap("legacy", map[string]string{"name": "test-app"}),
+		newTestConfigMap("other", map[string]string{"name": "other"}),
+	)
+
+	app := newTestApplication(configMapGK)
+	app.Annotations = map[string]string{
+		utils.AnnotationFallbackSelectors: `[{"matchLabels":{"name":"test-app"}},{"matchLabels":{"name":"other"}}]`,
+	}
+
+	res := r.resolveComponents(context.TODO(), app)
+	g.Expect(res.failures).To(gomega.BeEmpty())
+	g.Expect(res.components).To(gomega.HaveLen(1))
+	g.Expect(res.components[0].GetName()).To(gomega.Equal("legacy"))
+	g.Expect(res.selectorIndex).To(gomega.Equal(1))
+
+	status := &appv1beta1.ApplicationStatus{}
+	updateComponentStatus(status, res, defaultTestHealthPolicy)
+	g.Expect(getCondition(status, ComponentsSelector).Reason).To(gomega.Equal("FallbackSelector"))
+}
+
+func TestFilterComponentsByImage(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	newTestDeploymentWithImage := func(name, image string) *unstructured.Unstructured {
+		deploy := &appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+			Spec: appsv1.DeploymentSpec{
+				Template: corev1.PodTemplateSpec{
+					Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "main", Image: image}}},
+				},
+			},
+		}
+
+		return toUnstructured(g, deploy, appsv1.SchemeGroupVersion.WithKind("Deployment"))
+	}
+
+	cm := &unstructured.Unstructured{}
+	cm.SetGroupVersionKind(corev1.SchemeGroupVersion.WithKind("ConfigMap"))
+
+	items := []*unstructured.Unstructured{
+		newTestDeploymentWithImage("ubi", "registry.example.com/ubi8/nodejs:16"),
+		newTestDeploymentWithImage("alpine", "docker.io/library/alpine:3.16"),
+		cm,
+	}
+
+	app := newTestApplication()
+	app.Annotations = map[string]string{utils.AnnotationComponentImage: "ubi8/"}
+
+	filter, err := utils.GetImageFilter(app)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	filtered := filterComponentsByImage(items, filter)
+	g.Expect(filtered).To(gomega.HaveLen(2))
+	g.Expect(filtered[0].GetName()).To(gomega.Equal("ubi"))
+	g.Expect(filtered[1].GetKind()).To(gomega.Equal("ConfigMap"))
+
+	app.Annotations = map[string]string{utils.AnnotationComponentImageRegex: "("}
+	_, err = utils.GetImageFilter(app)
+	g.Expect(err).To(gomega.HaveOccurred())
+}
+
+func TestResolveComponentsDedupe(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	r := newTestReconciler(newTestConfigMap("matched", map[string]string{"app": "test-app"}))
+
+	// the same kind listed without and with a version resolves to the same objects
+	res := r.resolveComponents(context.TODO(), newTestApplication(configMapGK, metav1.GroupKind{Group: "v1", Kind: "ConfigMap"}))
+
+	g.Expect(res.failures).To(gomega.BeEmpty())
+	g.Expect(res.components).To(gomega.HaveLen(1))
+}
+
+func TestResolveComponentsFromCache(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	r := newTestReconciler(newTestConfigMap("listed", map[string]string{"app": "test-app"}))
+
+	cached := newTestConfigMap("cached", map[string]string{"app": "test-app"})
+	r.componentReader = fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(&cached).Build()
+
+	res := r.resolveComponents(context.TODO(), newTestApplication(configMapGK))
+	g.Expect(res.failures).To(gomega.BeEmpty())
+	g.Expect(res.components).To(gomega.HaveLen(1))
+	g.Expect(res.components[0].GetName()).To(gomega.Equal("cached"))
+}
@@ -0,0 +1,190 @@
+// Copyright 2019 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package application
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	appv1beta1 "sigs.k8s.io/application/api/v1beta1"
+)
+
+const (
+	// Degraded is set when components the application asserts to exist are missing, or when the controller is
+	// forbidden to resolve or patch components of the applications failing on forbidden accesses, its message
+	// then naming both
+	Degraded appv1beta1.ConditionType = "Degraded"
+	// AccessForbidden names the components the controller is forbidden to resolve or patch, on the applications
+	// failing on forbidden accesses. The forbidden accesses also turn Degraded true.
+	AccessForbidden appv1beta1.ConditionType = "AccessForbidden"
+	// ComponentsResolved reports how many components the application resolved to
+	ComponentsResolved appv1beta1.ConditionType = "ComponentsResolved"
+	// ComponentsSelector reports the index of the selector the components were resolved with, spec.selector
+	// being 0 and the fallback selectors following
+	ComponentsSelector appv1beta1.ConditionType = "ComponentsSelector"
+	// SelectorInvalid carries the error building a selector of the application, it is not retried until the
+	// application changes
+	SelectorInvalid appv1beta1.ConditionType = "SelectorInvalid"
+	// OwnerReferencesForbidden names the component kinds the controller lacks the permission to set owner references on
+	OwnerReferencesForbidden appv1beta1.ConditionType = "OwnerReferencesForbidden"
+	// WaitingForComponents is set while the application resolves to no components without any resolution error,
+	// as a new application whose workloads are not deployed yet. Its reason turns to NoComponentsAfterGracePeriod
+	// once the components grace period elapsed since the application creation. The resolution errors are
+	// reported by the Error and SelectorInvalid conditions instead.
+	WaitingForComponents appv1beta1.ConditionType = "WaitingForComponents"
+	// ConversionUnavailable records that the application could not be read or written for a while, the API server
+	// failing to convert it between the versions of its CRD. The reconciles back off meanwhile, the condition is
+	// written once the conversion works again.
+	ConversionUnavailable appv1beta1.ConditionType = "ConversionUnavailable"
+	// ReconcileIncomplete is set when the last reconcile was interrupted, the rest of the status is left as the
+	// previous complete reconcile wrote it. Its reason is WriteBudgetExhausted when the reconcile deferred some
+	// component patches to the next one, the rest of the status is current then.
+	ReconcileIncomplete appv1beta1.ConditionType = "ReconcileIncomplete"
+	// TerminatingNamespacesSkipped names the terminating namespaces selected by a template application, the
+	// template is not materialized into them while they drain
+	TerminatingNamespacesSkipped appv1beta1.ConditionType = "TerminatingNamespacesSkipped"
+	// ComponentKindsSuspended names the component kinds that were not listed, their lists having failed
+	// consecutively across the applications. The kinds are probed again once the breaker cooldown elapsed.
+	ComponentKindsSuspended appv1beta1.ConditionType = "ComponentKindsSuspended"
+	// SelectorDiagnostics reports, for the applications asking for it, how many components match the selector
+	// with each of its terms removed in turn. A term whose removal matches no more components does nothing.
+	SelectorDiagnostics appv1beta1.ConditionType = "SelectorDiagnostics"
+	// ComponentKindsMatched reports the number of components each componentGroupKind resolved to, it is false
+	// while some kinds match no component
+	ComponentKindsMatched appv1beta1.ConditionType = "ComponentKindsMatched"
+	// Paused is set while the writes to the components of the application are paused, its status and health
+	// are still published. A frozen application is not reconciled at all and keeps its last status.
+	Paused appv1beta1.ConditionType = "Paused"
+	// ClustersHealthy rolls up on the hub the health of the managed clusters the deployables of the application
+	// target, with the health aggregation rule of the application. The clusters that reported no status are
+	// named unreachable.
+	ClustersHealthy appv1beta1.ConditionType = "ClustersHealthy"
+	// AssemblySucceeded is set on the applications with a TTL after succeeded while spec.assemblyPhase is
+	// Succeeded, the application is deleted once its transition time is older than the TTL
+	AssemblySucceeded appv1beta1.ConditionType = "AssemblySucceeded"
+	// Healthy is only set while the Ready condition is true, its lastTransitionTime is the time the application
+	// last turned healthy. It is removed on any transition out of healthy, so its age is the time the application
+	// has been healthy for.
+	Healthy appv1beta1.ConditionType = "Healthy"
+)
+
+// MaintainedConditions are the condition types the controller sets on the applications
+var MaintainedConditions = []appv1beta1.ConditionType{
+	appv1beta1.Ready,
+	appv1beta1.Error,
+	Degraded,
+	AccessForbidden,
+	ComponentsResolved,
+	ComponentsSelector,
+	SelectorInvalid,
+	OwnerReferencesForbidden,
+	WaitingForComponents,
+	ConversionUnavailable,
+	ReconcileIncomplete,
+	TerminatingNamespacesSkipped,
+	ComponentKindsSuspended,
+	SelectorDiagnostics,
+	Paused,
+	ComponentKindsMatched,
+	ClustersHealthy,
+	AssemblySucceeded,
+	Healthy,
+}
+
+// IsMaintainedCondition returns true if the controller sets the condition type on the applications
+func IsMaintainedCondition(ctype string) bool {
+	for _, c := range MaintainedConditions {
+		if string(c) == ctype {
+			return true
+		}
+	}
+
+	return false
+}
+
+// setErrorCondition - shortcut to set error condition
+func setErrorCondition(appStatus *appv1beta1.ApplicationStatus, reason, message string) {
+	setCondition(appStatus, appv1beta1.Error, corev1.ConditionTrue, reason, message)
+}
+
+// clearErrorCondition - shortcut to clear error condition
+func clearErrorCondition(appStatus *appv1beta1.ApplicationStatus) {
+	setCondition(appStatus, appv1beta1.Error, corev1.ConditionFalse, "NoError", "No error seen")
+}
+
+// clearCondition sets an existing condition to false, a condition that was never set is not added
+func clearCondition(appStatus *appv1beta1.ApplicationStatus, ctype appv1beta1.ConditionType, reason, message string) {
+	if getCondition(appStatus, ctype) != nil {
+		setCondition(appStatus, ctype, corev1.ConditionFalse, reason, message)
+	}
+}
+
+// removeCondition drops the condition of the given type
+func removeCondition(appStatus *appv1beta1.ApplicationStatus, ctype appv1beta1.ConditionType) {
+	for i := range appStatus.Conditions {
+		if appStatus.Conditions[i].Type == ctype {
+			appStatus.Conditions = append(appStatus.Conditions[:i], appStatus.Conditions[i+1:]...)
+			return
+		}
+	}
+}
+
+// getCondition returns the condition of the given type, nil if it is not set
+func getCondition(appStatus *appv1beta1.ApplicationStatus, ctype appv1beta1.ConditionType) *appv1beta1.Condition {
+	for i := range appStatus.Conditions {
+		if appStatus.Conditions[i].Type == ctype {
+			return &appStatus.Conditions[i]
+		}
+	}
+
+	return nil
+}
+
+// setCondition updates the condition of the given type, the timestamps are only touched when the condition changes
+func setCondition(appStatus *appv1beta1.ApplicationStatus, ctype appv1beta1.ConditionType, status corev1.ConditionStatus,
+	reason, message string) {
+	now := metav1.Now()
+
+	for i := range appStatus.Conditions {
+		c := &appStatus.Conditions[i]
+		if c.Type != ctype {
+			continue
+		}
+
+		if c.Status == status && c.Reason == reason && c.Message == message {
+			return
+		}
+
+		c.LastUpdateTime = now
+
+		if c.Status != status {
+			c.LastTransitionTime = now
+		}
+
+		c.Status = status
+		c.Reason = reason
+		c.Message = message
+
+		return
+	}
+
+	appStatus.Conditions = append(appStatus.Conditions, appv1beta1.Condition{
+		Type:               ctype,
+		Status:             status,
+		Reason:             reason,
+		Message:            message,
+		LastUpdateTime:     now,
+		LastTransitionTime: now,
+	})
+}
@@ -0,0 +1,64 @@
+// Copyright 2019 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package application
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog"
+	appv1beta1 "sigs.k8s.io/application/api/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// conversionRetryPeriod is how long the reconcile of an application the API server fails to convert backs off,
+// the conversion webhook is expected back once the CRD upgrade completes
+const conversionRetryPeriod = 30 * time.Second
+
+// isConversionError returns true if the API server failed to convert the application between the versions of
+// its CRD, as when the conversion webhook is unavailable during a CRD upgrade
+func isConversionError(err error) bool {
+	return err != nil && strings.Contains(err.Error(), "conversion webhook for")
+}
+
+// backOffConversion requeues the application the API server failed to convert without returning an error, only
+// the first failure is logged as a warning until the application is readable again
+func (r *ReconcileApplication) backOffConversion(key types.NamespacedName, err error) (reconcile.Result, error) {
+	if _, failing := r.conversionFailures.LoadOrStore(key, time.Now()); failing {
+		klog.V(1).Info("Application ", key, " still fails to convert, backing off, error: ", err)
+	} else {
+		klog.Warning("Application ", key, " fails to convert between the CRD versions, backing off until the conversion works, error: ", err)
+	}
+
+	return reconcile.Result{RequeueAfter: conversionRetryPeriod}, nil
+}
+
+// updateConversionCondition records on the status of an application read again that it failed to convert for a
+// while, the condition is left as is by the later reconciles
+func (r *ReconcileApplication) updateConversionCondition(key types.NamespacedName, status *appv1beta1.ApplicationStatus) {
+	since, failing := r.conversionFailures.LoadAndDelete(key)
+	if !failing {
+		return
+	}
+
+	start := since.(time.Time)
+
+	setCondition(status, ConversionUnavailable, corev1.ConditionFalse, "ConversionRecovered",
+		fmt.Sprintf("the application failed to convert between the CRD versions for %s from %s",
+			time.Since(start).Round(time.Second), start.UTC().Format(time.RFC3339)))
+}
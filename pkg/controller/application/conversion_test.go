@@ -0,0 +1,69 @@
+// Copyright 2019 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package application
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	appv1beta1 "sigs.k8s.io/application/api/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// unconvertibleClient fails every get as the API server does while the conversion webhook is unavailable
+type unconvertibleClient struct {
+	client.Client
+}
+
+func (c *unconvertibleClient) Get(ctx context.Context, key client.ObjectKey, obj client.Object) error {
+	return errors.NewInternalError(fmt.Errorf("conversion webhook for app.k8s.io/v1beta2, Kind=Application failed: " +
+		"Post \"https://application-conversion.svc:443/convert\": dial tcp: connection refused"))
+}
+
+func TestReconcileConversionUnavailable(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	r := newTestReconciler()
+	r.Client = &unconvertibleClient{r.Client}
+
+	key := types.NamespacedName{Namespace: "default", Name: "test-app"}
+
+	for i := 0; i < 2; i++ {
+		result, err := r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: key})
+		g.Expect(err).NotTo(gomega.HaveOccurred())
+		g.Expect(result.RequeueAfter).To(gomega.Equal(conversionRetryPeriod))
+	}
+
+	status := &appv1beta1.ApplicationStatus{}
+	r.updateConversionCondition(key, status)
+
+	c := getCondition(status, ConversionUnavailable)
+	g.Expect(c).NotTo(gomega.BeNil())
+	g.Expect(c.Status).To(gomega.Equal(corev1.ConditionFalse))
+	g.Expect(c.Reason).To(gomega.Equal("ConversionRecovered"))
+
+	// recorded once, the next reconciles leave the condition alone
+	status = &appv1beta1.ApplicationStatus{}
+	r.updateConversionCondition(key, status)
+	g.Expect(getCondition(status, ConversionUnavailable)).To(gomega.BeNil())
+
+	g.Expect(isConversionError(errors.NewInternalError(fmt.Errorf("etcdserver: request timed out")))).To(gomega.BeFalse())
+}
@@ -0,0 +1,150 @@
+// Copyright 2019 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package application
+
+import (
+	"context"
+
+	"github.com/stolostron/multicloud-operators-application/utils"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog"
+	appv1beta1 "sigs.k8s.io/application/api/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// crdGVK is the kind of the CustomResourceDefinitions, they are watched unstructured as their types are not in
+// the scheme of the manager
+var crdGVK = schema.GroupVersionKind{Group: "apiextensions.k8s.io", Version: "v1", Kind: "CustomResourceDefinition"}
+
+// canWatchCRDs returns true when the operator is allowed to list and watch the CustomResourceDefinitions, the
+// watch is not set up otherwise
+func canWatchCRDs(ctx context.Context, c client.Client) bool {
+	for _, verb := range []string{"list", "watch"} {
+		review := &authorizationv1.SelfSubjectAccessReview{
+			Spec: authorizationv1.SelfSubjectAccessReviewSpec{
+				ResourceAttributes: &authorizationv1.ResourceAttributes{
+					Group:    crdGVK.Group,
+					Resource: "customresourcedefinitions",
+					Verb:     verb,
+				},
+			},
+		}
+
+		if err := c.Create(ctx, review); err != nil {
+			klog.Info("Not watching the CustomResourceDefinitions, failed to review the access: ", err)
+			return false
+		}
+
+		if !review.Status.Allowed {
+			klog.Info("Not watching the CustomResourceDefinitions, the operator is not allowed to ", verb, " them")
+			return false
+		}
+	}
+
+	return true
+}
+
+// crdEstablished returns true when the Established condition of the CustomResourceDefinition is true, its kind
+// is then served
+func crdEstablished(obj client.Object) bool {
+	u, ok := obj.(*unstructured.Unstructured)
+	if !ok {
+		return false
+	}
+
+	conditions, _, _ := unstructured.NestedSlice(u.Object, "status", "conditions")
+	for _, c := range conditions {
+		cond, ok := c.(map[string]interface{})
+		if ok && cond["type"] == "Established" && cond["status"] == "True" {
+			return true
+		}
+	}
+
+	return false
+}
+
+// crdEstablishedPredicate lets through the CustomResourceDefinitions created established or becoming established
+var crdEstablishedPredicate = predicate.Funcs{
+	CreateFunc: func(e event.CreateEvent) bool {
+		return crdEstablished(e.Object)
+	},
+	UpdateFunc: func(e event.UpdateEvent) bool {
+		return !crdEstablished(e.ObjectOld) && crdEstablished(e.ObjectNew)
+	},
+	DeleteFunc: func(e event.DeleteEvent) bool {
+		return false
+	},
+	GenericFunc: func(e event.GenericEvent) bool {
+		return false
+	},
+}
+
+// crdMapper enqueues the applications referencing the kind of a newly established CustomResourceDefinition, so
+// they resolve its resources without waiting for the next resync
+type crdMapper struct {
+	client.Client
+}
+
+func (mapper *crdMapper) Map(obj client.Object) []reconcile.Request {
+	u, ok := obj.(*unstructured.Unstructured)
+	if !ok {
+		return nil
+	}
+
+	group, _, _ := unstructured.NestedString(u.Object, "spec", "group")
+	kind, _, _ := unstructured.NestedString(u.Object, "spec", "names", "kind")
+
+	applicationList := &appv1beta1.ApplicationList{}
+	if err := mapper.List(context.TODO(), applicationList); err != nil {
+		klog.Error("Failed to list all application objects. ", "error: ", err)
+		return nil
+	}
+
+	var requests []reconcile.Request
+
+	for i := range applicationList.Items {
+		app := &applicationList.Items[i]
+		if referencesKind(app, group, kind) {
+			requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Name: app.Name, Namespace: app.Namespace}})
+		}
+	}
+
+	if len(requests) > 0 {
+		klog.Info("Enqueued ", len(requests), " applications for the established CustomResourceDefinition ", u.GetName())
+	}
+
+	return requests
+}
+
+// referencesKind returns true when the application resolves the kind, as a component or for its health
+func referencesKind(app *appv1beta1.Application, group, kind string) bool {
+	// the invalid health kinds are reported by the reconcile
+	healthKinds, _ := utils.GetHealthKinds(app)
+
+	for _, gk := range append(append([]metav1.GroupKind{}, app.Spec.ComponentGroupKinds...), healthKinds...) {
+		if appv1beta1.StripVersion(gk.Group) == group && gk.Kind == kind {
+			return true
+		}
+	}
+
+	return false
+}
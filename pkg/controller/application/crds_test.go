@@ -0,0 +1,105 @@
+// Copyright 2019 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package application
+
+import (
+	"context"
+	"testing"
+
+	"github.com/onsi/gomega"
+	"github.com/stolostron/multicloud-operators-application/utils"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	appv1beta1 "sigs.k8s.io/application/api/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+)
+
+func newTestCRD(group, kind string, established bool) *unstructured.Unstructured {
+	u := &unstructured.Unstructured{}
+	u.SetGroupVersionKind(crdGVK)
+	u.SetName("widgets." + group)
+	status := "False"
+	if established {
+		status = "True"
+	}
+
+	u.Object["spec"] = map[string]interface{}{"group": group, "names": map[string]interface{}{"kind": kind}}
+	u.Object["status"] = map[string]interface{}{"conditions": []interface{}{
+		map[string]interface{}{"type": "NamesAccepted", "status": "True"},
+		map[string]interface{}{"type": "Established", "status": status},
+	}}
+
+	return u
+}
+
+// accessReviewClient answers the self subject access reviews
+type accessReviewClient struct {
+	client.Client
+	allowed map[string]bool
+}
+
+func (c *accessReviewClient) Create(ctx context.Context, obj client.Object, opts ...client.CreateOption) error {
+	review := obj.(*authorizationv1.SelfSubjectAccessReview)
+	review.Status.Allowed = c.allowed[review.Spec.ResourceAttributes.Verb]
+
+	return nil
+}
+
+func TestCRDEstablished(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	pending := newTestCRD("example.com", "Widget", false)
+	established := newTestCRD("example.com", "Widget", true)
+
+	g.Expect(crdEstablishedPredicate.Create(event.CreateEvent{Object: pending})).To(gomega.BeFalse())
+	g.Expect(crdEstablishedPredicate.Create(event.CreateEvent{Object: established})).To(gomega.BeTrue())
+	g.Expect(crdEstablishedPredicate.Update(event.UpdateEvent{ObjectOld: pending, ObjectNew: established})).To(gomega.BeTrue())
+	g.Expect(crdEstablishedPredicate.Update(event.UpdateEvent{ObjectOld: established, ObjectNew: established})).To(gomega.BeFalse())
+
+	g.Expect(canWatchCRDs(context.TODO(), &accessReviewClient{allowed: map[string]bool{"list": true, "watch": true}})).To(gomega.BeTrue())
+	g.Expect(canWatchCRDs(context.TODO(), &accessReviewClient{allowed: map[string]bool{"list": true}})).To(gomega.BeFalse())
+}
+
+func TestCRDMapper(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	s := runtime.NewScheme()
+	g.Expect(clientgoscheme.AddToScheme(s)).To(gomega.Succeed())
+	g.Expect(appv1beta1.AddToScheme(s)).To(gomega.Succeed())
+
+	widgets := newTestApplication(metav1.GroupKind{Group: "example.com", Kind: "Widget"})
+	widgets.Name = "widgets"
+
+	health := newTestApplication(configMapGK)
+	health.Name = "health"
+	health.Annotations = map[string]string{utils.AnnotationHealthKinds: `[{"group":"example.com/v1","kind":"Widget"}]`}
+
+	other := newTestApplication(configMapGK)
+	other.Name = "other"
+
+	mapper := &crdMapper{fake.NewClientBuilder().WithScheme(s).WithObjects(widgets, health, other).Build()}
+
+	requests := mapper.Map(newTestCRD("example.com", "Widget", true))
+	g.Expect(requests).To(gomega.HaveLen(2))
+	g.Expect(requests[0].Name).To(gomega.Equal("health"))
+	g.Expect(requests[1].Name).To(gomega.Equal("widgets"))
+
+	g.Expect(mapper.Map(newTestCRD("example.com", "Gadget", true))).To(gomega.BeEmpty())
+}
@@ -0,0 +1,162 @@
+// Copyright 2019 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package application
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/stolostron/multicloud-operators-application/utils"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog"
+	appv1beta1 "sigs.k8s.io/application/api/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// DashboardLinks are the operator URL templates of the dashboards of the applications, keyed by the link name.
+// The templates are Go text templates executed with the application namespace, name, labels and selector match
+// labels, e.g. https://grafana.example.com/d/apps?var-namespace={{ .Namespace | urlquery }}
+type DashboardLinks struct {
+	templates map[string]*template.Template
+}
+
+// dashboardLinkData is what the URL templates are executed with
+type dashboardLinkData struct {
+	Namespace string
+	Name      string
+	Labels    map[string]string
+	// Selector is the spec.selector match labels of the application
+	Selector map[string]string
+	// SelectorString is the spec.selector match labels in the label selector syntax, app=foo,tier=web
+	SelectorString string
+}
+
+// NewDashboardLinks parses the URL templates, any invalid template fails all of them
+func NewDashboardLinks(templates map[string]string) (*DashboardLinks, error) {
+	links := &DashboardLinks{templates: map[string]*template.Template{}}
+
+	for name, text := range templates {
+		tmpl, err := template.New(name).Option("missingkey=zero").Parse(text)
+		if err != nil {
+			return nil, fmt.Errorf("invalid dashboard URL template %s: %w", name, err)
+		}
+
+		links.templates[name] = tmpl
+	}
+
+	return links, nil
+}
+
+// LoadDashboardLinks reads the URL templates from the ConfigMap, every key is a link name
+func LoadDashboardLinks(ctx context.Context, c client.Reader, key types.NamespacedName) (*DashboardLinks, error) {
+	cm := &corev1.ConfigMap{}
+	if err := c.Get(ctx, key, cm); err != nil {
+		return nil, fmt.Errorf("failed to get the dashboard links configmap %s: %w", key, err)
+	}
+
+	return NewDashboardLinks(cm.Data)
+}
+
+// Names returns the sorted names of the links
+func (d *DashboardLinks) Names() []string {
+	if d == nil {
+		return nil
+	}
+
+	names := make([]string, 0, len(d.templates))
+	for name := range d.templates {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+// render executes the templates for the application, a template failing to execute is left out
+func (d *DashboardLinks) render(app *appv1beta1.Application) map[string]string {
+	data := dashboardLinkData{
+		Namespace: app.Namespace,
+		Name:      app.Name,
+		Labels:    app.Labels,
+	}
+
+	if app.Spec.Selector != nil {
+		data.Selector = app.Spec.Selector.MatchLabels
+
+		pairs := make([]string, 0, len(data.Selector))
+		for k, v := range data.Selector {
+			pairs = append(pairs, k+"="+v)
+		}
+
+		sort.Strings(pairs)
+
+		data.SelectorString = strings.Join(pairs, ",")
+	}
+
+	urls := map[string]string{}
+
+	for name, tmpl := range d.templates {
+		var sb strings.Builder
+		if err := tmpl.Execute(&sb, data); err != nil {
+			klog.Error("Failed to render the dashboard link ", name, " of application ", app.Namespace+"/"+app.Name, " error: ", err)
+			continue
+		}
+
+		urls[name] = sb.String()
+	}
+
+	return urls
+}
+
+// setDashboardLinks writes the rendered dashboard links into the application annotation, the annotation is
+// removed when no link is configured. It returns true when the annotation changed.
+func (r *ReconcileApplication) setDashboardLinks(app *appv1beta1.Application) bool {
+	current, exists := app.GetAnnotations()[utils.AnnotationDashboardLinks]
+
+	if r.options.DashboardLinks == nil || len(r.options.DashboardLinks.templates) == 0 {
+		if !exists {
+			return false
+		}
+
+		delete(app.Annotations, utils.AnnotationDashboardLinks)
+
+		return true
+	}
+
+	// json sorts the map keys, the annotation is stable across reconciles
+	data, err := json.Marshal(r.options.DashboardLinks.render(app))
+	if err != nil {
+		klog.Error("Failed to encode the dashboard links of application ", app.Namespace+"/"+app.Name, " error: ", err)
+		return false
+	}
+
+	if exists && current == string(data) {
+		return false
+	}
+
+	if app.Annotations == nil {
+		app.Annotations = map[string]string{}
+	}
+
+	app.Annotations[utils.AnnotationDashboardLinks] = string(data)
+
+	return true
+}
@@ -0,0 +1,57 @@
+// Copyright 2019 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package application
+
+import (
+	"testing"
+
+	"github.com/onsi/gomega"
+	"github.com/stolostron/multicloud-operators-application/utils"
+)
+
+func TestSetDashboardLinks(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	_, err := NewDashboardLinks(map[string]string{"broken": "{{ .Name"})
+	g.Expect(err).To(gomega.HaveOccurred())
+
+	links, err := NewDashboardLinks(map[string]string{
+		"grafana": "https://grafana.example.com/d/apps?var-ns={{ .Namespace }}&var-app={{ .Name | urlquery }}",
+		"kiali":   "https://kiali.example.com/graph?selector={{ .SelectorString | urlquery }}&team={{ .Labels.team }}",
+	})
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(links.Names()).To(gomega.Equal([]string{"grafana", "kiali"}))
+
+	r := newTestReconciler()
+	r.options.DashboardLinks = links
+
+	app := newTestApplication(configMapGK)
+
+	g.Expect(r.setDashboardLinks(app)).To(gomega.BeTrue())
+	g.Expect(app.Annotations[utils.AnnotationDashboardLinks]).To(gomega.MatchJSON(`{
+		"grafana": "https://grafana.example.com/d/apps?var-ns=default&var-app=test-app",
+		"kiali": "https://kiali.example.com/graph?selector=app%3Dtest-app&team="
+	}`))
+
+	g.Expect(r.setDashboardLinks(app)).To(gomega.BeFalse())
+
+	app.Labels = map[string]string{"team": "payments"}
+	g.Expect(r.setDashboardLinks(app)).To(gomega.BeTrue())
+	g.Expect(app.Annotations[utils.AnnotationDashboardLinks]).To(gomega.ContainSubstring("team=payments"))
+
+	r.options.DashboardLinks = nil
+	g.Expect(r.setDashboardLinks(app)).To(gomega.BeTrue())
+	g.Expect(app.Annotations).NotTo(gomega.HaveKey(utils.AnnotationDashboardLinks))
+}
@@ -0,0 +1,183 @@
+// Copyright 2019 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package application
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/stolostron/multicloud-operators-application/utils"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog"
+	appv1beta1 "sigs.k8s.io/application/api/v1beta1"
+)
+
+// selectorDiagnosticsMaxTerms bounds the lists made by the diagnostics, each term lists every component kind
+const selectorDiagnosticsMaxTerms = 10
+
+// selectorTermMatches is the number of components matching the selector without one of its terms
+type selectorTermMatches struct {
+	term    string
+	matches int
+}
+
+// selectorTerms splits the selector into its match labels, sorted by key, and its expressions, each returned
+// as a selector of that term alone
+func selectorTerms(sel *metav1.LabelSelector) []*metav1.LabelSelector {
+	var terms []*metav1.LabelSelector
+
+	keys := make([]string, 0, len(sel.MatchLabels))
+	for k := range sel.MatchLabels {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		terms = append(terms, &metav1.LabelSelector{MatchLabels: map[string]string{k: sel.MatchLabels[k]}})
+	}
+
+	for _, expr := range sel.MatchExpressions {
+		terms = append(terms, &metav1.LabelSelector{MatchExpressions: []metav1.LabelSelectorRequirement{expr}})
+	}
+
+	return terms
+}
+
+// withoutTerm returns a copy of the selector without the ith term of selectorTerms
+func withoutTerm(sel *metav1.LabelSelector, i int) *metav1.LabelSelector {
+	relaxed := &metav1.LabelSelector{MatchLabels: map[string]string{}}
+
+	for j, term := range selectorTerms(sel) {
+		if j == i {
+			continue
+		}
+
+		for k, v := range term.MatchLabels {
+			relaxed.MatchLabels[k] = v
+		}
+
+		relaxed.MatchExpressions = append(relaxed.MatchExpressions, term.MatchExpressions...)
+	}
+
+	return relaxed
+}
+
+// diagnoseSelector resolves the components again with each term of the selector they were resolved with
+// removed in turn, only the removal of a single term is tried to bound the lists. It returns the diagnostics
+// of the first terms and the number of terms of the selector.
+func (r *ReconcileApplication) diagnoseSelector(ctx context.Context, app *appv1beta1.Application,
+	res *componentResolution) ([]selectorTermMatches, int, error) {
+	selectors, err := utils.GetSelectors(app)
+	if err != nil || res.selectorIndex >= len(selectors) || selectors[res.selectorIndex] == nil {
+		return nil, 0, err
+	}
+
+	imageFilter, err := utils.GetImageFilter(app)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	namespaces, err := utils.GetComponentNamespaces(app)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	sel := selectors[res.selectorIndex]
+	terms := selectorTerms(sel)
+	total := len(terms)
+
+	if total > selectorDiagnosticsMaxTerms {
+		terms = terms[:selectorDiagnosticsMaxTerms]
+	}
+
+	diagnostics := make([]selectorTermMatches, 0, len(terms))
+
+	for i, term := range terms {
+		scratch := &componentResolution{failures: map[string]error{}, reader: res.reader}
+		components := r.resolveKinds(ctx, app, app.Spec.ComponentGroupKinds, withoutTerm(sel, i), imageFilter, namespaces, scratch)
+
+		if scratch.interrupted != nil {
+			return nil, 0, scratch.interrupted
+		}
+
+		if len(scratch.failures) > 0 || scratch.selectorErr != nil {
+			return nil, 0, fmt.Errorf("failed to resolve the components without %s: %s", describeTerm(term),
+				scratch.failureMessage())
+		}
+
+		diagnostics = append(diagnostics, selectorTermMatches{
+			term:    describeTerm(term),
+			matches: len(dedupeComponents(app, components)),
+		})
+	}
+
+	return diagnostics, total, nil
+}
+
+// describeTerm renders the term in the label selector syntax
+func describeTerm(term *metav1.LabelSelector) string {
+	selector, err := metav1.LabelSelectorAsSelector(term)
+	if err != nil {
+		return fmt.Sprintf("%v", term)
+	}
+
+	return selector.String()
+}
+
+// updateSelectorDiagnosticsCondition reports the diagnostics of the selector, the condition is cleared once
+// the application no longer asks for them
+func (r *ReconcileApplication) updateSelectorDiagnosticsCondition(ctx context.Context, app *appv1beta1.Application,
+	res *componentResolution, status *appv1beta1.ApplicationStatus) {
+	if !utils.IsDiagnosingSelector(app) {
+		clearCondition(status, SelectorDiagnostics, "NotRequested", "the selector diagnostics are not requested")
+		return
+	}
+
+	if res.selectors == 0 || res.selectorErr != nil {
+		setCondition(status, SelectorDiagnostics, corev1.ConditionFalse, "NoSelector",
+			"the components are not resolved with a selector")
+		return
+	}
+
+	diagnostics, total, err := r.diagnoseSelector(ctx, app, res)
+	if err != nil {
+		klog.Error("Failed to diagnose the selector of application ", app.Namespace+"/"+app.Name, " error: ", err)
+		setCondition(status, SelectorDiagnostics, corev1.ConditionFalse, "DiagnosticsFailed", err.Error())
+
+		return
+	}
+
+	parts := make([]string, 0, len(diagnostics))
+	for _, d := range diagnostics {
+		parts = append(parts, fmt.Sprintf("without %s: %d", d.term, d.matches))
+	}
+
+	msg := fmt.Sprintf("%d components match selector %d", len(res.components), res.selectorIndex)
+	if len(parts) > 0 {
+		msg += "; " + strings.Join(parts, ", ")
+	}
+
+	if total > len(diagnostics) {
+		msg += fmt.Sprintf("; only the first %d of the %d terms are diagnosed", len(diagnostics), total)
+	}
+
+	klog.Info("Selector diagnostics of application ", app.Namespace+"/"+app.Name, ": ", msg)
+
+	setCondition(status, SelectorDiagnostics, corev1.ConditionTrue, "Diagnosed", msg)
+}
@@ -0,0 +1,67 @@
+// Copyright 2019 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package application
+
+import (
+	"context"
+	"testing"
+
+	"github.com/onsi/gomega"
+	"github.com/stolostron/multicloud-operators-application/utils"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestSelectorDiagnostics(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	r := newTestReconciler(
+		newTestConfigMap("web", map[string]string{"app": "test-app", "tier": "web"}),
+		newTestConfigMap("db", map[string]string{"app": "test-app", "tier": "db"}),
+		newTestConfigMap("other-web", map[string]string{"app": "other", "tier": "web"}),
+	)
+
+	app := newTestApplication(configMapGK)
+	app.Spec.Selector.MatchExpressions = []metav1.LabelSelectorRequirement{
+		{Key: "tier", Operator: metav1.LabelSelectorOpIn, Values: []string{"web"}},
+	}
+
+	res := r.resolveComponents(context.TODO(), app)
+	g.Expect(res.components).To(gomega.HaveLen(1))
+
+	diagnostics, total, err := r.diagnoseSelector(context.TODO(), app, res)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(total).To(gomega.Equal(2))
+	g.Expect(diagnostics).To(gomega.Equal([]selectorTermMatches{
+		{term: "app=test-app", matches: 2},
+		{term: "tier in (web)", matches: 2},
+	}))
+
+	// the diagnostics are opt-in
+	r.updateSelectorDiagnosticsCondition(context.TODO(), app, res, &app.Status)
+	g.Expect(getCondition(&app.Status, SelectorDiagnostics)).To(gomega.BeNil())
+
+	app.Annotations = map[string]string{utils.AnnotationDiagnoseSelector: "true"}
+	r.updateSelectorDiagnosticsCondition(context.TODO(), app, res, &app.Status)
+
+	cond := getCondition(&app.Status, SelectorDiagnostics)
+	g.Expect(cond).NotTo(gomega.BeNil())
+	g.Expect(cond.Status).To(gomega.Equal(corev1.ConditionTrue))
+	g.Expect(cond.Message).To(gomega.Equal("1 components match selector 0; without app=test-app: 2, without tier in (web): 2"))
+
+	delete(app.Annotations, utils.AnnotationDiagnoseSelector)
+	r.updateSelectorDiagnosticsCondition(context.TODO(), app, res, &app.Status)
+	g.Expect(getCondition(&app.Status, SelectorDiagnostics).Status).To(gomega.Equal(corev1.ConditionFalse))
+}
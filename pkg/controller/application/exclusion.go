@@ -0,0 +1,81 @@
+// Copyright 2019 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package application
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// NamespaceExclusion are the namespaces excluded by the operator from the resolution of every application, by
+// name or by a label selector. The components in those namespaces are neither listed nor adopted, whatever the
+// selectors of the applications.
+type NamespaceExclusion struct {
+	names    map[string]bool
+	selector labels.Selector
+}
+
+// NewNamespaceExclusion excludes the named namespaces and the namespaces matching the label selector, no
+// namespace is excluded by the selector when it is empty
+func NewNamespaceExclusion(names []string, selector string) (*NamespaceExclusion, error) {
+	e := &NamespaceExclusion{names: make(map[string]bool, len(names))}
+
+	for _, name := range names {
+		e.names[name] = true
+	}
+
+	if selector != "" {
+		sel, err := labels.Parse(selector)
+		if err != nil {
+			return nil, fmt.Errorf("invalid excluded namespace selector %q: %w", selector, err)
+		}
+
+		e.selector = sel
+	}
+
+	return e, nil
+}
+
+// excludes returns true if the namespace is excluded, the namespace labels are read for the selector only
+func (e *NamespaceExclusion) excludes(ctx context.Context, c client.Reader, namespace string) (bool, error) {
+	if e == nil {
+		return false, nil
+	}
+
+	if e.names[namespace] {
+		return true, nil
+	}
+
+	if e.selector == nil {
+		return false, nil
+	}
+
+	ns := &corev1.Namespace{}
+	if err := c.Get(ctx, types.NamespacedName{Name: namespace}, ns); err != nil {
+		if errors.IsNotFound(err) {
+			return false, nil
+		}
+
+		return false, fmt.Errorf("failed to get namespace %s: %w", namespace, err)
+	}
+
+	return e.selector.Matches(labels.Set(ns.Labels)), nil
+}
@@ -0,0 +1,72 @@
+// Copyright 2019 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package application
+
+import (
+	"context"
+	"testing"
+
+	"github.com/onsi/gomega"
+	"github.com/stolostron/multicloud-operators-application/utils"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestResolveComponentsExcludedNamespaces(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	_, err := NewNamespaceExclusion(nil, "tier in (")
+	g.Expect(err).To(gomega.HaveOccurred())
+
+	system := newTestConfigMap("system", map[string]string{"app": "test-app"})
+	system.Namespace = "kube-system"
+
+	tenant := newTestConfigMap("tenant", map[string]string{"app": "test-app"})
+	tenant.Namespace = "tenant"
+
+	r := newTestReconciler()
+	r.Client = fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "tenant", Labels: map[string]string{"tier": "system"}}},
+		&system, &tenant,
+		&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "default", Namespace: "default",
+			Labels: map[string]string{"app": "test-app"}}},
+	).Build()
+
+	exclusion, err := NewNamespaceExclusion([]string{"kube-system"}, "tier=system")
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	r.options.ExcludedNamespaces = exclusion
+
+	app := newTestApplication(configMapGK)
+	res := r.resolveComponents(context.TODO(), app)
+	g.Expect(res.failures).To(gomega.BeEmpty())
+	g.Expect(res.components).To(gomega.HaveLen(1))
+
+	// the namespaces are excluded by name and by label, after the component namespaces of the application
+	for _, ns := range []string{"kube-system", "tenant"} {
+		app.Annotations = map[string]string{utils.AnnotationComponentNamespaces: `{"ConfigMap": "` + ns + `"}`}
+
+		res = r.resolveComponents(context.TODO(), app)
+		g.Expect(res.failures).To(gomega.BeEmpty())
+		g.Expect(res.components).To(gomega.BeEmpty(), ns)
+	}
+
+	r.options.ExcludedNamespaces = nil
+	res = r.resolveComponents(context.TODO(), app)
+	g.Expect(res.components).To(gomega.HaveLen(1))
+	g.Expect(res.components[0].GetName()).To(gomega.Equal("tenant"))
+}
@@ -0,0 +1,121 @@
+// Copyright 2019 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package application
+
+import (
+	"encoding/json"
+	"sort"
+
+	"github.com/stolostron/multicloud-operators-application/utils"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/klog"
+	appv1beta1 "sigs.k8s.io/application/api/v1beta1"
+)
+
+// DefaultExportedComponentsMaxBytes keeps the exported components well below the 256KiB limit of all annotations
+const DefaultExportedComponentsMaxBytes = 32 * 1024
+
+// exportedComponent is a component in the exported components annotation, the namespace is only set for the
+// components outside the application namespace
+type exportedComponent struct {
+	Group     string `json:"group,omitempty"`
+	Kind      string `json:"kind"`
+	Namespace string `json:"namespace,omitempty"`
+	Name      string `json:"name"`
+}
+
+// exportedComponentsTruncated replaces the exported components past the size cap
+type exportedComponentsTruncated struct {
+	Truncated bool `json:"truncated"`
+	Count     int  `json:"count"`
+}
+
+// exportComponents encodes the sorted components, or the truncation marker when they exceed maxBytes
+func exportComponents(app *appv1beta1.Application, components []*unstructured.Unstructured, maxBytes int) ([]byte, error) {
+	exported := make([]exportedComponent, 0, len(components))
+
+	for _, u := range components {
+		c := exportedComponent{
+			Group: u.GroupVersionKind().Group,
+			Kind:  u.GetKind(),
+			Name:  u.GetName(),
+		}
+
+		if u.GetNamespace() != app.Namespace {
+			c.Namespace = u.GetNamespace()
+		}
+
+		exported = append(exported, c)
+	}
+
+	sort.Slice(exported, func(i, j int) bool {
+		a, b := exported[i], exported[j]
+		if a.Group != b.Group {
+			return a.Group < b.Group
+		}
+
+		if a.Kind != b.Kind {
+			return a.Kind < b.Kind
+		}
+
+		if a.Namespace != b.Namespace {
+			return a.Namespace < b.Namespace
+		}
+
+		return a.Name < b.Name
+	})
+
+	data, err := json.Marshal(exported)
+	if err != nil || len(data) <= maxBytes {
+		return data, err
+	}
+
+	return json.Marshal(exportedComponentsTruncated{Truncated: true, Count: len(exported)})
+}
+
+// setExportedComponents writes the components into the exported components annotation of the applications
+// opting in, the annotation is removed once the application opts out. It returns true when the annotation changed.
+func (r *ReconcileApplication) setExportedComponents(app *appv1beta1.Application, components []*unstructured.Unstructured) bool {
+	current, exists := app.GetAnnotations()[utils.AnnotationExportedComponents]
+
+	if !utils.IsExportingComponents(app) {
+		if !exists {
+			return false
+		}
+
+		delete(app.Annotations, utils.AnnotationExportedComponents)
+
+		return true
+	}
+
+	maxBytes := r.options.ExportedComponentsMaxBytes
+	if maxBytes <= 0 {
+		maxBytes = DefaultExportedComponentsMaxBytes
+	}
+
+	data, err := exportComponents(app, components, maxBytes)
+	if err != nil {
+		klog.Error("Failed to encode the components of application ", app.Namespace+"/"+app.Name, " error: ", err)
+		return false
+	}
+
+	if exists && current == string(data) {
+		return false
+	}
+
+	app.Annotations[utils.AnnotationExportedComponents] = string(data)
+
+	return true
+}
@@ -0,0 +1,56 @@
+// Copyright 2019 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package application
+
+import (
+	"context"
+	"testing"
+
+	"github.com/onsi/gomega"
+	"github.com/stolostron/multicloud-operators-application/utils"
+)
+
+func TestSetExportedComponents(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	shared := newTestConfigMap("shared", map[string]string{"app": "test-app"})
+	shared.Namespace = "ns-b"
+
+	r := newTestReconciler(newTestConfigMap("web", map[string]string{"app": "test-app"}),
+		newTestConfigMap("db", map[string]string{"app": "test-app"}), shared)
+
+	app := newTestApplication(configMapGK)
+	components := r.resolveComponents(context.TODO(), app).components
+
+	app.Annotations = map[string]string{utils.AnnotationComponentNamespaces: `{"ConfigMap":"ns-b"}`}
+	components = append(components, r.resolveComponents(context.TODO(), app).components...)
+	g.Expect(components).To(gomega.HaveLen(3))
+
+	g.Expect(r.setExportedComponents(app, components)).To(gomega.BeFalse())
+
+	app.Annotations[utils.AnnotationExportComponents] = "true"
+	g.Expect(r.setExportedComponents(app, components)).To(gomega.BeTrue())
+	g.Expect(app.Annotations[utils.AnnotationExportedComponents]).To(gomega.Equal(
+		`[{"kind":"ConfigMap","name":"db"},{"kind":"ConfigMap","name":"web"},{"kind":"ConfigMap","namespace":"ns-b","name":"shared"}]`))
+	g.Expect(r.setExportedComponents(app, components)).To(gomega.BeFalse())
+
+	r.options.ExportedComponentsMaxBytes = 64
+	g.Expect(r.setExportedComponents(app, components)).To(gomega.BeTrue())
+	g.Expect(app.Annotations[utils.AnnotationExportedComponents]).To(gomega.Equal(`{"truncated":true,"count":3}`))
+
+	delete(app.Annotations, utils.AnnotationExportComponents)
+	g.Expect(r.setExportedComponents(app, components)).To(gomega.BeTrue())
+	g.Expect(app.Annotations).NotTo(gomega.HaveKey(utils.AnnotationExportedComponents))
+}
@@ -0,0 +1,67 @@
+// Copyright 2019 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package application
+
+import (
+	"context"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// fieldOwnerClient writes with the field manager of the operator, rather than the one the API server derives from
+// the user agent, so the fields the controller sets are told apart from the other controllers' in managed fields
+type fieldOwnerClient struct {
+	client.Client
+	owner client.FieldOwner
+}
+
+// withFieldOwner returns the client writing with the field manager, the client itself when none is set
+func withFieldOwner(c client.Client, fieldManager string) client.Client {
+	if fieldManager == "" {
+		return c
+	}
+
+	return &fieldOwnerClient{Client: c, owner: client.FieldOwner(fieldManager)}
+}
+
+func (c *fieldOwnerClient) Create(ctx context.Context, obj client.Object, opts ...client.CreateOption) error {
+	return c.Client.Create(ctx, obj, append([]client.CreateOption{c.owner}, opts...)...)
+}
+
+func (c *fieldOwnerClient) Update(ctx context.Context, obj client.Object, opts ...client.UpdateOption) error {
+	return c.Client.Update(ctx, obj, append([]client.UpdateOption{c.owner}, opts...)...)
+}
+
+func (c *fieldOwnerClient) Patch(ctx context.Context, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+	return c.Client.Patch(ctx, obj, patch, append([]client.PatchOption{c.owner}, opts...)...)
+}
+
+func (c *fieldOwnerClient) Status() client.StatusWriter {
+	return &fieldOwnerStatusWriter{StatusWriter: c.Client.Status(), owner: c.owner}
+}
+
+type fieldOwnerStatusWriter struct {
+	client.StatusWriter
+	owner client.FieldOwner
+}
+
+func (w *fieldOwnerStatusWriter) Update(ctx context.Context, obj client.Object, opts ...client.UpdateOption) error {
+	return w.StatusWriter.Update(ctx, obj, append([]client.UpdateOption{w.owner}, opts...)...)
+}
+
+func (w *fieldOwnerStatusWriter) Patch(ctx context.Context, obj client.Object, patch client.Patch,
+	opts ...client.PatchOption) error {
+	return w.StatusWriter.Patch(ctx, obj, patch, append([]client.PatchOption{w.owner}, opts...)...)
+}
@@ -0,0 +1,52 @@
+// Copyright 2019 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package application
+
+import (
+	"context"
+	"testing"
+
+	"github.com/onsi/gomega"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// fieldManagerClient records the field manager of the last patch
+type fieldManagerClient struct {
+	client.Client
+	fieldManager string
+}
+
+func (c *fieldManagerClient) Patch(ctx context.Context, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+	c.fieldManager = (&client.PatchOptions{}).ApplyOptions(opts).FieldManager
+
+	return c.Client.Patch(ctx, obj, patch, opts...)
+}
+
+func TestWithFieldOwner(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	cm := newTestConfigMap("settings", nil)
+	r := newTestReconciler(cm)
+	recorder := &fieldManagerClient{Client: r.Client}
+
+	g.Expect(withFieldOwner(recorder, "")).To(gomega.BeIdenticalTo(recorder))
+
+	c := withFieldOwner(recorder, "acm-application")
+	orig := cm.DeepCopy()
+	cm.Labels = map[string]string{"app": "test-app"}
+
+	g.Expect(c.Patch(context.TODO(), &cm, client.MergeFrom(orig))).To(gomega.Succeed())
+	g.Expect(recorder.fieldManager).To(gomega.Equal("acm-application"))
+}
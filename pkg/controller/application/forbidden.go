@@ -0,0 +1,101 @@
+// Copyright 2019 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package application
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/stolostron/multicloud-operators-application/utils"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	appv1beta1 "sigs.k8s.io/application/api/v1beta1"
+)
+
+// forbiddenPattern matches the authorization failures of the API server, capturing the user and the verb denied
+var forbiddenPattern = regexp.MustCompile(`User "([^"]*)" cannot ([a-z]+)`)
+
+// failsOnForbidden returns true if the forbidden accesses of the application are reported in its Degraded condition,
+// as they always are for the applications resolved as a ServiceAccount
+func (r *ReconcileApplication) failsOnForbidden(app *appv1beta1.Application) bool {
+	return r.options.FailOnForbidden || utils.IsFailingOnForbidden(app) || app.GetAnnotations()[utils.AnnotationResolveAs] != ""
+}
+
+// forbiddenMessage describes the accesses the controller was forbidden during the reconcile, the components it
+// could not resolve and the kinds it could not set owner references on. It is empty when none was forbidden.
+func (r *ReconcileApplication) forbiddenMessage(res *componentResolution, ownerRefsForbidden []string) string {
+	sources := make([]string, 0, len(res.failures))
+	for source := range res.failures {
+		sources = append(sources, source)
+	}
+
+	sort.Strings(sources)
+
+	var msgs []string
+
+	for _, source := range sources {
+		err := res.failures[source]
+		if !errors.IsForbidden(err) {
+			continue
+		}
+
+		verb, user := "access", r.options.ServiceAccount
+		if res.resolvedAs != "" {
+			user = res.resolvedAs
+		}
+
+		if m := forbiddenPattern.FindStringSubmatch(err.Error()); m != nil {
+			user, verb = m[1], m[2]
+		}
+
+		msgs = append(msgs, describeForbidden(source, verb, user))
+	}
+
+	for _, kind := range ownerRefsForbidden {
+		msgs = append(msgs, describeForbidden(kind, "patch", r.options.ServiceAccount))
+	}
+
+	return strings.Join(msgs, "; ")
+}
+
+func describeForbidden(source, verb, user string) string {
+	if user == "" {
+		user = "the operator"
+	}
+
+	return fmt.Sprintf("%s cannot %s %s", user, verb, source)
+}
+
+// updateForbiddenCondition sets the AccessForbidden condition naming the forbidden accesses, it turns false once
+// none was forbidden. The forbidden accesses also turn the Degraded condition true, keeping the required components
+// it reports in its message, so it is updated after the required components status.
+func updateForbiddenCondition(status *appv1beta1.ApplicationStatus, forbidden string) {
+	if forbidden == "" {
+		clearCondition(status, AccessForbidden, "Allowed", "no access was forbidden")
+		return
+	}
+
+	msg := "missing RBAC permissions: " + forbidden
+
+	setCondition(status, AccessForbidden, corev1.ConditionTrue, "Forbidden", msg)
+
+	if c := getCondition(status, Degraded); c != nil && c.Status != corev1.ConditionFalse {
+		msg += "; " + c.Message
+	}
+
+	setCondition(status, Degraded, corev1.ConditionTrue, "Forbidden", msg)
+}
@@ -0,0 +1,96 @@
+// Copyright 2019 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package application
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/onsi/gomega"
+	"github.com/stolostron/multicloud-operators-application/utils"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	appv1beta1 "sigs.k8s.io/application/api/v1beta1"
+)
+
+func TestForbiddenMessage(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	r := newTestReconciler()
+	r.options.ServiceAccount = "system:serviceaccount:ocm:application"
+
+	app := newTestApplication(configMapGK)
+	g.Expect(r.failsOnForbidden(app)).To(gomega.BeFalse())
+
+	app.Annotations = map[string]string{utils.AnnotationFailOnForbidden: "true"}
+	g.Expect(r.failsOnForbidden(app)).To(gomega.BeTrue())
+
+	res := &componentResolution{failures: map[string]error{
+		"ConfigMap": errors.NewForbidden(schema.GroupResource{Resource: "configmaps"}, "", fmt.Errorf(
+			`User "system:serviceaccount:ocm:other" cannot list resource "configmaps" in API group "" in the namespace "default"`)),
+		"Secret":          errors.NewForbidden(schema.GroupResource{Resource: "secrets"}, "", fmt.Errorf("denied")),
+		"Deployment.apps": fmt.Errorf("timeout"),
+	}}
+
+	g.Expect(r.forbiddenMessage(res, []string{"Service"})).To(gomega.Equal(
+		"system:serviceaccount:ocm:other cannot list ConfigMap; " +
+			"system:serviceaccount:ocm:application cannot access Secret; " +
+			"system:serviceaccount:ocm:application cannot patch Service"))
+
+	g.Expect(r.forbiddenMessage(&componentResolution{}, nil)).To(gomega.BeEmpty())
+}
+
+func TestUpdateForbiddenCondition(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	status := &appv1beta1.ApplicationStatus{}
+
+	updateForbiddenCondition(status, "")
+	g.Expect(getCondition(status, AccessForbidden)).To(gomega.BeNil())
+	g.Expect(getCondition(status, Degraded)).To(gomega.BeNil())
+
+	updateRequiredComponentsStatus(status, &requiredComponentsCheck{}, nil)
+	updateForbiddenCondition(status, "the operator cannot list ConfigMap")
+
+	for _, ctype := range []appv1beta1.ConditionType{AccessForbidden, Degraded} {
+		c := getCondition(status, ctype)
+		g.Expect(c.Status).To(gomega.Equal(corev1.ConditionTrue))
+		g.Expect(c.Reason).To(gomega.Equal("Forbidden"))
+		g.Expect(c.Message).To(gomega.Equal("missing RBAC permissions: the operator cannot list ConfigMap"))
+	}
+
+	// the missing required components are reported along the forbidden accesses
+	updateRequiredComponentsStatus(status, &requiredComponentsCheck{missing: []string{"ConfigMap/absent"}}, nil)
+	updateForbiddenCondition(status, "the operator cannot list ConfigMap")
+
+	c := getCondition(status, Degraded)
+	g.Expect(c.Status).To(gomega.Equal(corev1.ConditionTrue))
+	g.Expect(c.Reason).To(gomega.Equal("Forbidden"))
+	g.Expect(c.Message).To(gomega.Equal("missing RBAC permissions: the operator cannot list ConfigMap; " +
+		"missing required components: ConfigMap/absent"))
+	g.Expect(getCondition(status, AccessForbidden).Message).To(gomega.Equal(
+		"missing RBAC permissions: the operator cannot list ConfigMap"))
+
+	// the required components alone once the access is granted
+	updateRequiredComponentsStatus(status, &requiredComponentsCheck{missing: []string{"ConfigMap/absent"}}, nil)
+	updateForbiddenCondition(status, "")
+	g.Expect(getCondition(status, AccessForbidden).Status).To(gomega.Equal(corev1.ConditionFalse))
+	g.Expect(getCondition(status, Degraded).Reason).To(gomega.Equal("RequiredComponentsMissing"))
+
+	updateRequiredComponentsStatus(status, &requiredComponentsCheck{}, nil)
+	updateForbiddenCondition(status, "")
+	g.Expect(getCondition(status, Degraded).Status).To(gomega.Equal(corev1.ConditionFalse))
+}
@@ -0,0 +1,190 @@
+// Copyright 2019 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package application
+
+import (
+	"encoding/json"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/stolostron/multicloud-operators-application/pkg/apis"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	appv1beta1 "sigs.k8s.io/application/api/v1beta1"
+)
+
+// DefaultComponentGraphMaxNodes bounds the graph of an application when no cap is given
+const DefaultComponentGraphMaxNodes = 500
+
+// The relations of the graph edges, an edge points from the application or the owner to the object
+const (
+	GraphRelationComponent = "component"
+	GraphRelationChild     = "child"
+	GraphRelationOwner     = "owner"
+)
+
+// GraphNode is an application or a component of the graph, the namespace is only set for the objects outside
+// the application namespace
+type GraphNode struct {
+	ID        string `json:"id"`
+	Group     string `json:"group,omitempty"`
+	Kind      string `json:"kind"`
+	Namespace string `json:"namespace,omitempty"`
+	Name      string `json:"name"`
+}
+
+// GraphEdge links two nodes of the graph by their ID
+type GraphEdge struct {
+	From     string `json:"from"`
+	To       string `json:"to"`
+	Relation string `json:"relation"`
+}
+
+// ComponentGraph is the read-only graph of the resolved components of an application, the application links to
+// its components and child applications, and the owners among them link to the objects they own. Count is the
+// number of nodes before the cap, Truncated is set when nodes were left out.
+type ComponentGraph struct {
+	Nodes     []GraphNode `json:"nodes"`
+	Edges     []GraphEdge `json:"edges"`
+	Count     int         `json:"count"`
+	Truncated bool        `json:"truncated,omitempty"`
+}
+
+// NewComponentGraph builds the graph of the application from its resolved components and child applications,
+// keeping the application and the first maxNodes-1 objects sorted by group, kind, namespace and name. The
+// edges of the nodes left out are dropped. A maxNodes of 0 or less uses DefaultComponentGraphMaxNodes.
+func NewComponentGraph(app *appv1beta1.Application, components, children []*unstructured.Unstructured,
+	maxNodes int) *ComponentGraph {
+	if maxNodes <= 0 {
+		maxNodes = DefaultComponentGraphMaxNodes
+	}
+
+	root := graphNode(app, apis.ApplicationGroupVersion.Group, "Application", app.Namespace, app.Name)
+
+	type member struct {
+		node     GraphNode
+		obj      *unstructured.Unstructured
+		relation string
+	}
+
+	members := make([]member, 0, len(components)+len(children))
+
+	for _, u := range components {
+		members = append(members, member{
+			node:     graphNode(app, u.GroupVersionKind().Group, u.GetKind(), u.GetNamespace(), u.GetName()),
+			obj:      u,
+			relation: GraphRelationComponent,
+		})
+	}
+
+	for _, u := range children {
+		members = append(members, member{
+			node:     graphNode(app, u.GroupVersionKind().Group, u.GetKind(), u.GetNamespace(), u.GetName()),
+			obj:      u,
+			relation: GraphRelationChild,
+		})
+	}
+
+	sort.Slice(members, func(i, j int) bool { return members[i].node.ID < members[j].node.ID })
+
+	graph := &ComponentGraph{Nodes: []GraphNode{root}, Edges: []GraphEdge{}, Count: 1}
+	byUID := map[types.UID]string{app.UID: root.ID}
+	kept := make([]member, 0, len(members))
+	seen := map[string]bool{root.ID: true}
+
+	for _, m := range members {
+		if seen[m.node.ID] {
+			continue
+		}
+
+		seen[m.node.ID] = true
+		graph.Count++
+
+		if len(graph.Nodes) >= maxNodes {
+			graph.Truncated = true
+			continue
+		}
+
+		graph.Nodes = append(graph.Nodes, m.node)
+		graph.Edges = append(graph.Edges, GraphEdge{From: root.ID, To: m.node.ID, Relation: m.relation})
+		kept = append(kept, m)
+
+		if m.obj.GetUID() != "" {
+			byUID[m.obj.GetUID()] = m.node.ID
+		}
+	}
+
+	for _, m := range kept {
+		for _, ref := range m.obj.GetOwnerReferences() {
+			// the application is already linked to its components
+			if owner, ok := byUID[ref.UID]; ok && owner != root.ID && owner != m.node.ID {
+				graph.Edges = append(graph.Edges, GraphEdge{From: owner, To: m.node.ID, Relation: GraphRelationOwner})
+			}
+		}
+	}
+
+	return graph
+}
+
+// graphNode identifies the object by its group, kind, namespace and name
+func graphNode(app *appv1beta1.Application, group, kind, namespace, name string) GraphNode {
+	node := GraphNode{
+		ID:    strings.Join([]string{group, kind, namespace, name}, "/"),
+		Group: group,
+		Kind:  kind,
+		Name:  name,
+	}
+
+	if namespace != app.Namespace {
+		node.Namespace = namespace
+	}
+
+	return node
+}
+
+// JSON encodes the graph
+func (g *ComponentGraph) JSON() ([]byte, error) {
+	return json.Marshal(g)
+}
+
+// DOT renders the graph in the Graphviz DOT language, the nodes are labeled with their kind and name
+func (g *ComponentGraph) DOT() string {
+	var b strings.Builder
+
+	b.WriteString("digraph {\n")
+
+	for _, n := range g.Nodes {
+		label := n.Kind + "\n" + n.Name
+		if n.Namespace != "" {
+			label = n.Kind + "\n" + n.Namespace + "/" + n.Name
+		}
+
+		b.WriteString("\t" + strconv.Quote(n.ID) + " [label=" + strconv.Quote(label) + "];\n")
+	}
+
+	for _, e := range g.Edges {
+		b.WriteString("\t" + strconv.Quote(e.From) + " -> " + strconv.Quote(e.To) +
+			" [label=" + strconv.Quote(e.Relation) + "];\n")
+	}
+
+	if g.Truncated {
+		b.WriteString("\t// truncated, " + strconv.Itoa(g.Count-len(g.Nodes)) + " nodes left out\n")
+	}
+
+	b.WriteString("}\n")
+
+	return b.String()
+}
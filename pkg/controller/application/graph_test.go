@@ -0,0 +1,79 @@
+// Copyright 2019 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package application
+
+import (
+	"testing"
+
+	"github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func TestComponentGraph(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	app := newTestApplication()
+	app.UID = types.UID("app-uid")
+
+	deploy := newTestDeployment(g, "web", 1, 1)
+	deploy.SetUID("deploy-uid")
+	deploy.SetOwnerReferences([]metav1.OwnerReference{applicationOwnerRef(app)})
+
+	rs := &unstructured.Unstructured{}
+	rs.SetGroupVersionKind(appsv1.SchemeGroupVersion.WithKind("ReplicaSet"))
+	rs.SetNamespace("default")
+	rs.SetName("web-1")
+	rs.SetOwnerReferences([]metav1.OwnerReference{{APIVersion: "apps/v1", Kind: "Deployment", Name: "web", UID: "deploy-uid"}})
+
+	child := &unstructured.Unstructured{}
+	child.SetAPIVersion("app.k8s.io/v1beta1")
+	child.SetKind("Application")
+	child.SetNamespace("ns-b")
+	child.SetName("child")
+
+	graph := NewComponentGraph(app, []*unstructured.Unstructured{rs, deploy}, []*unstructured.Unstructured{child}, 0)
+	g.Expect(graph.Truncated).To(gomega.BeFalse())
+	g.Expect(graph.Count).To(gomega.Equal(4))
+	g.Expect(graph.Nodes).To(gomega.Equal([]GraphNode{
+		{ID: "app.k8s.io/Application/default/test-app", Group: "app.k8s.io", Kind: "Application", Name: "test-app"},
+		{ID: "app.k8s.io/Application/ns-b/child", Group: "app.k8s.io", Kind: "Application", Namespace: "ns-b", Name: "child"},
+		{ID: "apps/Deployment/default/web", Group: "apps", Kind: "Deployment", Name: "web"},
+		{ID: "apps/ReplicaSet/default/web-1", Group: "apps", Kind: "ReplicaSet", Name: "web-1"},
+	}))
+	g.Expect(graph.Edges).To(gomega.Equal([]GraphEdge{
+		{From: "app.k8s.io/Application/default/test-app", To: "app.k8s.io/Application/ns-b/child", Relation: GraphRelationChild},
+		{From: "app.k8s.io/Application/default/test-app", To: "apps/Deployment/default/web", Relation: GraphRelationComponent},
+		{From: "app.k8s.io/Application/default/test-app", To: "apps/ReplicaSet/default/web-1", Relation: GraphRelationComponent},
+		{From: "apps/Deployment/default/web", To: "apps/ReplicaSet/default/web-1", Relation: GraphRelationOwner},
+	}))
+
+	g.Expect(graph.DOT()).To(gomega.ContainSubstring(
+		`"apps/Deployment/default/web" -> "apps/ReplicaSet/default/web-1" [label="owner"];`))
+	g.Expect(graph.DOT()).To(gomega.ContainSubstring(`"apps/Deployment/default/web" [label="Deployment\nweb"];`))
+
+	data, err := graph.JSON()
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(string(data)).To(gomega.ContainSubstring(`"relation":"owner"`))
+
+	graph = NewComponentGraph(app, []*unstructured.Unstructured{rs, deploy}, []*unstructured.Unstructured{child}, 3)
+	g.Expect(graph.Truncated).To(gomega.BeTrue())
+	g.Expect(graph.Count).To(gomega.Equal(4))
+	g.Expect(graph.Nodes).To(gomega.HaveLen(3))
+	g.Expect(graph.Edges).To(gomega.HaveLen(2))
+	g.Expect(graph.DOT()).To(gomega.ContainSubstring("// truncated, 1 nodes left out"))
+}
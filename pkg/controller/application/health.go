@@ -0,0 +1,341 @@
+// Copyright 2019 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package application
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/stolostron/multicloud-operators-application/utils"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	appv1beta1 "sigs.k8s.io/application/api/v1beta1"
+)
+
+// HealthState is the health of a component or the aggregated health of an application
+type HealthState string
+
+const (
+	HealthHealthy     HealthState = "Healthy"
+	HealthProgressing HealthState = "Progressing"
+	HealthDegraded    HealthState = "Degraded"
+	HealthUnknown     HealthState = "Unknown"
+	// HealthTerminating is a component being deleted within the terminating grace period, it is left out
+	// of the aggregated health
+	HealthTerminating HealthState = "Terminating"
+	// HealthNotApplicable is the health of the applications in the inventory mode, it is not evaluated. The child
+	// applications not applicable are left out of the health of their parent.
+	HealthNotApplicable HealthState = "NotApplicable"
+)
+
+// ReadinessEvaluator computes the health of a component, the reason explains a state other than healthy
+type ReadinessEvaluator func(u *unstructured.Unstructured) (HealthState, string)
+
+var (
+	readinessEvaluatorsLock sync.RWMutex
+	readinessEvaluators     = map[schema.GroupKind]ReadinessEvaluator{}
+)
+
+// RegisterReadinessEvaluator sets the evaluator of a kind, replacing the built-in one if any.
+// Builds extending the operator are expected to register their evaluators before the manager starts.
+func RegisterReadinessEvaluator(gk schema.GroupKind, fn ReadinessEvaluator) {
+	readinessEvaluatorsLock.Lock()
+	defer readinessEvaluatorsLock.Unlock()
+
+	readinessEvaluators[gk] = fn
+}
+
+// evaluateHealth runs the evaluator registered for the kind of the component, kinds without an
+// evaluator fall back to the standard Ready condition
+func evaluateHealth(u *unstructured.Unstructured) (HealthState, string) {
+	readinessEvaluatorsLock.RLock()
+	fn, ok := readinessEvaluators[u.GroupVersionKind().GroupKind()]
+	readinessEvaluatorsLock.RUnlock()
+
+	if !ok {
+		fn = standardConditionsHealth
+	}
+
+	return fn(u)
+}
+
+// healthRollup is the aggregated health of the components of an application
+type healthRollup struct {
+	state   HealthState
+	healthy int
+	total   int
+	// healthyWeight and totalWeight weigh the healthy and the aggregated components for the aggregation rule
+	healthyWeight int
+	totalWeight   int
+	// reasons of the first unhealthy components, bounded to keep the condition message short
+	reasons []string
+	// skippedAbove is the component count the health evaluation was skipped above, 0 when it was evaluated
+	skippedAbove int
+}
+
+// notApplicableMessage explains the health of the applications in the inventory mode
+const notApplicableMessage = "health is not evaluated for the applications in the Inventory mode"
+
+const maxHealthReasons = 3
+
+// terminatingHealth reports the components being deleted, such as the pods replaced by a rolling update, as
+// terminating until the grace period elapses past their deletion timestamp, and degraded afterwards
+func terminatingHealth(u *unstructured.Unstructured, grace time.Duration) (HealthState, string) {
+	deadline := u.GetDeletionTimestamp().Add(grace)
+
+	if time.Now().Before(deadline) {
+		return HealthTerminating, ""
+	}
+
+	return HealthDegraded, fmt.Sprintf("terminating since %s", u.GetDeletionTimestamp().UTC().Format(time.RFC3339))
+}
+
+// newComponentHealth reports the degraded components created within the grace period as progressing, the
+// workloads just created briefly fail to be ready while their first replicas are scheduled
+func newComponentHealth(u *unstructured.Unstructured, state HealthState, grace time.Duration) HealthState {
+	if state != HealthDegraded || grace <= 0 {
+		return state
+	}
+
+	if time.Since(u.GetCreationTimestamp().Time) < grace {
+		return HealthProgressing
+	}
+
+	return state
+}
+
+// healthPolicy tunes how the component health rolls up into the application health
+type healthPolicy struct {
+	terminatingGrace time.Duration
+	// newComponentGrace is how long after their creation the degraded components count as progressing
+	newComponentGrace time.Duration
+	// aggregation is the utils.HealthAggregation* rule
+	aggregation string
+	// maxComponents is the component count above which the health is not evaluated, 0 sets no limit
+	maxComponents int
+	// weights weigh the components in the aggregation, the components weighing 0 are left out of it
+	weights utils.HealthWeights
+	// inventory skips the health evaluation of the applications in the inventory mode
+	inventory bool
+}
+
+// healthyByAggregation returns true if enough components are healthy for the aggregation rule
+func (p healthPolicy) healthyByAggregation(healthy, total int) bool {
+	switch p.aggregation {
+	case utils.HealthAggregationAny:
+		return healthy > 0
+	case utils.HealthAggregationMajority:
+		return 2*healthy > total
+	default:
+		return healthy == total
+	}
+}
+
+// rollupHealth evaluates every component and updates their status, the application is healthy when enough of them
+// are for the aggregation rule, otherwise it takes the worst state of the components.
+// The components created within the grace period are progressing rather than degraded.
+// The components terminating within the grace period and those weighing 0 do not count, the others count for their
+// weight in the aggregation rule. Above the component count of the policy the health is not evaluated, it is Unknown.
+// The health of the applications in the inventory mode is not evaluated either, it is NotApplicable, and the child
+// applications in that mode are left out.
+func rollupHealth(components []*unstructured.Unstructured, objects []appv1beta1.ObjectStatus, policy healthPolicy) *healthRollup {
+	rollup := &healthRollup{}
+
+	if policy.inventory {
+		rollup.state = HealthNotApplicable
+		rollup.total = len(components)
+
+		return rollup
+	}
+
+	if policy.maxComponents > 0 && len(components) > policy.maxComponents {
+		rollup.state = HealthUnknown
+		rollup.total = len(components)
+		rollup.skippedAbove = policy.maxComponents
+
+		return rollup
+	}
+
+	counts := map[HealthState]int{}
+
+	for i, u := range components {
+		var (
+			state  HealthState
+			reason string
+		)
+
+		if u.GetDeletionTimestamp() != nil {
+			state, reason = terminatingHealth(u, policy.terminatingGrace)
+		} else {
+			state, reason = evaluateHealth(u)
+			state = newComponentHealth(u, state, policy.newComponentGrace)
+		}
+
+		objects[i].Status = string(state)
+
+		if state == HealthTerminating {
+			counts[state]++
+			continue
+		}
+
+		if state == HealthNotApplicable {
+			continue
+		}
+
+		weight := policy.weights.Weight(u.GroupVersionKind().Group, u.GetKind(), u.GetName())
+		if weight == 0 {
+			continue
+		}
+
+		counts[state]++
+		rollup.total++
+		rollup.totalWeight += weight
+
+		if state == HealthHealthy {
+			rollup.healthyWeight += weight
+			continue
+		}
+
+		if len(rollup.reasons) < maxHealthReasons && reason != "" {
+			rollup.reasons = append(rollup.reasons, fmt.Sprintf("%s %s: %s", u.GetKind(), u.GetName(), reason))
+		}
+	}
+
+	rollup.healthy = counts[HealthHealthy]
+
+	switch {
+	case rollup.total == 0 && counts[HealthTerminating] > 0:
+		rollup.state = HealthTerminating
+	case rollup.total == 0:
+		rollup.state = HealthUnknown
+	case policy.healthyByAggregation(rollup.healthyWeight, rollup.totalWeight):
+		rollup.state = HealthHealthy
+	case counts[HealthDegraded] > 0:
+		rollup.state = HealthDegraded
+	case counts[HealthProgressing] > 0:
+		rollup.state = HealthProgressing
+	case counts[HealthUnknown] > 0:
+		rollup.state = HealthUnknown
+	default:
+		rollup.state = HealthHealthy
+	}
+
+	return rollup
+}
+
+func (rollup *healthRollup) message() string {
+	if rollup.state == HealthNotApplicable {
+		return notApplicableMessage
+	}
+
+	if rollup.skippedAbove > 0 {
+		return fmt.Sprintf("health evaluation skipped for %d components, above the limit of %d", rollup.total, rollup.skippedAbove)
+	}
+
+	msg := fmt.Sprintf("%d/%d components healthy", rollup.healthy, rollup.total)
+
+	for _, reason := range rollup.reasons {
+		msg += "; " + reason
+	}
+
+	return msg
+}
+
+// updateHealthStatus publishes the aggregated health as componentsReady and the Ready condition
+func updateHealthStatus(status *appv1beta1.ApplicationStatus, rollup *healthRollup) {
+	status.ComponentsReady = fmt.Sprintf("%d/%d", rollup.healthy, rollup.total)
+
+	if rollup.skippedAbove > 0 {
+		status.ComponentsReady = fmt.Sprintf("?/%d", rollup.total)
+		setCondition(status, appv1beta1.Ready, corev1.ConditionUnknown, "HealthEvaluationSkipped", rollup.message())
+		updateHealthyCondition(status)
+
+		return
+	}
+
+	if rollup.state == HealthNotApplicable {
+		status.ComponentsReady = fmt.Sprintf("-/%d", rollup.total)
+		setCondition(status, appv1beta1.Ready, corev1.ConditionUnknown, string(rollup.state), rollup.message())
+		updateHealthyCondition(status)
+
+		return
+	}
+
+	switch rollup.state {
+	case HealthHealthy:
+		setCondition(status, appv1beta1.Ready, corev1.ConditionTrue, string(rollup.state), rollup.message())
+	case HealthUnknown:
+		setCondition(status, appv1beta1.Ready, corev1.ConditionUnknown, string(rollup.state), rollup.message())
+	default:
+		setCondition(status, appv1beta1.Ready, corev1.ConditionFalse, string(rollup.state), rollup.message())
+	}
+
+	updateHealthyCondition(status)
+}
+
+// updateHealthyCondition derives the Healthy condition from the Ready condition, it carries the transition time
+// of the Ready condition turning true and is removed as soon as the application is not ready anymore
+func updateHealthyCondition(status *appv1beta1.ApplicationStatus) {
+	ready := getCondition(status, appv1beta1.Ready)
+	if ready == nil || ready.Status != corev1.ConditionTrue {
+		removeCondition(status, Healthy)
+		return
+	}
+
+	healthy := getCondition(status, Healthy)
+	if healthy != nil && healthy.LastTransitionTime.Equal(&ready.LastTransitionTime) {
+		return
+	}
+
+	removeCondition(status, Healthy)
+
+	status.Conditions = append(status.Conditions, appv1beta1.Condition{
+		Type:               Healthy,
+		Status:             corev1.ConditionTrue,
+		Reason:             string(HealthHealthy),
+		Message:            "healthy since " + ready.LastTransitionTime.UTC().Format(time.RFC3339),
+		LastUpdateTime:     ready.LastTransitionTime,
+		LastTransitionTime: ready.LastTransitionTime,
+	})
+}
+
+// healthTransition returns the health the application just transitioned to, Healthy when its Ready condition turned
+// true and Degraded when it turned false for degraded components, empty otherwise. The transition is keyed on the
+// lastTransitionTime of the Ready condition, or its reason between the not ready states, so it is reported once.
+func healthTransition(oldStatus, newStatus *appv1beta1.ApplicationStatus) HealthState {
+	newReady := getCondition(newStatus, appv1beta1.Ready)
+	if newReady == nil {
+		return ""
+	}
+
+	oldReady := getCondition(oldStatus, appv1beta1.Ready)
+	if oldReady != nil && oldReady.Status == newReady.Status && oldReady.Reason == newReady.Reason {
+		return ""
+	}
+
+	switch {
+	case newReady.Status == corev1.ConditionTrue:
+		if oldReady == nil || !oldReady.LastTransitionTime.Equal(&newReady.LastTransitionTime) {
+			return HealthHealthy
+		}
+	case newReady.Status == corev1.ConditionFalse && newReady.Reason == string(HealthDegraded):
+		return HealthDegraded
+	}
+
+	return ""
+}
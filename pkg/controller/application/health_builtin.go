@@ -0,0 +1,234 @@
+// Copyright 2019 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package application
+
+import (
+	"fmt"
+
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func init() {
+	RegisterReadinessEvaluator(schema.GroupKind{Group: "apps", Kind: "Deployment"}, deploymentHealth)
+	RegisterReadinessEvaluator(schema.GroupKind{Group: "apps", Kind: "StatefulSet"}, statefulSetHealth)
+	RegisterReadinessEvaluator(schema.GroupKind{Group: "apps", Kind: "DaemonSet"}, daemonSetHealth)
+	RegisterReadinessEvaluator(schema.GroupKind{Group: "apps", Kind: "ReplicaSet"}, replicaSetHealth)
+	RegisterReadinessEvaluator(schema.GroupKind{Group: "batch", Kind: "Job"}, jobHealth)
+	RegisterReadinessEvaluator(schema.GroupKind{Kind: "Pod"}, podHealth)
+	RegisterReadinessEvaluator(schema.GroupKind{Kind: "PersistentVolumeClaim"}, pvcHealth)
+	RegisterReadinessEvaluator(schema.GroupKind{Kind: "Service"}, serviceHealth)
+}
+
+func replicasOrDefault(replicas *int32) int32 {
+	if replicas == nil {
+		return 1
+	}
+
+	return *replicas
+}
+
+func deploymentHealth(u *unstructured.Unstructured) (HealthState, string) {
+	deploy := &appsv1.Deployment{}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(u.Object, deploy); err != nil {
+		return HealthUnknown, err.Error()
+	}
+
+	for _, c := range deploy.Status.Conditions {
+		if c.Type == appsv1.DeploymentReplicaFailure && c.Status == corev1.ConditionTrue {
+			return HealthDegraded, c.Message
+		}
+
+		if c.Type == appsv1.DeploymentProgressing && c.Status == corev1.ConditionFalse {
+			return HealthDegraded, c.Message
+		}
+	}
+
+	replicas := replicasOrDefault(deploy.Spec.Replicas)
+
+	if deploy.Status.ObservedGeneration < deploy.Generation ||
+		deploy.Status.UpdatedReplicas < replicas ||
+		deploy.Status.AvailableReplicas < replicas {
+		return HealthProgressing, fmt.Sprintf("%d/%d replicas available", deploy.Status.AvailableReplicas, replicas)
+	}
+
+	return HealthHealthy, ""
+}
+
+func statefulSetHealth(u *unstructured.Unstructured) (HealthState, string) {
+	sts := &appsv1.StatefulSet{}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(u.Object, sts); err != nil {
+		return HealthUnknown, err.Error()
+	}
+
+	replicas := replicasOrDefault(sts.Spec.Replicas)
+
+	if sts.Status.ObservedGeneration < sts.Generation ||
+		sts.Status.ReadyReplicas < replicas ||
+		sts.Status.CurrentReplicas < replicas {
+		return HealthProgressing, fmt.Sprintf("%d/%d replicas ready", sts.Status.ReadyReplicas, replicas)
+	}
+
+	return HealthHealthy, ""
+}
+
+func daemonSetHealth(u *unstructured.Unstructured) (HealthState, string) {
+	ds := &appsv1.DaemonSet{}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(u.Object, ds); err != nil {
+		return HealthUnknown, err.Error()
+	}
+
+	if ds.Status.ObservedGeneration < ds.Generation ||
+		ds.Status.NumberReady < ds.Status.DesiredNumberScheduled ||
+		ds.Status.NumberAvailable < ds.Status.DesiredNumberScheduled {
+		return HealthProgressing, fmt.Sprintf("%d/%d pods ready", ds.Status.NumberReady, ds.Status.DesiredNumberScheduled)
+	}
+
+	return HealthHealthy, ""
+}
+
+func replicaSetHealth(u *unstructured.Unstructured) (HealthState, string) {
+	rs := &appsv1.ReplicaSet{}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(u.Object, rs); err != nil {
+		return HealthUnknown, err.Error()
+	}
+
+	for _, c := range rs.Status.Conditions {
+		if c.Type == appsv1.ReplicaSetReplicaFailure && c.Status == corev1.ConditionTrue {
+			return HealthDegraded, c.Message
+		}
+	}
+
+	replicas := replicasOrDefault(rs.Spec.Replicas)
+
+	if rs.Status.ObservedGeneration < rs.Generation || rs.Status.AvailableReplicas < replicas {
+		return HealthProgressing, fmt.Sprintf("%d/%d replicas available", rs.Status.AvailableReplicas, replicas)
+	}
+
+	return HealthHealthy, ""
+}
+
+func jobHealth(u *unstructured.Unstructured) (HealthState, string) {
+	job := &batchv1.Job{}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(u.Object, job); err != nil {
+		return HealthUnknown, err.Error()
+	}
+
+	for _, c := range job.Status.Conditions {
+		if c.Type == batchv1.JobFailed && c.Status == corev1.ConditionTrue {
+			return HealthDegraded, c.Message
+		}
+
+		if c.Type == batchv1.JobComplete && c.Status == corev1.ConditionTrue {
+			return HealthHealthy, ""
+		}
+	}
+
+	if job.Status.StartTime == nil {
+		return HealthProgressing, "job not started"
+	}
+
+	return HealthHealthy, ""
+}
+
+func podHealth(u *unstructured.Unstructured) (HealthState, string) {
+	pod := &corev1.Pod{}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(u.Object, pod); err != nil {
+		return HealthUnknown, err.Error()
+	}
+
+	switch pod.Status.Phase {
+	case corev1.PodSucceeded:
+		return HealthHealthy, ""
+	case corev1.PodFailed:
+		return HealthDegraded, pod.Status.Reason
+	case corev1.PodUnknown:
+		return HealthUnknown, pod.Status.Reason
+	}
+
+	for _, c := range pod.Status.Conditions {
+		if c.Type == corev1.PodReady && c.Status == corev1.ConditionTrue {
+			return HealthHealthy, ""
+		}
+	}
+
+	return HealthProgressing, "pod not ready"
+}
+
+func pvcHealth(u *unstructured.Unstructured) (HealthState, string) {
+	pvc := &corev1.PersistentVolumeClaim{}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(u.Object, pvc); err != nil {
+		return HealthUnknown, err.Error()
+	}
+
+	switch pvc.Status.Phase {
+	case corev1.ClaimBound:
+		return HealthHealthy, ""
+	case corev1.ClaimLost:
+		return HealthDegraded, "claim lost"
+	default:
+		return HealthProgressing, "claim pending"
+	}
+}
+
+func serviceHealth(u *unstructured.Unstructured) (HealthState, string) {
+	svc := &corev1.Service{}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(u.Object, svc); err != nil {
+		return HealthUnknown, err.Error()
+	}
+
+	if svc.Spec.Type == corev1.ServiceTypeLoadBalancer && len(svc.Status.LoadBalancer.Ingress) == 0 {
+		return HealthProgressing, "load balancer not provisioned"
+	}
+
+	return HealthHealthy, ""
+}
+
+// standardConditionsHealth evaluates kinds following the Ready condition convention, kinds without
+// conditions are considered healthy once they exist
+func standardConditionsHealth(u *unstructured.Unstructured) (HealthState, string) {
+	conditions, found, err := unstructured.NestedSlice(u.Object, "status", "conditions")
+	if err != nil {
+		return HealthUnknown, err.Error()
+	}
+
+	if !found {
+		return HealthHealthy, ""
+	}
+
+	for _, c := range conditions {
+		cond, ok := c.(map[string]interface{})
+		if !ok || cond["type"] != "Ready" {
+			continue
+		}
+
+		msg, _ := cond["message"].(string)
+
+		switch cond["status"] {
+		case string(corev1.ConditionTrue):
+			return HealthHealthy, ""
+		case string(corev1.ConditionFalse):
+			return HealthProgressing, msg
+		default:
+			return HealthUnknown, msg
+		}
+	}
+
+	return HealthHealthy, ""
+}
@@ -0,0 +1,373 @@
+// Copyright 2019 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package application
+
+import (
+	"testing"
+	"time"
+
+	"github.com/onsi/gomega"
+	"github.com/stolostron/multicloud-operators-application/utils"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	appv1beta1 "sigs.k8s.io/application/api/v1beta1"
+)
+
+var defaultTestHealthPolicy = healthPolicy{terminatingGrace: DefaultTerminatingGracePeriod}
+
+func toUnstructured(g *gomega.GomegaWithT, obj runtime.Object, gvk schema.GroupVersionKind) *unstructured.Unstructured {
+	content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	u := &unstructured.Unstructured{Object: content}
+	u.SetGroupVersionKind(gvk)
+
+	return u
+}
+
+func newTestDeployment(g *gomega.GomegaWithT, name string, replicas, available int32) *unstructured.Unstructured {
+	deploy := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+		Spec:       appsv1.DeploymentSpec{Replicas: &replicas},
+		Status: appsv1.DeploymentStatus{
+			UpdatedReplicas:   available,
+			AvailableReplicas: available,
+		},
+	}
+
+	return toUnstructured(g, deploy, appsv1.SchemeGroupVersion.WithKind("Deployment"))
+}
+
+func TestRollupHealth(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	components := []*unstructured.Unstructured{
+		newTestDeployment(g, "ready", 2, 2),
+		newTestDeployment(g, "rolling", 2, 1),
+	}
+	objects := make([]appv1beta1.ObjectStatus, len(components))
+
+	rollup := rollupHealth(components, objects, defaultTestHealthPolicy)
+	g.Expect(rollup.state).To(gomega.Equal(HealthProgressing))
+	g.Expect(rollup.healthy).To(gomega.Equal(1))
+	g.Expect(objects[0].Status).To(gomega.Equal(string(HealthHealthy)))
+	g.Expect(objects[1].Status).To(gomega.Equal(string(HealthProgressing)))
+
+	status := &appv1beta1.ApplicationStatus{}
+	updateHealthStatus(status, rollup)
+	g.Expect(status.ComponentsReady).To(gomega.Equal("1/2"))
+	g.Expect(getCondition(status, appv1beta1.Ready).Status).To(gomega.Equal(corev1.ConditionFalse))
+
+	rollup = rollupHealth(nil, nil, defaultTestHealthPolicy)
+	g.Expect(rollup.state).To(gomega.Equal(HealthUnknown))
+}
+
+func TestRegisterReadinessEvaluator(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	gk := schema.GroupKind{Group: "example.com", Kind: "Widget"}
+	u := &unstructured.Unstructured{}
+	u.SetGroupVersionKind(gk.WithVersion("v1"))
+
+	state, _ := evaluateHealth(u)
+	g.Expect(state).To(gomega.Equal(HealthHealthy))
+
+	RegisterReadinessEvaluator(gk, func(u *unstructured.Unstructured) (HealthState, string) {
+		return HealthDegraded, "widget is broken"
+	})
+
+	state, reason := evaluateHealth(u)
+	g.Expect(state).To(gomega.Equal(HealthDegraded))
+	g.Expect(reason).To(gomega.Equal("widget is broken"))
+}
+
+func TestRollupHealthRollingRestart(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	newTestPod := func(name string, ready corev1.ConditionStatus, deletedAgo time.Duration) *unstructured.Unstructured {
+		pod := &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+			Status: corev1.PodStatus{
+				Phase:      corev1.PodRunning,
+				Conditions: []corev1.PodCondition{{Type: corev1.PodReady, Status: ready}},
+			},
+		}
+
+		if deletedAgo > 0 {
+			deleted := metav1.NewTime(time.Now().Add(-deletedAgo))
+			pod.DeletionTimestamp = &deleted
+		}
+
+		return toUnstructured(g, pod, corev1.SchemeGroupVersion.WithKind("Pod"))
+	}
+
+	// the old pod is terminating while its replacement is ready
+	components := []*unstructured.Unstructured{
+		newTestPod("old", corev1.ConditionFalse, time.Second),
+		newTestPod("new", corev1.ConditionTrue, 0),
+	}
+	objects := make([]appv1beta1.ObjectStatus, len(components))
+
+	rollup := rollupHealth(components, objects, healthPolicy{terminatingGrace: time.Minute})
+	g.Expect(rollup.state).To(gomega.Equal(HealthHealthy))
+	g.Expect(rollup.total).To(gomega.Equal(1))
+	g.Expect(objects[0].Status).To(gomega.Equal(string(HealthTerminating)))
+
+	// the old pod is stuck terminating past the grace period
+	components[0] = newTestPod("old", corev1.ConditionFalse, 2*time.Minute)
+
+	rollup = rollupHealth(components, objects, healthPolicy{terminatingGrace: time.Minute})
+	g.Expect(rollup.state).To(gomega.Equal(HealthDegraded))
+	g.Expect(rollup.total).To(gomega.Equal(2))
+}
+
+func TestRollupHealthNewComponent(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	newTestFailingDeployment := func(createdAgo time.Duration) *unstructured.Unstructured {
+		deploy := &appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:              "web",
+				Namespace:         "default",
+				CreationTimestamp: metav1.NewTime(time.Now().Add(-createdAgo)),
+			},
+			Status: appsv1.DeploymentStatus{
+				Conditions: []appsv1.DeploymentCondition{{
+					Type:    appsv1.DeploymentReplicaFailure,
+					Status:  corev1.ConditionTrue,
+					Message: "pods \"web-1\" is forbidden: exceeded quota",
+				}},
+			},
+		}
+
+		return toUnstructured(g, deploy, appsv1.SchemeGroupVersion.WithKind("Deployment"))
+	}
+
+	// the deployment was just created, its first replica is not ready yet
+	components := []*unstructured.Unstructured{newTestFailingDeployment(time.Second)}
+	objects := make([]appv1beta1.ObjectStatus, len(components))
+
+	rollup := rollupHealth(components, objects, healthPolicy{newComponentGrace: time.Minute})
+	g.Expect(rollup.state).To(gomega.Equal(HealthProgressing))
+	g.Expect(objects[0].Status).To(gomega.Equal(string(HealthProgressing)))
+
+	rollup = rollupHealth(components, objects, healthPolicy{})
+	g.Expect(rollup.state).To(gomega.Equal(HealthDegraded))
+
+	// past the grace period the deployment is evaluated normally
+	components[0] = newTestFailingDeployment(2 * time.Minute)
+
+	rollup = rollupHealth(components, objects, healthPolicy{newComponentGrace: time.Minute})
+	g.Expect(rollup.state).To(gomega.Equal(HealthDegraded))
+	g.Expect(objects[0].Status).To(gomega.Equal(string(HealthDegraded)))
+}
+
+func TestRollupHealthAggregation(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	components := []*unstructured.Unstructured{
+		newTestDeployment(g, "active", 1, 1),
+		newTestDeployment(g, "standby-a", 1, 0),
+		newTestDeployment(g, "standby-b", 1, 0),
+	}
+	objects := make([]appv1beta1.ObjectStatus, len(components))
+
+	for aggregation, state := range map[string]HealthState{
+		utils.HealthAggregationAll:      HealthProgressing,
+		utils.HealthAggregationAny:      HealthHealthy,
+		utils.HealthAggregationMajority: HealthProgressing,
+	} {
+		rollup := rollupHealth(components, objects, healthPolicy{aggregation: aggregation})
+		g.Expect(rollup.state).To(gomega.Equal(state), aggregation)
+	}
+
+	components[1] = newTestDeployment(g, "standby-a", 1, 1)
+
+	rollup := rollupHealth(components, objects, healthPolicy{aggregation: utils.HealthAggregationMajority})
+	g.Expect(rollup.state).To(gomega.Equal(HealthHealthy))
+}
+
+func TestRollupHealthMaxComponents(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	components := []*unstructured.Unstructured{
+		newTestDeployment(g, "ready", 1, 1),
+		newTestDeployment(g, "rolling", 2, 1),
+		newTestDeployment(g, "standby", 1, 0),
+	}
+	objects := make([]appv1beta1.ObjectStatus, len(components))
+
+	rollup := rollupHealth(components, objects, healthPolicy{maxComponents: 2})
+	g.Expect(rollup.state).To(gomega.Equal(HealthUnknown))
+	g.Expect(objects[0].Status).To(gomega.BeEmpty())
+
+	status := &appv1beta1.ApplicationStatus{}
+	updateHealthStatus(status, rollup)
+	g.Expect(status.ComponentsReady).To(gomega.Equal("?/3"))
+	g.Expect(getCondition(status, appv1beta1.Ready).Status).To(gomega.Equal(corev1.ConditionUnknown))
+	g.Expect(getCondition(status, appv1beta1.Ready).Reason).To(gomega.Equal("HealthEvaluationSkipped"))
+	g.Expect(getCondition(status, appv1beta1.Ready).Message).To(gomega.Equal("health evaluation skipped for 3 components, above the limit of 2"))
+
+	rollup = rollupHealth(components, objects, healthPolicy{maxComponents: 3})
+	g.Expect(rollup.state).To(gomega.Equal(HealthProgressing))
+	g.Expect(objects[0].Status).To(gomega.Equal(string(HealthHealthy)))
+
+	r := &ReconcileApplication{options: ReconcileOptions{HealthMaxComponents: 2}}
+	app := &appv1beta1.Application{}
+	g.Expect(r.healthPolicy(app).maxComponents).To(gomega.Equal(2))
+
+	app.Annotations = map[string]string{utils.AnnotationHealthMaxComponents: "0"}
+	g.Expect(r.healthPolicy(app).maxComponents).To(gomega.Equal(0))
+}
+
+func TestHealthTransition(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	healthy := &healthRollup{state: HealthHealthy, healthy: 1, total: 1}
+	progressing := &healthRollup{state: HealthProgressing, total: 1}
+	degraded := &healthRollup{state: HealthDegraded, total: 1}
+
+	oldStatus := &appv1beta1.ApplicationStatus{}
+	newStatus := oldStatus.DeepCopy()
+	updateHealthStatus(newStatus, progressing)
+	g.Expect(healthTransition(oldStatus, newStatus)).To(gomega.BeEmpty())
+
+	oldStatus = newStatus.DeepCopy()
+	updateHealthStatus(newStatus, healthy)
+	g.Expect(healthTransition(oldStatus, newStatus)).To(gomega.Equal(HealthHealthy))
+
+	// staying healthy is no transition, even with another message
+	oldStatus = newStatus.DeepCopy()
+	updateHealthStatus(newStatus, &healthRollup{state: HealthHealthy, healthy: 2, total: 2})
+	g.Expect(healthTransition(oldStatus, newStatus)).To(gomega.BeEmpty())
+
+	oldStatus = newStatus.DeepCopy()
+	updateHealthStatus(newStatus, degraded)
+	g.Expect(healthTransition(oldStatus, newStatus)).To(gomega.Equal(HealthDegraded))
+
+	oldStatus = newStatus.DeepCopy()
+	updateHealthStatus(newStatus, degraded)
+	g.Expect(healthTransition(oldStatus, newStatus)).To(gomega.BeEmpty())
+
+	oldStatus = newStatus.DeepCopy()
+	updateHealthStatus(newStatus, progressing)
+	g.Expect(healthTransition(oldStatus, newStatus)).To(gomega.BeEmpty())
+
+	g.Expect(healthTransition(&appv1beta1.ApplicationStatus{}, newStatus)).To(gomega.BeEmpty())
+}
+
+func TestRollupHealthInventory(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	components := []*unstructured.Unstructured{
+		newTestDeployment(g, "web", 1, 1),
+		newTestDeployment(g, "db", 1, 0),
+	}
+	objects := make([]appv1beta1.ObjectStatus, len(components))
+
+	rollup := rollupHealth(components, objects, healthPolicy{inventory: true})
+	g.Expect(rollup.state).To(gomega.Equal(HealthNotApplicable))
+	g.Expect(objects[0].Status).To(gomega.BeEmpty())
+
+	status := &appv1beta1.ApplicationStatus{}
+	updateHealthStatus(status, rollup)
+	g.Expect(status.ComponentsReady).To(gomega.Equal("-/2"))
+
+	ready := getCondition(status, appv1beta1.Ready)
+	g.Expect(ready.Status).To(gomega.Equal(corev1.ConditionUnknown))
+	g.Expect(ready.Reason).To(gomega.Equal(string(HealthNotApplicable)))
+
+	// an inventory child is left out of the health of its parent
+	child := &appv1beta1.Application{Status: *status}
+	components = []*unstructured.Unstructured{
+		newTestDeployment(g, "web", 1, 1),
+		toUnstructured(g, child, appv1beta1.GroupVersion.WithKind("Application")),
+	}
+	objects = make([]appv1beta1.ObjectStatus, len(components))
+
+	rollup = rollupHealth(components, objects, defaultTestHealthPolicy)
+	g.Expect(rollup.state).To(gomega.Equal(HealthHealthy))
+	g.Expect(rollup.total).To(gomega.Equal(1))
+	g.Expect(objects[1].Status).To(gomega.Equal(string(HealthNotApplicable)))
+}
+
+func TestHealthyCondition(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	status := &appv1beta1.ApplicationStatus{}
+	updateHealthStatus(status, &healthRollup{state: HealthProgressing, total: 1})
+	g.Expect(getCondition(status, Healthy)).To(gomega.BeNil())
+
+	updateHealthStatus(status, &healthRollup{state: HealthHealthy, healthy: 1, total: 1})
+	ready := getCondition(status, appv1beta1.Ready)
+	healthy := getCondition(status, Healthy)
+	g.Expect(healthy).NotTo(gomega.BeNil())
+	g.Expect(healthy.Status).To(gomega.Equal(corev1.ConditionTrue))
+	g.Expect(healthy.LastTransitionTime).To(gomega.Equal(ready.LastTransitionTime))
+
+	// staying healthy keeps the time the application turned healthy
+	since := healthy.LastTransitionTime
+	updateHealthStatus(status, &healthRollup{state: HealthHealthy, healthy: 2, total: 2})
+	g.Expect(getCondition(status, Healthy).LastTransitionTime).To(gomega.Equal(since))
+
+	updateHealthStatus(status, &healthRollup{state: HealthDegraded, total: 1})
+	g.Expect(getCondition(status, Healthy)).To(gomega.BeNil())
+
+	updateHealthStatus(status, &healthRollup{state: HealthUnknown})
+	g.Expect(getCondition(status, Healthy)).To(gomega.BeNil())
+}
+
+func TestRollupHealthWeights(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	components := []*unstructured.Unstructured{
+		newTestDeployment(g, "api", 1, 1),
+		newTestDeployment(g, "metrics-exporter", 1, 0),
+		newTestDeployment(g, "worker", 1, 1),
+	}
+	objects := make([]appv1beta1.ObjectStatus, len(components))
+
+	app := &appv1beta1.Application{}
+	app.Annotations = map[string]string{utils.AnnotationHealthWeights: `[
+		{"group":"apps","kind":"Deployment","weight":2},
+		{"group":"apps/v1","kind":"Deployment","name":"metrics-exporter","weight":0}]`}
+
+	policy := (&ReconcileApplication{}).healthPolicy(app)
+	g.Expect(policy.weights).To(gomega.HaveLen(2))
+
+	// the exporter is evaluated and listed, but left out of the application health
+	rollup := rollupHealth(components, objects, policy)
+	g.Expect(rollup.state).To(gomega.Equal(HealthHealthy))
+	g.Expect(rollup.healthy).To(gomega.Equal(2))
+	g.Expect(rollup.total).To(gomega.Equal(2))
+	g.Expect(objects[1].Status).To(gomega.Equal(string(HealthProgressing)))
+
+	// a down api outweighs the two others with the majority rule once it weighs more than them
+	components[0] = newTestDeployment(g, "api", 1, 0)
+	components[1] = newTestDeployment(g, "metrics-exporter", 1, 1)
+	weight := 1
+	policy = healthPolicy{
+		aggregation: utils.HealthAggregationMajority,
+		weights:     utils.HealthWeights{{Group: "apps", Kind: "Deployment", Name: "api", Weight: &weight}},
+	}
+	g.Expect(rollupHealth(components, objects, policy).state).To(gomega.Equal(HealthHealthy))
+
+	weight = 3
+	g.Expect(rollupHealth(components, objects, policy).state).To(gomega.Equal(HealthProgressing))
+}
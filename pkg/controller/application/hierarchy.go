@@ -0,0 +1,111 @@
+// Copyright 2019 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package application
+
+import (
+	"context"
+	"sort"
+
+	"github.com/stolostron/multicloud-operators-application/pkg/apis"
+	"github.com/stolostron/multicloud-operators-application/utils"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	appv1beta1 "sigs.k8s.io/application/api/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// childrenSource is the failure key of the child applications that could not be listed
+const childrenSource = "child applications"
+
+func init() {
+	RegisterReadinessEvaluator(schema.GroupKind{Group: appv1beta1.GroupVersion.Group, Kind: "Application"}, applicationHealth)
+}
+
+// registerApplicationReadiness rolls up the child applications of the configured group version the same way
+func registerApplicationReadiness() {
+	if apis.ApplicationGroupVersion.Group != appv1beta1.GroupVersion.Group {
+		RegisterReadinessEvaluator(schema.GroupKind{Group: apis.ApplicationGroupVersion.Group, Kind: "Application"}, applicationHealth)
+	}
+}
+
+// resolveChildren adds the applications naming the application as their parent to the resolution, their
+// health is rolled up with the health of the components
+func (r *ReconcileApplication) resolveChildren(ctx context.Context, app *appv1beta1.Application, res *componentResolution) {
+	list := &appv1beta1.ApplicationList{}
+	if err := r.List(ctx, list, client.InNamespace(app.Namespace)); err != nil {
+		res.failures[childrenSource] = err
+		return
+	}
+
+	sort.Slice(list.Items, func(i, j int) bool { return list.Items[i].Name < list.Items[j].Name })
+
+	for i := range list.Items {
+		child := &list.Items[i]
+
+		// an invalid parent, such as the application itself, is denied by the webhook and never rolled up
+		if parent, err := utils.GetParent(child); err != nil || parent != app.Name {
+			continue
+		}
+
+		obj, err := runtime.DefaultUnstructuredConverter.ToUnstructured(child)
+		if err != nil {
+			res.failures[childrenSource] = err
+			continue
+		}
+
+		u := &unstructured.Unstructured{Object: obj}
+		u.SetGroupVersionKind(apis.ApplicationGroupVersion.WithKind("Application"))
+
+		res.children = append(res.children, u)
+	}
+}
+
+// mapParent enqueues the parent of a child application, so the parent health follows the health of its children
+func mapParent(obj client.Object) []reconcile.Request {
+	parent := obj.GetAnnotations()[utils.AnnotationParent]
+	if parent == "" || parent == obj.GetName() {
+		return nil
+	}
+
+	return []reconcile.Request{{NamespacedName: types.NamespacedName{Namespace: obj.GetNamespace(), Name: parent}}}
+}
+
+// applicationHealth is the health of a child application, its Ready condition carries its health as the reason
+func applicationHealth(u *unstructured.Unstructured) (HealthState, string) {
+	app := &appv1beta1.Application{}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(u.Object, app); err != nil {
+		return HealthUnknown, err.Error()
+	}
+
+	c := getCondition(&app.Status, appv1beta1.Ready)
+	if c == nil {
+		return HealthUnknown, "not reconciled yet"
+	}
+
+	switch state := HealthState(c.Reason); state {
+	case HealthHealthy, HealthProgressing, HealthDegraded, HealthUnknown, HealthTerminating, HealthNotApplicable:
+		return state, c.Message
+	}
+
+	if c.Status == corev1.ConditionTrue {
+		return HealthHealthy, ""
+	}
+
+	return HealthUnknown, c.Message
+}
@@ -0,0 +1,82 @@
+// Copyright 2019 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package application
+
+import (
+	"context"
+	"testing"
+
+	"github.com/onsi/gomega"
+	"github.com/stolostron/multicloud-operators-application/utils"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	appv1beta1 "sigs.k8s.io/application/api/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func newTestChild(name, parent string, health HealthState) *appv1beta1.Application {
+	app := &appv1beta1.Application{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        name,
+			Namespace:   "default",
+			Annotations: map[string]string{utils.AnnotationParent: parent},
+		},
+	}
+
+	status := corev1.ConditionFalse
+	if health == HealthHealthy {
+		status = corev1.ConditionTrue
+	}
+
+	setCondition(&app.Status, appv1beta1.Ready, status, string(health), "")
+
+	return app
+}
+
+func TestResolveChildren(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	s := runtime.NewScheme()
+	g.Expect(clientgoscheme.AddToScheme(s)).To(gomega.Succeed())
+	g.Expect(appv1beta1.AddToScheme(s)).To(gomega.Succeed())
+
+	app := newTestApplication()
+
+	r := newTestReconciler()
+	r.Client = fake.NewClientBuilder().WithScheme(s).WithObjects(
+		app,
+		newTestChild("frontend", app.Name, HealthHealthy),
+		newTestChild("backend", app.Name, HealthDegraded),
+		newTestChild("unrelated", "other", HealthDegraded),
+	).Build()
+
+	res := &componentResolution{failures: map[string]error{}}
+	r.resolveChildren(context.TODO(), app, res)
+	g.Expect(res.failures).To(gomega.BeEmpty())
+	g.Expect(res.children).To(gomega.HaveLen(2))
+	g.Expect(res.children[0].GetName()).To(gomega.Equal("backend"))
+	g.Expect(res.children[0].GetKind()).To(gomega.Equal("Application"))
+
+	rollup := updateComponentStatus(&app.Status, res, defaultTestHealthPolicy)
+	g.Expect(rollup.state).To(gomega.Equal(HealthDegraded))
+	g.Expect(rollup.total).To(gomega.Equal(2))
+	g.Expect(app.Status.ComponentList.Objects).To(gomega.HaveLen(2))
+	g.Expect(app.Status.ComponentList.Objects[1].Status).To(gomega.Equal(string(HealthHealthy)))
+
+	g.Expect(mapParent(newTestChild("frontend", app.Name, HealthHealthy))).To(gomega.HaveLen(1))
+	g.Expect(mapParent(app)).To(gomega.BeEmpty())
+}
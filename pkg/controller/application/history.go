@@ -0,0 +1,128 @@
+// Copyright 2019 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package application
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/stolostron/multicloud-operators-application/utils"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog"
+	appv1beta1 "sigs.k8s.io/application/api/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// DefaultReconcileHistorySize is the number of reconcile outcomes kept on the applications
+const DefaultReconcileHistorySize = 5
+
+// reconcileHistoryMaxErrorLength bounds the error messages of the history, the annotation stays small
+const reconcileHistoryMaxErrorLength = 256
+
+// reconcileOutcome is an entry of the reconcile history, an error or the success marker of a reconcile
+type reconcileOutcome struct {
+	Time    metav1.Time `json:"time"`
+	Error   string      `json:"error,omitempty"`
+	Success bool        `json:"success,omitempty"`
+}
+
+// newReconcileOutcome returns the outcome of the reconcile, the error it returned or else the error of the
+// components that failed to resolve
+func newReconcileOutcome(err error, partial error) reconcileOutcome {
+	if err == nil {
+		err = partial
+	}
+
+	if err == nil {
+		return reconcileOutcome{Time: metav1.Now(), Success: true}
+	}
+
+	msg := err.Error()
+	if len(msg) > reconcileHistoryMaxErrorLength {
+		msg = msg[:reconcileHistoryMaxErrorLength] + "..."
+	}
+
+	return reconcileOutcome{Time: metav1.Now(), Error: msg}
+}
+
+// appendReconcileOutcome adds the outcome to the history, keeping the last size entries. An outcome repeating the
+// last one, the same error or another success, is not added so the history only changes along the transitions,
+// and the time of an entry is when it was first seen. It returns the history and whether it changed.
+func appendReconcileOutcome(history []reconcileOutcome, outcome reconcileOutcome, size int) ([]reconcileOutcome, bool) {
+	if n := len(history); n > 0 && history[n-1].Success == outcome.Success && history[n-1].Error == outcome.Error {
+		return history, false
+	}
+
+	history = append(history, outcome)
+	if len(history) > size {
+		history = history[len(history)-size:]
+	}
+
+	return history, true
+}
+
+// recordReconcileOutcome writes the outcome of the reconcile into the reconcile history annotation of the
+// application. It is patched on its own after the reconcile, the reconcile writes may be what failed.
+func (r *ReconcileApplication) recordReconcileOutcome(key types.NamespacedName, outcome reconcileOutcome) {
+	if r.options.ReconcileHistorySize <= 0 {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), interruptedStatusTimeout)
+	defer cancel()
+
+	app := &appv1beta1.Application{}
+	if err := r.Get(ctx, key, app); err != nil {
+		if !errors.IsNotFound(err) {
+			klog.V(1).Info("Skipping the reconcile history of application ", key, " error: ", err)
+		}
+
+		return
+	}
+
+	var history []reconcileOutcome
+
+	if data := app.GetAnnotations()[utils.AnnotationReconcileHistory]; data != "" {
+		// a history that cannot be read is started over
+		if err := json.Unmarshal([]byte(data), &history); err != nil {
+			history = nil
+		}
+	}
+
+	history, changed := appendReconcileOutcome(history, outcome, r.options.ReconcileHistorySize)
+	if !changed {
+		return
+	}
+
+	data, err := json.Marshal(history)
+	if err != nil {
+		klog.Error("Failed to encode the reconcile history of application ", key, " error: ", err)
+		return
+	}
+
+	orig := app.DeepCopy()
+
+	if app.Annotations == nil {
+		app.Annotations = map[string]string{}
+	}
+
+	app.Annotations[utils.AnnotationReconcileHistory] = string(data)
+
+	if err := r.Patch(ctx, app, client.MergeFrom(orig)); err != nil {
+		klog.Error("Failed to record the reconcile history of application ", key, " error: ", err)
+	}
+}
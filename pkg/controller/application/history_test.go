@@ -0,0 +1,97 @@
+// Copyright 2019 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package application
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/onsi/gomega"
+	"github.com/stolostron/multicloud-operators-application/utils"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	appv1beta1 "sigs.k8s.io/application/api/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestAppendReconcileOutcome(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	var (
+		history []reconcileOutcome
+		changed bool
+	)
+
+	history, changed = appendReconcileOutcome(history, newReconcileOutcome(errors.New("timeout"), nil), 3)
+	g.Expect(changed).To(gomega.BeTrue())
+
+	// the repeated error keeps the time it was first seen
+	_, changed = appendReconcileOutcome(history, newReconcileOutcome(errors.New("timeout"), nil), 3)
+	g.Expect(changed).To(gomega.BeFalse())
+
+	history, _ = appendReconcileOutcome(history, newReconcileOutcome(nil, nil), 3)
+	history, _ = appendReconcileOutcome(history, newReconcileOutcome(nil, errors.New("kind Foo failed")), 3)
+	history, changed = appendReconcileOutcome(history, newReconcileOutcome(nil, nil), 3)
+	g.Expect(changed).To(gomega.BeTrue())
+
+	// the oldest entry is dropped past the size, the successes do not clear the errors
+	g.Expect(history).To(gomega.HaveLen(3))
+	g.Expect(history[0].Success).To(gomega.BeTrue())
+	g.Expect(history[1].Error).To(gomega.Equal("kind Foo failed"))
+	g.Expect(history[2].Success).To(gomega.BeTrue())
+
+	long := newReconcileOutcome(errors.New(strings.Repeat("x", 1000)), nil)
+	g.Expect(long.Error).To(gomega.HaveLen(reconcileHistoryMaxErrorLength + 3))
+}
+
+func TestRecordReconcileOutcome(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	s := runtime.NewScheme()
+	g.Expect(clientgoscheme.AddToScheme(s)).To(gomega.Succeed())
+	g.Expect(appv1beta1.AddToScheme(s)).To(gomega.Succeed())
+
+	app := newTestApplication(configMapGK)
+	key := types.NamespacedName{Namespace: app.Namespace, Name: app.Name}
+
+	r := newTestReconciler()
+	r.Client = fake.NewClientBuilder().WithScheme(s).WithObjects(app).Build()
+
+	// disabled by default
+	r.recordReconcileOutcome(key, newReconcileOutcome(errors.New("timeout"), nil))
+	g.Expect(r.Get(context.TODO(), key, app)).To(gomega.Succeed())
+	g.Expect(app.Annotations).NotTo(gomega.HaveKey(utils.AnnotationReconcileHistory))
+
+	r.options.ReconcileHistorySize = DefaultReconcileHistorySize
+
+	r.recordReconcileOutcome(key, newReconcileOutcome(errors.New("timeout"), nil))
+	r.recordReconcileOutcome(key, newReconcileOutcome(nil, nil))
+	r.recordReconcileOutcome(key, newReconcileOutcome(nil, nil))
+
+	g.Expect(r.Get(context.TODO(), key, app)).To(gomega.Succeed())
+
+	var history []reconcileOutcome
+	g.Expect(json.Unmarshal([]byte(app.Annotations[utils.AnnotationReconcileHistory]), &history)).To(gomega.Succeed())
+	g.Expect(history).To(gomega.HaveLen(2))
+	g.Expect(history[0].Error).To(gomega.Equal("timeout"))
+	g.Expect(history[1].Success).To(gomega.BeTrue())
+
+	// a deleted application is skipped
+	r.recordReconcileOutcome(types.NamespacedName{Namespace: "default", Name: "gone"}, newReconcileOutcome(nil, nil))
+}
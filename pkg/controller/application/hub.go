@@ -28,7 +28,9 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
-func (r *ReconcileApplication) doAppHubReconcile(app *appv1beta1.Application) {
+// doAppHubReconcile records the subscriptions and deployables of the application, it returns the deployables
+// grouped by the managed cluster they target
+func (r *ReconcileApplication) doAppHubReconcile(app *appv1beta1.Application) clusterView {
 	// allSubs: all subscriptions
 	// allDpls: all deployables. The deployables subscribed in the subscriptions are not counted
 	// allClusterDplMap: all deployables for each cluster. The deployables subscribed in the subscriptions are counted.
@@ -62,6 +64,8 @@ func (r *ReconcileApplication) doAppHubReconcile(app *appv1beta1.Application) {
 
 	app.Annotations["apps.open-cluster-management.io/subscriptions"] = substr
 	app.Annotations["apps.open-cluster-management.io/deployables"] = dplstr
+
+	return r.clusterViewOf(allSubs, allDpls)
 }
 
 // In 2.5, disable setting the part-of label on all subscriptions of the application.
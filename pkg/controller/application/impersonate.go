@@ -0,0 +1,83 @@
+// Copyright 2019 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package application
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/stolostron/multicloud-operators-application/utils"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/rest"
+	appv1beta1 "sigs.k8s.io/application/api/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// impersonatingReaders builds the readers impersonating the users the components are resolved as, one per user
+// kept for the life of the operator
+type impersonatingReaders struct {
+	config  *rest.Config
+	scheme  *runtime.Scheme
+	mapper  meta.RESTMapper
+	readers sync.Map
+}
+
+func newImpersonatingReaders(config *rest.Config, scheme *runtime.Scheme, mapper meta.RESTMapper) *impersonatingReaders {
+	return &impersonatingReaders{config: config, scheme: scheme, mapper: mapper}
+}
+
+// reader returns the reader impersonating the user, its reads go to the API server rather than to a cache
+func (i *impersonatingReaders) reader(user string) (client.Reader, error) {
+	if c, ok := i.readers.Load(user); ok {
+		return c.(client.Reader), nil
+	}
+
+	cfg := rest.CopyConfig(i.config)
+	cfg.Impersonate = rest.ImpersonationConfig{UserName: user}
+
+	c, err := client.New(cfg, client.Options{Scheme: i.scheme, Mapper: i.mapper})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the client impersonating %s: %w", user, err)
+	}
+
+	actual, _ := i.readers.LoadOrStore(user, c)
+
+	return actual.(client.Reader), nil
+}
+
+// resolutionReader returns the reader impersonating the ServiceAccount the application resolves its components
+// as, along the impersonated user. The reader is nil when the application is resolved as the operator.
+func (r *ReconcileApplication) resolutionReader(app *appv1beta1.Application) (client.Reader, string, error) {
+	sa, err := utils.GetResolveAs(app)
+	if err != nil || sa == "" {
+		return nil, "", err
+	}
+
+	// the components are never resolved as the operator in place of the ServiceAccount
+	if r.impersonate == nil {
+		return nil, "", fmt.Errorf("the application is resolved as ServiceAccount %s but the operator does not "+
+			"impersonate service accounts", sa)
+	}
+
+	user := utils.ServiceAccountUser(app.Namespace, sa)
+
+	reader, err := r.impersonate(user)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return reader, user, nil
+}
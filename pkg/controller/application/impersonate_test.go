@@ -0,0 +1,88 @@
+// Copyright 2019 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package application
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/onsi/gomega"
+	"github.com/stolostron/multicloud-operators-application/utils"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+// forbiddenReader denies every read to the impersonated user
+type forbiddenReader struct {
+	user string
+}
+
+func (f forbiddenReader) Get(ctx context.Context, key client.ObjectKey, obj client.Object) error {
+	return f.deny("get")
+}
+
+func (f forbiddenReader) List(ctx context.Context, list client.ObjectList, opts ...client.ListOption) error {
+	return f.deny("list")
+}
+
+func (f forbiddenReader) deny(verb string) error {
+	return errors.NewForbidden(schema.GroupResource{Resource: "configmaps"}, "", fmt.Errorf(
+		`User "%s" cannot %s resource "configmaps" in API group "" in the namespace "default"`, f.user, verb))
+}
+
+func TestResolveComponentsAsServiceAccount(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	visible := newTestConfigMap("visible", map[string]string{"app": "test-app"})
+
+	r := newTestReconciler(visible, newTestConfigMap("hidden", map[string]string{"app": "test-app"}))
+
+	app := newTestApplication(configMapGK)
+	app.Annotations = map[string]string{utils.AnnotationResolveAs: "tenant-reader"}
+
+	// the operator never resolves the application as itself in place of the ServiceAccount
+	res := r.resolveComponents(context.TODO(), app)
+	g.Expect(res.components).To(gomega.BeEmpty())
+	g.Expect(res.failures).To(gomega.HaveKeyWithValue(utils.AnnotationResolveAs,
+		gomega.MatchError(gomega.ContainSubstring("does not impersonate service accounts"))))
+
+	var impersonated []string
+
+	tenant := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(&visible).Build()
+	r.impersonate = func(user string) (client.Reader, error) {
+		impersonated = append(impersonated, user)
+		return tenant, nil
+	}
+
+	res = r.resolveComponents(context.TODO(), app)
+	g.Expect(res.failures).To(gomega.BeEmpty())
+	g.Expect(res.components).To(gomega.HaveLen(1))
+	g.Expect(res.components[0].GetName()).To(gomega.Equal("visible"))
+	g.Expect(impersonated).To(gomega.Equal([]string{"system:serviceaccount:default:tenant-reader"}))
+
+	r.impersonate = func(user string) (client.Reader, error) {
+		return forbiddenReader{user: user}, nil
+	}
+
+	res = r.resolveComponents(context.TODO(), app)
+	g.Expect(res.components).To(gomega.BeEmpty())
+	g.Expect(r.failsOnForbidden(app)).To(gomega.BeTrue())
+	g.Expect(r.forbiddenMessage(res, nil)).To(gomega.Equal(
+		"system:serviceaccount:default:tenant-reader cannot list ConfigMap"))
+}
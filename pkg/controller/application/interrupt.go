@@ -0,0 +1,57 @@
+// Copyright 2019 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package application
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/klog"
+	appv1beta1 "sigs.k8s.io/application/api/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// interruptedStatusTimeout bounds the status write of an interrupted reconcile, made past the reconcile context
+const interruptedStatusTimeout = 10 * time.Second
+
+// interruptReconcile marks the status of an application whose reconcile context is done before the status is
+// computed. Only the ReconcileIncomplete condition is written, the other fields keep the values of the last
+// complete reconcile rather than mixing in the partial results, and the application is requeued.
+func (r *ReconcileApplication) interruptReconcile(app *appv1beta1.Application, phase string, cause error) (reconcile.Result, error) {
+	klog.Info("Reconcile of application ", app.Namespace+"/"+app.Name, " interrupted during ", phase, ": ", cause)
+
+	reason := "Canceled"
+	if errors.Is(cause, context.DeadlineExceeded) {
+		reason = "DeadlineExceeded"
+	}
+
+	updated := app.DeepCopy()
+	setCondition(&updated.Status, ReconcileIncomplete, corev1.ConditionTrue, reason,
+		"interrupted during "+phase+": "+cause.Error())
+
+	if !equality.Semantic.DeepEqual(updated.Status, app.Status) {
+		ctx, cancel := context.WithTimeout(context.Background(), interruptedStatusTimeout)
+		defer cancel()
+
+		if err := r.Status().Update(ctx, updated); err != nil {
+			klog.Error("Failed to mark the status of application ", app.Namespace+"/"+app.Name, " incomplete, error: ", err)
+		}
+	}
+
+	return reconcile.Result{Requeue: true}, nil
+}
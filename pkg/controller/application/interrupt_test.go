@@ -0,0 +1,86 @@
+// Copyright 2019 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package application
+
+import (
+	"context"
+	"testing"
+
+	"github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	appv1beta1 "sigs.k8s.io/application/api/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+// cancelingListClient cancels the reconcile context once a list returns, as a deadline expiring mid-resolution
+type cancelingListClient struct {
+	client.Client
+	cancel context.CancelFunc
+	lists  int
+}
+
+func (c *cancelingListClient) List(ctx context.Context, list client.ObjectList, opts ...client.ListOption) error {
+	c.lists++
+	defer c.cancel()
+
+	return c.Client.List(ctx, list, opts...)
+}
+
+func TestReconcileInterruptedMidResolution(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	s := runtime.NewScheme()
+	g.Expect(clientgoscheme.AddToScheme(s)).To(gomega.Succeed())
+	g.Expect(appv1beta1.AddToScheme(s)).To(gomega.Succeed())
+
+	app := newTestApplication(configMapGK, metav1.GroupKind{Kind: "Secret"})
+	app.Status.ComponentsReady = "2/2"
+
+	cm := newTestConfigMap("matched", map[string]string{"app": "test-app"})
+
+	r := newTestReconciler()
+	r.Client = fake.NewClientBuilder().WithScheme(s).WithObjects(app, &cm).Build()
+	r.mapper.(*meta.DefaultRESTMapper).Add(corev1.SchemeGroupVersion.WithKind("Secret"), meta.RESTScopeNamespace)
+
+	ctx, cancel := context.WithCancel(context.TODO())
+	lister := &cancelingListClient{Client: r.Client, cancel: cancel}
+	r.Client = lister
+
+	res := r.resolveComponents(ctx, app)
+	g.Expect(lister.lists).To(gomega.Equal(1))
+	g.Expect(res.interrupted).To(gomega.MatchError(context.Canceled))
+	g.Expect(res.components).To(gomega.HaveLen(1))
+
+	result, err := r.interruptReconcile(app, "component resolution", res.interrupted)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(result.Requeue).To(gomega.BeTrue())
+
+	updated := &appv1beta1.Application{}
+	g.Expect(r.Get(context.TODO(), types.NamespacedName{Namespace: "default", Name: "test-app"}, updated)).To(gomega.Succeed())
+	g.Expect(updated.Status.ComponentsReady).To(gomega.Equal("2/2"))
+	g.Expect(updated.Status.ComponentList.Objects).To(gomega.BeEmpty())
+
+	c := getCondition(&updated.Status, ReconcileIncomplete)
+	g.Expect(c).NotTo(gomega.BeNil())
+	g.Expect(c.Status).To(gomega.Equal(corev1.ConditionTrue))
+	g.Expect(c.Reason).To(gomega.Equal("Canceled"))
+	g.Expect(c.Message).To(gomega.ContainSubstring("component resolution"))
+}
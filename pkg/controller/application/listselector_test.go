@@ -0,0 +1,68 @@
+// Copyright 2019 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package application
+
+import (
+	"context"
+	"testing"
+
+	"github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// selectorRecordingClient records the label selectors the lists are sent with
+type selectorRecordingClient struct {
+	client.Client
+	selectors []string
+}
+
+func (c *selectorRecordingClient) List(ctx context.Context, list client.ObjectList, opts ...client.ListOption) error {
+	listOpts := &client.ListOptions{}
+	listOpts.ApplyOptions(opts)
+
+	if listOpts.LabelSelector != nil {
+		c.selectors = append(c.selectors, listOpts.LabelSelector.String())
+	}
+
+	return c.Client.List(ctx, list, opts...)
+}
+
+func TestListComponentsSetBasedSelector(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	r := newTestReconciler(
+		newTestConfigMap("matched", map[string]string{"app": "test-app", "tier": "web"}),
+		newTestConfigMap("canary", map[string]string{"app": "test-app", "tier": "canary"}),
+		newTestConfigMap("legacy", map[string]string{"app": "test-app", "legacy": "true"}),
+	)
+
+	c := &selectorRecordingClient{Client: r.Client}
+	r.Client = c
+
+	app := newTestApplication(configMapGK)
+	app.Spec.Selector.MatchExpressions = []metav1.LabelSelectorRequirement{
+		{Key: "tier", Operator: metav1.LabelSelectorOpNotIn, Values: []string{"canary"}},
+		{Key: "legacy", Operator: metav1.LabelSelectorOpDoesNotExist},
+	}
+
+	res := r.resolveComponents(context.TODO(), app)
+	g.Expect(res.failures).To(gomega.BeEmpty())
+	g.Expect(res.components).To(gomega.HaveLen(1))
+	g.Expect(res.components[0].GetName()).To(gomega.Equal("matched"))
+
+	// the negated requirements are part of the listed selector rather than filtered after the list
+	g.Expect(c.selectors).To(gomega.Equal([]string{"app=test-app,!legacy,tier notin (canary)"}))
+}
@@ -0,0 +1,111 @@
+// Copyright 2019 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package application
+
+import (
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+// controllerName names the application controller, its work queue metrics registered by controller-runtime
+// in metrics.Registry are labeled name="application-controller": workqueue_depth, workqueue_adds_total,
+// workqueue_queue_duration_seconds, workqueue_work_duration_seconds, workqueue_unfinished_work_seconds,
+// workqueue_longest_running_processor_seconds and workqueue_retries_total. They are served with the other
+// metrics of the manager.
+//
+// An alert on the controller falling behind, the queue growing or items waiting over a minute at the 99th
+// percentile for ten minutes:
+//
+//	workqueue_depth{name="application-controller"} > 100
+//	or histogram_quantile(0.99, sum by (le) (rate(workqueue_queue_duration_seconds_bucket{name="application-controller"}[5m]))) > 60
+const controllerName = "application-controller"
+
+var (
+	shortCircuitedReconciles = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "application_reconcile_short_circuited_total",
+		Help: "Number of application updates skipped because the spec generation did not change.",
+	})
+
+	ownerRefPatchFailures = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "application_owner_reference_patch_failures_total",
+		Help: "Number of failed patches setting the application owner reference on a component, by component kind.",
+	}, []string{"kind"})
+
+	applicationsByHealth = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "application_health_count",
+		Help: "Number of applications by namespace and health, the namespace is empty when not labeled.",
+	}, []string{"namespace", "health"})
+
+	appHealth = newHealthCounter(applicationsByHealth)
+
+	componentKindBreakerOpen = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "application_component_kind_breaker_open",
+		Help: "Whether listing the components of a kind is suspended after consecutive failures, 1 when suspended.",
+	}, []string{"kind"})
+)
+
+func init() {
+	metrics.Registry.MustRegister(shortCircuitedReconciles, ownerRefPatchFailures, applicationsByHealth,
+		componentKindBreakerOpen)
+}
+
+// healthCounter counts the applications by namespace and health as last reconciled, the gauge is moved along
+// the changes of each application
+type healthCounter struct {
+	lock   sync.Mutex
+	gauge  *prometheus.GaugeVec
+	counts map[types.NamespacedName]prometheus.Labels
+}
+
+func newHealthCounter(gauge *prometheus.GaugeVec) *healthCounter {
+	return &healthCounter{gauge: gauge, counts: map[types.NamespacedName]prometheus.Labels{}}
+}
+
+// record counts the application in its health, under its namespace when byNamespace is set to bound the
+// cardinality otherwise
+func (c *healthCounter) record(key types.NamespacedName, health HealthState, byNamespace bool) {
+	labels := prometheus.Labels{"namespace": "", "health": string(health)}
+	if byNamespace {
+		labels["namespace"] = key.Namespace
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if prev, ok := c.counts[key]; ok {
+		if prev["namespace"] == labels["namespace"] && prev["health"] == labels["health"] {
+			return
+		}
+
+		c.gauge.With(prev).Dec()
+	}
+
+	c.gauge.With(labels).Inc()
+	c.counts[key] = labels
+}
+
+// forget stops counting a deleted application
+func (c *healthCounter) forget(key types.NamespacedName) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if prev, ok := c.counts[key]; ok {
+		c.gauge.With(prev).Dec()
+		delete(c.counts, key)
+	}
+}
@@ -0,0 +1,117 @@
+// Copyright 2019 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package application
+
+import (
+	"testing"
+
+	"github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/workqueue"
+	appv1beta1 "sigs.k8s.io/application/api/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+func TestWorkQueueMetrics(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	// the controller builds its rate limited queue under its name, the same provider backs this one
+	queue := workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), controllerName)
+	defer queue.ShutDown()
+
+	queue.Add("default/test-app")
+
+	families, err := metrics.Registry.Gather()
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	depth := -1.0
+
+	for _, mf := range families {
+		if mf.GetName() != "workqueue_depth" {
+			continue
+		}
+
+		for _, m := range mf.GetMetric() {
+			for _, l := range m.GetLabel() {
+				if l.GetName() == "name" && l.GetValue() == controllerName {
+					depth = m.GetGauge().GetValue()
+				}
+			}
+		}
+	}
+
+	g.Expect(depth).To(gomega.Equal(1.0))
+}
+
+func TestHealthCounter(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	gauge := prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: "test_application_health_count"}, []string{"namespace", "health"})
+	counter := newHealthCounter(gauge)
+
+	count := func(namespace string, health HealthState) float64 {
+		return testutil.ToFloat64(gauge.WithLabelValues(namespace, string(health)))
+	}
+
+	web := types.NamespacedName{Namespace: "team-a", Name: "web"}
+	db := types.NamespacedName{Namespace: "team-a", Name: "db"}
+
+	counter.record(web, HealthHealthy, true)
+	counter.record(db, HealthHealthy, true)
+	counter.record(db, HealthHealthy, true)
+	g.Expect(count("team-a", HealthHealthy)).To(gomega.Equal(2.0))
+
+	counter.record(db, HealthDegraded, true)
+	g.Expect(count("team-a", HealthHealthy)).To(gomega.Equal(1.0))
+	g.Expect(count("team-a", HealthDegraded)).To(gomega.Equal(1.0))
+
+	counter.forget(db)
+	counter.forget(db)
+	g.Expect(count("team-a", HealthDegraded)).To(gomega.Equal(0.0))
+
+	// without the namespace label the applications of every namespace are counted together
+	counter.record(web, HealthHealthy, false)
+	counter.record(types.NamespacedName{Namespace: "team-b", Name: "api"}, HealthHealthy, false)
+	g.Expect(count("team-a", HealthHealthy)).To(gomega.Equal(0.0))
+	g.Expect(count("", HealthHealthy)).To(gomega.Equal(2.0))
+}
+
+func TestShortCircuitedReconciles(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	oldApp := &appv1beta1.Application{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-app", Namespace: "default", Generation: 1, ResourceVersion: "1"},
+	}
+	newApp := oldApp.DeepCopy()
+	newApp.ResourceVersion = "2"
+
+	skipped := event.UpdateEvent{ObjectOld: oldApp, ObjectNew: newApp}
+	before := testutil.ToFloat64(shortCircuitedReconciles)
+
+	// the other watches of the applications filter the same updates without counting them
+	g.Expect(applicationPredicateFunc.Update(skipped)).To(gomega.BeFalse())
+	g.Expect(testutil.ToFloat64(shortCircuitedReconciles)).To(gomega.Equal(before))
+
+	g.Expect(primaryApplicationPredicate.Update(skipped)).To(gomega.BeFalse())
+	g.Expect(testutil.ToFloat64(shortCircuitedReconciles)).To(gomega.Equal(before + 1))
+
+	newApp.Generation = 2
+	g.Expect(primaryApplicationPredicate.Update(skipped)).To(gomega.BeTrue())
+	g.Expect(testutil.ToFloat64(shortCircuitedReconciles)).To(gomega.Equal(before + 1))
+}
@@ -0,0 +1,223 @@
+// Copyright 2019 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package application
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/stolostron/multicloud-operators-application/pkg/apis"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/klog"
+	appv1beta1 "sigs.k8s.io/application/api/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// applicationOwnerRef returns the non-controller owner reference the application sets on its components
+func applicationOwnerRef(app *appv1beta1.Application) metav1.OwnerReference {
+	return metav1.OwnerReference{
+		APIVersion: apis.ApplicationGroupVersion.String(),
+		Kind:       "Application",
+		Name:       app.Name,
+		UID:        app.UID,
+	}
+}
+
+func hasOwnerRef(obj metav1.Object, uid string) bool {
+	for _, ref := range obj.GetOwnerReferences() {
+		if string(ref.UID) == uid {
+			return true
+		}
+	}
+
+	return false
+}
+
+// maxRepairedComponents bounds the components named in the repair event
+const maxRepairedComponents = 3
+
+// wasOwned returns true if the component was resolved by the previous reconcile of the same generation of the
+// application, which set the owner reference on it. The reference missing since was stripped by another actor.
+func wasOwned(app *appv1beta1.Application, u *unstructured.Unstructured) bool {
+	if app.Generation == 0 || app.Status.ObservedGeneration != app.Generation {
+		return false
+	}
+
+	gvk := u.GroupVersionKind()
+
+	for _, o := range app.Status.ComponentList.Objects {
+		if o.Group == gvk.Group && o.Kind == gvk.Kind && o.Name == u.GetName() {
+			return true
+		}
+	}
+
+	return false
+}
+
+// setOwnerRefs adds the application owner reference to the components missing it, a component that
+// fails to be patched is logged and does not stop the others from being patched. The components in
+// another namespace are skipped, owner references cannot cross namespaces, and so are the cluster-scoped
+// components, the namespace of the application included, a namespaced owner is invalid for them. The components the application
+// is the soft owner of are re-adopted, their owner reference to a predecessor of the same name is replaced.
+// The components that lost the reference since the previous reconcile are repaired the same way, only the
+// reference of the application is added back and the other owners are left as they are, and the repairs are
+// reported in an event. The components past the write budget are left for the next reconcile. It returns the
+// kinds the controller is not permitted to patch.
+func (r *ReconcileApplication) setOwnerRefs(ctx context.Context, app *appv1beta1.Application,
+	components []*unstructured.Unstructured, budget *writeBudget) []string {
+	ownerRef := applicationOwnerRef(app)
+	forbidden := map[string]bool{}
+
+	var repaired []string
+
+	for _, u := range components {
+		if hasOwnerRef(u, string(app.UID)) {
+			continue
+		}
+
+		gk := u.GroupVersionKind().GroupKind().String()
+
+		if u.GetNamespace() != app.Namespace {
+			klog.Warning("Skipped the owner reference of application ", app.Namespace+"/"+app.Name, " on ",
+				gk, " ", u.GetNamespace()+"/"+u.GetName(), " outside the application namespace")
+
+			continue
+		}
+
+		if !budget.take() {
+			continue
+		}
+
+		orig := u.DeepCopy()
+
+		refs := u.GetOwnerReferences()
+		if isSoftOwned(u, app) {
+			refs = withoutPredecessorOwnerRefs(refs, app)
+		}
+
+		u.SetOwnerReferences(append(refs, ownerRef))
+
+		if err := r.Patch(ctx, u, client.MergeFrom(orig)); err != nil {
+			klog.Error("Failed to set owner reference of application ", app.Namespace+"/"+app.Name, " on ",
+				gk, " ", u.GetNamespace()+"/"+u.GetName(), " error: ", err)
+
+			ownerRefPatchFailures.WithLabelValues(gk).Inc()
+
+			if errors.IsForbidden(err) {
+				forbidden[gk] = true
+			}
+
+			continue
+		}
+
+		klog.V(1).Info("Set owner reference of application ", app.Namespace+"/"+app.Name, " on ",
+			gk, " ", u.GetNamespace()+"/"+u.GetName())
+
+		if wasOwned(app, u) {
+			klog.Info("Repaired the owner reference of application ", app.Namespace+"/"+app.Name, " on ",
+				gk, " ", u.GetNamespace()+"/"+u.GetName())
+
+			repaired = append(repaired, u.GetKind()+" "+u.GetName())
+		}
+
+		for _, ref := range orig.GetOwnerReferences() {
+			if !hasOwnerRef(u, string(ref.UID)) {
+				r.options.AuditLog.record(app, u, AuditEntry{Action: AuditOwnerReferenceRemoved, OwnerUID: ref.UID})
+			}
+		}
+
+		r.options.AuditLog.record(app, u, AuditEntry{Action: AuditOwnerReferenceAdded, OwnerUID: app.UID})
+	}
+
+	if len(repaired) > 0 && r.eventRecorder != nil {
+		named := repaired
+		if len(named) > maxRepairedComponents {
+			named = named[:maxRepairedComponents]
+		}
+
+		r.eventRecorder.RecordEvent(app, "OwnerReferencesRepaired", fmt.Sprintf(
+			"The owner reference of the app was missing on %d components and is added back: %s",
+			len(repaired), strings.Join(named, ", ")), nil)
+	}
+
+	kinds := make([]string, 0, len(forbidden))
+	for gk := range forbidden {
+		kinds = append(kinds, gk)
+	}
+
+	sort.Strings(kinds)
+
+	return kinds
+}
+
+// removeOwnerRefs drops the owner reference of the application from the components carrying it, once
+// spec.addOwnerRef is turned off, so they are no longer garbage collected with the application. Only the
+// reference matching the application UID is removed, the other owners are kept. The components past the
+// write budget are left for the next reconcile.
+func (r *ReconcileApplication) removeOwnerRefs(ctx context.Context, app *appv1beta1.Application,
+	components []*unstructured.Unstructured, budget *writeBudget) {
+	for _, u := range components {
+		if !hasOwnerRef(u, string(app.UID)) {
+			continue
+		}
+
+		if !budget.take() {
+			continue
+		}
+
+		gk := u.GroupVersionKind().GroupKind().String()
+		orig := u.DeepCopy()
+
+		refs := make([]metav1.OwnerReference, 0, len(orig.GetOwnerReferences()))
+
+		for _, ref := range orig.GetOwnerReferences() {
+			if ref.UID != app.UID {
+				refs = append(refs, ref)
+			}
+		}
+
+		u.SetOwnerReferences(refs)
+
+		if err := r.Patch(ctx, u, client.MergeFrom(orig)); err != nil {
+			klog.Error("Failed to remove owner reference of application ", app.Namespace+"/"+app.Name, " from ",
+				gk, " ", u.GetNamespace()+"/"+u.GetName(), " error: ", err)
+
+			ownerRefPatchFailures.WithLabelValues(gk).Inc()
+
+			continue
+		}
+
+		klog.V(1).Info("Removed owner reference of application ", app.Namespace+"/"+app.Name, " from ",
+			gk, " ", u.GetNamespace()+"/"+u.GetName())
+
+		r.options.AuditLog.record(app, u, AuditEntry{Action: AuditOwnerReferenceRemoved, OwnerUID: app.UID})
+	}
+}
+
+// updateOwnerRefStatus names the kinds the controller is not permitted to set owner references on
+func updateOwnerRefStatus(status *appv1beta1.ApplicationStatus, forbidden []string) {
+	if len(forbidden) == 0 {
+		clearCondition(status, OwnerReferencesForbidden, "OwnerReferencesSet", "owner references can be set on every kind")
+		return
+	}
+
+	setCondition(status, OwnerReferencesForbidden, corev1.ConditionTrue, "Forbidden",
+		"not permitted to set owner references on kinds "+strings.Join(forbidden, ", "))
+}
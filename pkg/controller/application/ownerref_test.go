@@ -0,0 +1,180 @@
+// Copyright 2019 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package application
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/onsi/gomega"
+	"github.com/stolostron/multicloud-operators-application/utils"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	appv1beta1 "sigs.k8s.io/application/api/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func TestSetOwnerRefs(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	r := newTestReconciler(newTestConfigMap("matched", map[string]string{"app": "test-app"}))
+
+	app := newTestApplication(configMapGK)
+	app.UID = types.UID("test-app-uid")
+
+	res := r.resolveComponents(context.TODO(), app)
+	g.Expect(res.components).To(gomega.HaveLen(1))
+
+	r.setOwnerRefs(context.TODO(), app, res.components, nil)
+	r.setOwnerRefs(context.TODO(), app, res.components, nil)
+
+	cm := &corev1.ConfigMap{}
+	g.Expect(r.Get(context.TODO(), types.NamespacedName{Namespace: "default", Name: "matched"}, cm)).To(gomega.Succeed())
+	g.Expect(cm.OwnerReferences).To(gomega.HaveLen(1))
+	g.Expect(cm.OwnerReferences[0].UID).To(gomega.Equal(app.UID))
+	g.Expect(cm.OwnerReferences[0].Kind).To(gomega.Equal("Application"))
+}
+
+func TestRemoveOwnerRefs(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	other := metav1.OwnerReference{APIVersion: "v1", Kind: "ConfigMap", Name: "other", UID: types.UID("other-uid")}
+	matched := newTestConfigMap("matched", map[string]string{"app": "test-app"})
+	matched.OwnerReferences = []metav1.OwnerReference{other}
+
+	r := newTestReconciler(matched)
+
+	app := newTestApplication(configMapGK)
+	app.UID = types.UID("test-app-uid")
+	app.Spec.AddOwnerRef = true
+
+	r.setOwnerRefs(context.TODO(), app, r.resolveComponents(context.TODO(), app).components, nil)
+
+	cm := &corev1.ConfigMap{}
+	g.Expect(r.Get(context.TODO(), types.NamespacedName{Namespace: "default", Name: "matched"}, cm)).To(gomega.Succeed())
+	g.Expect(cm.OwnerReferences).To(gomega.HaveLen(2))
+
+	app.Spec.AddOwnerRef = false
+
+	r.removeOwnerRefs(context.TODO(), app, r.resolveComponents(context.TODO(), app).components, nil)
+
+	cm = &corev1.ConfigMap{}
+	g.Expect(r.Get(context.TODO(), types.NamespacedName{Namespace: "default", Name: "matched"}, cm)).To(gomega.Succeed())
+	g.Expect(cm.OwnerReferences).To(gomega.Equal([]metav1.OwnerReference{other}))
+}
+
+func TestRepairOwnerRefs(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	other := metav1.OwnerReference{APIVersion: "v1", Kind: "ConfigMap", Name: "other", UID: types.UID("other-uid")}
+	stripped := newTestConfigMap("stripped", map[string]string{"app": "test-app"})
+	stripped.OwnerReferences = []metav1.OwnerReference{other}
+
+	r := newTestReconciler(stripped, newTestConfigMap("added", map[string]string{"app": "test-app"}))
+
+	recorder := record.NewFakeRecorder(2)
+	r.eventRecorder = &utils.EventRecorder{EventRecorder: recorder}
+
+	// the previous reconcile of the same generation resolved the stripped component only
+	app := newTestApplication(configMapGK)
+	app.UID = types.UID("test-app-uid")
+	app.Generation = 1
+	app.Status.ObservedGeneration = 1
+	app.Status.ComponentList.Objects = []appv1beta1.ObjectStatus{{Kind: "ConfigMap", Name: "stripped"}}
+
+	r.setOwnerRefs(context.TODO(), app, r.resolveComponents(context.TODO(), app).components, nil)
+
+	cm := &corev1.ConfigMap{}
+	g.Expect(r.Get(context.TODO(), types.NamespacedName{Namespace: "default", Name: "stripped"}, cm)).To(gomega.Succeed())
+	g.Expect(cm.OwnerReferences).To(gomega.Equal([]metav1.OwnerReference{other, applicationOwnerRef(app)}))
+
+	g.Expect(recorder.Events).To(gomega.HaveLen(1))
+	g.Expect(<-recorder.Events).To(gomega.Equal(
+		"Normal OwnerReferencesRepaired The owner reference of the app was missing on 1 components and is added back: ConfigMap stripped"))
+
+	r.setOwnerRefs(context.TODO(), app, r.resolveComponents(context.TODO(), app).components, nil)
+	g.Expect(recorder.Events).To(gomega.BeEmpty())
+
+	// a new generation may have turned addOwnerRef on, the missing references are not repairs
+	g.Expect(r.Get(context.TODO(), types.NamespacedName{Namespace: "default", Name: "stripped"}, cm)).To(gomega.Succeed())
+	cm.OwnerReferences = nil
+	g.Expect(r.Update(context.TODO(), cm)).To(gomega.Succeed())
+
+	app.Generation = 2
+	r.setOwnerRefs(context.TODO(), app, r.resolveComponents(context.TODO(), app).components, nil)
+	g.Expect(recorder.Events).To(gomega.BeEmpty())
+}
+
+func TestSetOwnerRefsCrossNamespace(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	shared := newTestConfigMap("shared", map[string]string{"app": "test-app"})
+	shared.Namespace = "ns-b"
+
+	r := newTestReconciler(newTestConfigMap("local", map[string]string{"app": "test-app"}), shared)
+
+	app := newTestApplication(configMapGK)
+	app.UID = types.UID("test-app-uid")
+	app.Annotations = map[string]string{utils.AnnotationComponentNamespaces: `{"ConfigMap":"ns-b"}`}
+
+	res := r.resolveComponents(context.TODO(), app)
+	g.Expect(res.failures).To(gomega.BeEmpty())
+	g.Expect(res.components).To(gomega.HaveLen(1))
+	g.Expect(res.components[0].GetNamespace()).To(gomega.Equal("ns-b"))
+
+	g.Expect(r.setOwnerRefs(context.TODO(), app, res.components, nil)).To(gomega.BeEmpty())
+
+	cm := &corev1.ConfigMap{}
+	g.Expect(r.Get(context.TODO(), types.NamespacedName{Namespace: "ns-b", Name: "shared"}, cm)).To(gomega.Succeed())
+	g.Expect(cm.OwnerReferences).To(gomega.BeEmpty())
+}
+
+// forbiddenPatchClient rejects every patch as the API server does without RBAC on the kind
+type forbiddenPatchClient struct {
+	client.Client
+}
+
+func (c *forbiddenPatchClient) Patch(ctx context.Context, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+	return errors.NewForbidden(schema.GroupResource{Resource: "configmaps"}, obj.GetName(), fmt.Errorf("rbac"))
+}
+
+func TestSetOwnerRefsForbidden(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	r := newTestReconciler(newTestConfigMap("matched", map[string]string{"app": "test-app"}))
+	r.Client = &forbiddenPatchClient{r.Client}
+
+	app := newTestApplication(configMapGK)
+	app.UID = types.UID("test-app-uid")
+
+	res := r.resolveComponents(context.TODO(), app)
+	g.Expect(res.components).To(gomega.HaveLen(1))
+
+	forbidden := r.setOwnerRefs(context.TODO(), app, res.components, nil)
+	g.Expect(forbidden).To(gomega.Equal([]string{"ConfigMap"}))
+
+	status := &appv1beta1.ApplicationStatus{}
+	updateOwnerRefStatus(status, forbidden)
+	g.Expect(getCondition(status, OwnerReferencesForbidden).Status).To(gomega.Equal(corev1.ConditionTrue))
+	g.Expect(getCondition(status, OwnerReferencesForbidden).Message).To(gomega.ContainSubstring("ConfigMap"))
+
+	updateOwnerRefStatus(status, nil)
+	g.Expect(getCondition(status, OwnerReferencesForbidden).Status).To(gomega.Equal(corev1.ConditionFalse))
+}
@@ -0,0 +1,34 @@
+// Copyright 2019 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package application
+
+import (
+	"github.com/stolostron/multicloud-operators-application/utils"
+	corev1 "k8s.io/api/core/v1"
+	appv1beta1 "sigs.k8s.io/application/api/v1beta1"
+)
+
+// updatePausedCondition reports whether the writes to the components are paused, a frozen application is not
+// reconciled and never gets there
+func updatePausedCondition(appStatus *appv1beta1.ApplicationStatus, pause string) {
+	if pause == utils.PauseMutations {
+		setCondition(appStatus, Paused, corev1.ConditionTrue, "MutationsPaused",
+			"the components are not written while the application is paused, its status is still updated")
+
+		return
+	}
+
+	clearCondition(appStatus, Paused, "NotPaused", "the application is not paused")
+}
@@ -0,0 +1,74 @@
+// Copyright 2019 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package application
+
+import (
+	"context"
+	"testing"
+
+	"github.com/onsi/gomega"
+	"github.com/stolostron/multicloud-operators-application/utils"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	appv1beta1 "sigs.k8s.io/application/api/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+func TestUpdatePausedCondition(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	status := &appv1beta1.ApplicationStatus{}
+
+	updatePausedCondition(status, utils.PauseNone)
+	g.Expect(getCondition(status, Paused)).To(gomega.BeNil())
+
+	updatePausedCondition(status, utils.PauseMutations)
+	g.Expect(getCondition(status, Paused).Status).To(gomega.Equal(corev1.ConditionTrue))
+
+	updatePausedCondition(status, utils.PauseNone)
+	g.Expect(getCondition(status, Paused).Status).To(gomega.Equal(corev1.ConditionFalse))
+}
+
+func TestReconcileFrozenApplication(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	s := runtime.NewScheme()
+	g.Expect(clientgoscheme.AddToScheme(s)).To(gomega.Succeed())
+	g.Expect(appv1beta1.AddToScheme(s)).To(gomega.Succeed())
+
+	app := newTestApplication(configMapGK)
+	app.Spec.AddOwnerRef = true
+	app.Annotations = map[string]string{utils.AnnotationPause: utils.PauseAll}
+	key := types.NamespacedName{Namespace: app.Namespace, Name: app.Name}
+
+	cm := newTestConfigMap("cm1", map[string]string{"app": "test-app"})
+
+	r := newTestReconciler()
+	r.Client = fake.NewClientBuilder().WithScheme(s).WithObjects(app, &cm).Build()
+
+	result, err := r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: key})
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(result).To(gomega.Equal(reconcile.Result{}))
+
+	// neither the component nor the status is written
+	g.Expect(r.Get(context.TODO(), types.NamespacedName{Namespace: cm.Namespace, Name: cm.Name}, &cm)).To(gomega.Succeed())
+	g.Expect(cm.OwnerReferences).To(gomega.BeEmpty())
+
+	g.Expect(r.Get(context.TODO(), key, app)).To(gomega.Succeed())
+	g.Expect(app.Status.Conditions).To(gomega.BeEmpty())
+}
@@ -0,0 +1,224 @@
+// Copyright 2019 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package application
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"github.com/stolostron/multicloud-operators-application/utils"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog"
+	appv1beta1 "sigs.k8s.io/application/api/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// propagateLabels sets the labels of the application on its components, each component gets the labels for
+// every component merged with the labels for its kind, along with the propagated annotations of the application.
+// Under the Remove cleanup policy the labels and annotations propagated before and no longer wanted are removed,
+// from the components still selected as well as from the components of the componentGroupKinds no longer
+// selected. A component that fails to be patched is logged and does not stop the others from being patched, the
+// components past the write budget are left for the next reconcile.
+func (r *ReconcileApplication) propagateLabels(ctx context.Context, app *appv1beta1.Application,
+	components []*unstructured.Unstructured, propagated *utils.PropagatedLabels, annotations map[string]string, policy string,
+	budget *writeBudget) {
+	selected := make(map[types.UID]bool, len(components))
+
+	for _, u := range components {
+		selected[u.GetUID()] = true
+
+		r.syncPropagatedLabels(ctx, app, u, propagated.ForKind(u.GroupVersionKind().GroupKind()), annotations, policy, budget)
+	}
+
+	if policy == utils.LabelCleanupPolicyRemove {
+		r.cleanupUnselectedLabels(ctx, app, selected, budget)
+	}
+}
+
+// syncPropagatedLabels sets the wanted labels and annotations on the component and records their keys, the
+// recorded keys no longer wanted are removed under the Remove cleanup policy
+func (r *ReconcileApplication) syncPropagatedLabels(ctx context.Context, app *appv1beta1.Application,
+	u *unstructured.Unstructured, want, wantAnnotations map[string]string, policy string, budget *writeBudget) {
+	gk := u.GroupVersionKind().GroupKind()
+
+	lbls := u.GetLabels()
+	if lbls == nil {
+		lbls = map[string]string{}
+	}
+
+	annotations := u.GetAnnotations()
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+
+	orig := u.DeepCopy()
+
+	labelKeys, changed := syncPropagatedKeys(lbls, annotations[utils.AnnotationPropagatedLabelKeys], want, policy)
+
+	annotationKeys, annotationsChanged := syncPropagatedKeys(annotations, annotations[utils.AnnotationPropagatedAnnotationKeys],
+		wantAnnotations, policy)
+	changed = annotationsChanged || changed
+
+	changed = recordPropagatedKeys(annotations, utils.AnnotationPropagatedLabelKeys, labelKeys, len(want) > 0, policy) || changed
+	changed = recordPropagatedKeys(annotations, utils.AnnotationPropagatedAnnotationKeys, annotationKeys,
+		len(wantAnnotations) > 0, policy) || changed
+
+	if len(want) > 0 || len(wantAnnotations) > 0 {
+		if cur, ok := lbls[utils.LabelPropagatedBy]; !ok || cur != string(app.UID) {
+			lbls[utils.LabelPropagatedBy] = string(app.UID)
+			changed = true
+		}
+	} else if policy == utils.LabelCleanupPolicyRemove {
+		if _, ok := lbls[utils.LabelPropagatedBy]; ok {
+			delete(lbls, utils.LabelPropagatedBy)
+
+			changed = true
+		}
+	}
+
+	if !changed || !budget.take() {
+		return
+	}
+
+	u.SetLabels(lbls)
+	u.SetAnnotations(annotations)
+
+	if err := r.Patch(ctx, u, client.MergeFrom(orig)); err != nil {
+		klog.Error("Failed to propagate labels of application ", app.Namespace+"/"+app.Name, " to ",
+			gk.String(), " ", u.GetNamespace()+"/"+u.GetName(), " error: ", err)
+
+		return
+	}
+
+	klog.V(1).Info("Propagated labels of application ", app.Namespace+"/"+app.Name, " to ",
+		gk.String(), " ", u.GetNamespace()+"/"+u.GetName())
+
+	if r.options.AuditLog != nil {
+		entry := AuditEntry{Action: AuditLabelsPropagated}
+		entry.SetLabels, entry.RemovedLabels = diffEntries(orig.GetLabels(), u.GetLabels())
+		entry.SetAnnotations, entry.RemovedAnnotations = diffEntries(orig.GetAnnotations(), u.GetAnnotations())
+
+		r.options.AuditLog.record(app, u, entry)
+	}
+}
+
+// syncPropagatedKeys sets the wanted entries on cur, labels or annotations, and removes the recorded keys no
+// longer wanted under the Remove cleanup policy. It returns the keys to record and true when cur changed.
+func syncPropagatedKeys(cur map[string]string, recorded string, want map[string]string, policy string) (string, bool) {
+	changed := false
+
+	for k, v := range want {
+		if c, ok := cur[k]; !ok || c != v {
+			cur[k] = v
+			changed = true
+		}
+	}
+
+	if policy == utils.LabelCleanupPolicyRemove {
+		for _, k := range strings.Split(recorded, ",") {
+			if _, ok := want[k]; ok || k == "" {
+				continue
+			}
+
+			if _, ok := cur[k]; ok {
+				delete(cur, k)
+
+				changed = true
+			}
+		}
+	}
+
+	// the kept entries stay recorded, they are removed should the policy change to Remove
+	keys := make(map[string]bool, len(want))
+	for k := range want {
+		keys[k] = true
+	}
+
+	if policy != utils.LabelCleanupPolicyRemove {
+		for _, k := range strings.Split(recorded, ",") {
+			if _, ok := cur[k]; ok && k != "" {
+				keys[k] = true
+			}
+		}
+	}
+
+	return joinKeys(keys), changed
+}
+
+// recordPropagatedKeys records the propagated keys in the annotation while anything is propagated, the record
+// is dropped along the entries under the Remove cleanup policy
+func recordPropagatedKeys(annotations map[string]string, annotation, keys string, propagating bool, policy string) bool {
+	if propagating {
+		if annotations[annotation] != keys {
+			annotations[annotation] = keys
+
+			return true
+		}
+
+		return false
+	}
+
+	if _, ok := annotations[annotation]; ok && policy == utils.LabelCleanupPolicyRemove {
+		delete(annotations, annotation)
+
+		return true
+	}
+
+	return false
+}
+
+// cleanupUnselectedLabels removes the propagated labels and annotations from the components of the componentGroupKinds the
+// application propagated labels to and no longer selects
+func (r *ReconcileApplication) cleanupUnselectedLabels(ctx context.Context, app *appv1beta1.Application, selected map[types.UID]bool,
+	budget *writeBudget) {
+	// an invalid annotation is already reported by the component resolution
+	namespaces, _ := utils.GetComponentNamespaces(app)
+	selector := labels.SelectorFromSet(labels.Set{utils.LabelPropagatedBy: string(app.UID)})
+
+	for _, gk := range app.Spec.ComponentGroupKinds {
+		ns, ok := namespaces[normalizedGroupKind(gk)]
+		if !ok {
+			ns = app.Namespace
+		}
+
+		items, err := r.listComponents(ctx, nil, gk, ns, selector)
+		if err != nil {
+			klog.Error("Failed to list the components of kind ", gk.String(), " labeled by application ",
+				app.Namespace+"/"+app.Name, " error: ", err)
+
+			continue
+		}
+
+		for _, u := range items {
+			if !selected[u.GetUID()] {
+				r.syncPropagatedLabels(ctx, app, u, nil, nil, utils.LabelCleanupPolicyRemove, budget)
+			}
+		}
+	}
+}
+
+func joinKeys(keys map[string]bool) string {
+	sorted := make([]string, 0, len(keys))
+	for k := range keys {
+		sorted = append(sorted, k)
+	}
+
+	sort.Strings(sorted)
+
+	return strings.Join(sorted, ",")
+}
@@ -0,0 +1,157 @@
+// Copyright 2019 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package application
+
+import (
+	"context"
+	"testing"
+
+	"github.com/onsi/gomega"
+	"github.com/stolostron/multicloud-operators-application/utils"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func TestPropagateLabels(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	r := newTestReconciler(newTestConfigMap("matched", map[string]string{"app": "test-app"}))
+
+	app := newTestApplication(configMapGK)
+	app.UID = types.UID("test-app-uid")
+	app.Annotations = map[string]string{
+		utils.AnnotationPropagateLabels:     `{"team":"a","tier":"backend"}`,
+		utils.AnnotationPropagateKindLabels: `{"ConfigMap":{"tier":"config"},"Service":{"mesh":"enabled"}}`,
+	}
+
+	propagated, err := utils.GetPropagatedLabels(app)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+
+	res := r.resolveComponents(context.TODO(), app)
+	g.Expect(res.components).To(gomega.HaveLen(1))
+
+	r.propagateLabels(context.TODO(), app, res.components, propagated, nil, utils.LabelCleanupPolicyRemove, nil)
+
+	cm := &corev1.ConfigMap{}
+	g.Expect(r.Get(context.TODO(), types.NamespacedName{Namespace: "default", Name: "matched"}, cm)).To(gomega.Succeed())
+	g.Expect(cm.Labels).To(gomega.Equal(map[string]string{
+		"app": "test-app", "team": "a", "tier": "config", utils.LabelPropagatedBy: "test-app-uid",
+	}))
+	g.Expect(cm.Annotations).To(gomega.HaveKeyWithValue(utils.AnnotationPropagatedLabelKeys, "team,tier"))
+}
+
+func TestPropagateLabelsCleanup(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	matched := newTestConfigMap("matched", map[string]string{"app": "test-app"})
+	matched.UID = types.UID("matched-uid")
+	leaving := newTestConfigMap("leaving", map[string]string{"app": "test-app"})
+	leaving.UID = types.UID("leaving-uid")
+
+	r := newTestReconciler(matched, leaving)
+
+	app := newTestApplication(configMapGK)
+	app.UID = types.UID("test-app-uid")
+	app.Annotations = map[string]string{utils.AnnotationPropagateLabels: `{"team":"a","tier":"backend"}`}
+
+	propagate := func(policy string) {
+		propagated, err := utils.GetPropagatedLabels(app)
+		g.Expect(err).NotTo(gomega.HaveOccurred())
+
+		res := r.resolveComponents(context.TODO(), app)
+		r.propagateLabels(context.TODO(), app, res.components, propagated, nil, policy, nil)
+	}
+
+	getLabels := func(name string) map[string]string {
+		cm := &corev1.ConfigMap{}
+		g.Expect(r.Get(context.TODO(), types.NamespacedName{Namespace: "default", Name: name}, cm)).To(gomega.Succeed())
+
+		return cm.Labels
+	}
+
+	propagate(utils.LabelCleanupPolicyRemove)
+	g.Expect(getLabels("leaving")).To(gomega.HaveKeyWithValue("tier", "backend"))
+
+	// a label key dropped and a component no longer selected, the labels are kept in place
+	app.Annotations[utils.AnnotationPropagateLabels] = `{"team":"a"}`
+	app.Spec.Selector.MatchExpressions = []metav1.LabelSelectorRequirement{
+		{Key: "tier", Operator: metav1.LabelSelectorOpExists},
+		{Key: "team", Operator: metav1.LabelSelectorOpExists},
+	}
+
+	propagate(utils.LabelCleanupPolicyKeep)
+	g.Expect(getLabels("matched")).To(gomega.HaveKey("tier"))
+
+	// only matched keeps being selected once its tier label is gone
+	app.Spec.Selector = &metav1.LabelSelector{MatchLabels: map[string]string{"app": "test-app"}}
+	g.Expect(r.Patch(context.TODO(), &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "leaving", Namespace: "default"}},
+		client.RawPatch(types.MergePatchType, []byte(`{"metadata":{"labels":{"app":"other"}}}`)))).To(gomega.Succeed())
+
+	propagate(utils.LabelCleanupPolicyRemove)
+	g.Expect(getLabels("matched")).To(gomega.Equal(map[string]string{
+		"app": "test-app", "team": "a", utils.LabelPropagatedBy: "test-app-uid",
+	}))
+	g.Expect(getLabels("leaving")).To(gomega.Equal(map[string]string{"app": "other"}))
+}
+
+func TestPropagateAnnotations(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	r := newTestReconciler(newTestConfigMap("matched", map[string]string{"app": "test-app"}))
+
+	app := newTestApplication(configMapGK)
+	app.UID = types.UID("test-app-uid")
+	app.Annotations = map[string]string{
+		utils.AnnotationPropagateAnnotations: `["example.com/revision","example.com/missing"]`,
+		"example.com/revision":               "abc123",
+		"example.com/owner":                  "team-a",
+	}
+
+	propagate := func() *corev1.ConfigMap {
+		annotations, err := utils.GetPropagatedAnnotations(app)
+		g.Expect(err).NotTo(gomega.HaveOccurred())
+
+		res := r.resolveComponents(context.TODO(), app)
+		r.propagateLabels(context.TODO(), app, res.components, nil, annotations, utils.LabelCleanupPolicyRemove, nil)
+
+		cm := &corev1.ConfigMap{}
+		g.Expect(r.Get(context.TODO(), types.NamespacedName{Namespace: "default", Name: "matched"}, cm)).To(gomega.Succeed())
+
+		return cm
+	}
+
+	cm := propagate()
+	g.Expect(cm.Annotations).To(gomega.Equal(map[string]string{
+		"example.com/revision":                   "abc123",
+		utils.AnnotationPropagatedAnnotationKeys: "example.com/revision",
+	}))
+	g.Expect(cm.Labels).To(gomega.HaveKeyWithValue(utils.LabelPropagatedBy, "test-app-uid"))
+
+	app.Annotations["example.com/revision"] = "def456"
+	g.Expect(propagate().Annotations).To(gomega.HaveKeyWithValue("example.com/revision", "def456"))
+
+	// the revision is no longer propagated, it is removed from the component along the record
+	app.Annotations[utils.AnnotationPropagateAnnotations] = `[]`
+
+	cm = propagate()
+	g.Expect(cm.Annotations).To(gomega.BeEmpty())
+	g.Expect(cm.Labels).NotTo(gomega.HaveKey(utils.LabelPropagatedBy))
+
+	app.Annotations[utils.AnnotationPropagateAnnotations] = `["apps.open-cluster-management.io/soft-owner"]`
+	_, err := utils.GetPropagatedAnnotations(app)
+	g.Expect(err).To(gomega.HaveOccurred())
+}
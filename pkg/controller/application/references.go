@@ -0,0 +1,116 @@
+// Copyright 2019 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package application
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/stolostron/multicloud-operators-application/utils"
+	"k8s.io/apimachinery/pkg/types"
+	appv1beta1 "sigs.k8s.io/application/api/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// configMapReferences tracks the ConfigMaps the applications resolve their components from, so a change to
+// one enqueues the applications referencing it
+var configMapReferences = newReferenceTracker()
+
+// referenceTracker maps the referenced objects to the applications referencing them, each application is
+// tracked as last reconciled
+type referenceTracker struct {
+	lock  sync.RWMutex
+	refs  map[types.NamespacedName]map[types.NamespacedName]bool
+	byApp map[types.NamespacedName]types.NamespacedName
+}
+
+func newReferenceTracker() *referenceTracker {
+	return &referenceTracker{
+		refs:  map[types.NamespacedName]map[types.NamespacedName]bool{},
+		byApp: map[types.NamespacedName]types.NamespacedName{},
+	}
+}
+
+// trackConfigMapReference records the components ConfigMap of the application, dropping the one it referenced
+// before
+func (t *referenceTracker) trackConfigMapReference(app *appv1beta1.Application) {
+	key := types.NamespacedName{Namespace: app.Namespace, Name: app.Name}
+
+	ref, found, err := utils.GetComponentsConfigMap(app)
+	if !found || err != nil {
+		t.forget(key)
+		return
+	}
+
+	t.set(key, types.NamespacedName{Namespace: app.Namespace, Name: ref.Name})
+}
+
+func (t *referenceTracker) set(app, ref types.NamespacedName) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if prev, ok := t.byApp[app]; ok {
+		if prev == ref {
+			return
+		}
+
+		t.unlink(app, prev)
+	}
+
+	if t.refs[ref] == nil {
+		t.refs[ref] = map[types.NamespacedName]bool{}
+	}
+
+	t.refs[ref][app] = true
+	t.byApp[app] = ref
+}
+
+// forget stops tracking the reference of a deleted application, or of one no longer referencing any
+func (t *referenceTracker) forget(app types.NamespacedName) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if prev, ok := t.byApp[app]; ok {
+		t.unlink(app, prev)
+	}
+}
+
+func (t *referenceTracker) unlink(app, ref types.NamespacedName) {
+	delete(t.refs[ref], app)
+
+	if len(t.refs[ref]) == 0 {
+		delete(t.refs, ref)
+	}
+
+	delete(t.byApp, app)
+}
+
+// Map enqueues the applications referencing the object
+func (t *referenceTracker) Map(obj client.Object) []reconcile.Request {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+
+	apps := t.refs[types.NamespacedName{Namespace: obj.GetNamespace(), Name: obj.GetName()}]
+
+	requests := make([]reconcile.Request, 0, len(apps))
+	for app := range apps {
+		requests = append(requests, reconcile.Request{NamespacedName: app})
+	}
+
+	sort.Slice(requests, func(i, j int) bool { return requests[i].Name < requests[j].Name })
+
+	return requests
+}
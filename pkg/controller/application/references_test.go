@@ -0,0 +1,66 @@
+// Copyright 2019 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package application
+
+import (
+	"testing"
+
+	"github.com/onsi/gomega"
+	"github.com/stolostron/multicloud-operators-application/utils"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+func TestConfigMapReferences(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	tracker := newReferenceTracker()
+
+	inventory := newTestConfigMap("inventory", nil)
+	other := newTestConfigMap("other", nil)
+
+	web := newTestApplication()
+	web.Name = "web"
+	web.Annotations = map[string]string{utils.AnnotationComponentsConfigMap: "inventory/components"}
+
+	db := newTestApplication()
+	db.Name = "db"
+	db.Annotations = map[string]string{utils.AnnotationComponentsConfigMap: "inventory/db"}
+
+	tracker.trackConfigMapReference(web)
+	tracker.trackConfigMapReference(db)
+	tracker.trackConfigMapReference(db)
+
+	g.Expect(tracker.Map(&inventory)).To(gomega.Equal([]reconcile.Request{
+		{NamespacedName: types.NamespacedName{Namespace: "default", Name: "db"}},
+		{NamespacedName: types.NamespacedName{Namespace: "default", Name: "web"}},
+	}))
+	g.Expect(tracker.Map(&other)).To(gomega.BeEmpty())
+
+	// the reference moves along the annotation, and is dropped with it
+	db.Annotations[utils.AnnotationComponentsConfigMap] = "other/db"
+	tracker.trackConfigMapReference(db)
+	g.Expect(tracker.Map(&inventory)).To(gomega.HaveLen(1))
+	g.Expect(tracker.Map(&other)).To(gomega.HaveLen(1))
+
+	delete(web.Annotations, utils.AnnotationComponentsConfigMap)
+	tracker.trackConfigMapReference(web)
+	g.Expect(tracker.Map(&inventory)).To(gomega.BeEmpty())
+
+	tracker.forget(types.NamespacedName{Namespace: "default", Name: "db"})
+	g.Expect(tracker.Map(&other)).To(gomega.BeEmpty())
+	g.Expect(tracker.refs).To(gomega.BeEmpty())
+	g.Expect(tracker.byApp).To(gomega.BeEmpty())
+}
@@ -0,0 +1,111 @@
+// Copyright 2019 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package application
+
+import (
+	"context"
+	"strings"
+
+	"github.com/stolostron/multicloud-operators-application/utils"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog"
+	appv1beta1 "sigs.k8s.io/application/api/v1beta1"
+)
+
+// requiredComponentsCheck is the outcome of checking the required components of an application
+type requiredComponentsCheck struct {
+	missing []string
+	// failed holds the components whose existence could not be determined
+	failed []string
+}
+
+// checkRequiredComponents looks up every required component by name, regardless of the application selector, in
+// the namespace of its kind or else the application namespace
+func (r *ReconcileApplication) checkRequiredComponents(ctx context.Context, app *appv1beta1.Application) (*requiredComponentsCheck, error) {
+	rcs, err := utils.GetRequiredComponents(app)
+	if err != nil {
+		return nil, err
+	}
+
+	check := &requiredComponentsCheck{}
+	if len(rcs) == 0 {
+		return check, nil
+	}
+
+	namespaces, err := utils.GetComponentNamespaces(app)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, rc := range rcs {
+		gk := normalizedGroupKind(rc.GroupKind())
+
+		// a kind the cluster does not serve may be installed later, the component is not known to be missing
+		mapping, err := r.mapper.RESTMapping(gk)
+		if err != nil {
+			klog.Info("No mapping for required component ", rc.String(), " error: ", err)
+
+			check.failed = append(check.failed, rc.String())
+
+			continue
+		}
+
+		ns, ok := namespaces[gk]
+		if !ok {
+			ns = app.Namespace
+		}
+
+		if mapping.Scope.Name() == meta.RESTScopeNameRoot {
+			ns = ""
+		}
+
+		obj := &unstructured.Unstructured{}
+		obj.SetGroupVersionKind(mapping.GroupVersionKind)
+
+		err = r.Get(ctx, types.NamespacedName{Namespace: ns, Name: rc.Name}, obj)
+
+		switch {
+		case err == nil:
+		case errors.IsNotFound(err):
+			check.missing = append(check.missing, rc.String())
+		default:
+			klog.Error("Failed to get required component ", rc.String(), " error: ", err)
+
+			check.failed = append(check.failed, rc.String())
+		}
+	}
+
+	return check, nil
+}
+
+// updateRequiredComponentsStatus sets the Degraded condition naming the missing required components
+func updateRequiredComponentsStatus(status *appv1beta1.ApplicationStatus, check *requiredComponentsCheck, err error) {
+	switch {
+	case err != nil:
+		setCondition(status, Degraded, corev1.ConditionTrue, "InvalidRequiredComponents", err.Error())
+	case len(check.missing) > 0:
+		setCondition(status, Degraded, corev1.ConditionTrue, "RequiredComponentsMissing",
+			"missing required components: "+strings.Join(check.missing, ", "))
+	case len(check.failed) > 0:
+		setCondition(status, Degraded, corev1.ConditionUnknown, "RequiredComponentsUnknown",
+			"unable to check required components: "+strings.Join(check.failed, ", "))
+	default:
+		clearCondition(status, Degraded, "RequiredComponentsPresent", "all required components are present")
+	}
+}
@@ -0,0 +1,108 @@
+// Copyright 2019 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package application
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/onsi/gomega"
+	"github.com/stolostron/multicloud-operators-application/utils"
+	corev1 "k8s.io/api/core/v1"
+	appv1beta1 "sigs.k8s.io/application/api/v1beta1"
+)
+
+func TestCheckRequiredComponents(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	shared := newTestConfigMap("shared", nil)
+	shared.Namespace = "ns-b"
+
+	r := newTestReconciler(newTestConfigMap("config", nil), shared)
+
+	app := newTestApplication(configMapGK)
+
+	check, err := r.checkRequiredComponents(context.TODO(), app)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(check.missing).To(gomega.BeEmpty())
+	g.Expect(check.failed).To(gomega.BeEmpty())
+
+	// present, missing and of a kind the cluster does not serve
+	app.Annotations = map[string]string{utils.AnnotationRequiredComponents: `[{"kind":"ConfigMap","name":"config"},` +
+		`{"kind":"ConfigMap","name":"absent"},{"group":"example.com","kind":"Unknown","name":"x"}]`}
+
+	check, err = r.checkRequiredComponents(context.TODO(), app)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(check.missing).To(gomega.Equal([]string{"ConfigMap/absent"}))
+	g.Expect(check.failed).To(gomega.Equal([]string{"Unknown.example.com/x"}))
+
+	// the required components are looked up in the namespace of their kind
+	app.Annotations = map[string]string{
+		utils.AnnotationRequiredComponents:  `[{"kind":"ConfigMap","name":"shared"}]`,
+		utils.AnnotationComponentNamespaces: `{"ConfigMap":"ns-b"}`,
+	}
+
+	check, err = r.checkRequiredComponents(context.TODO(), app)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(check.missing).To(gomega.BeEmpty())
+	g.Expect(check.failed).To(gomega.BeEmpty())
+
+	app.Annotations[utils.AnnotationRequiredComponents] = `[{"kind":"ConfigMap","name":"config"}]`
+
+	check, err = r.checkRequiredComponents(context.TODO(), app)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(check.missing).To(gomega.Equal([]string{"ConfigMap/config"}))
+
+	app.Annotations[utils.AnnotationRequiredComponents] = `{`
+
+	_, err = r.checkRequiredComponents(context.TODO(), app)
+	g.Expect(err).To(gomega.HaveOccurred())
+}
+
+func TestUpdateRequiredComponentsStatus(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	status := &appv1beta1.ApplicationStatus{}
+
+	updateRequiredComponentsStatus(status, &requiredComponentsCheck{
+		missing: []string{"ConfigMap/absent"},
+		failed:  []string{"Unknown.example.com/x"},
+	}, nil)
+
+	cond := getCondition(status, Degraded)
+	g.Expect(cond).NotTo(gomega.BeNil())
+	g.Expect(cond.Status).To(gomega.Equal(corev1.ConditionTrue))
+	g.Expect(cond.Reason).To(gomega.Equal("RequiredComponentsMissing"))
+	g.Expect(cond.Message).To(gomega.ContainSubstring("ConfigMap/absent"))
+
+	updateRequiredComponentsStatus(status, &requiredComponentsCheck{failed: []string{"Unknown.example.com/x"}}, nil)
+
+	cond = getCondition(status, Degraded)
+	g.Expect(cond.Status).To(gomega.Equal(corev1.ConditionUnknown))
+	g.Expect(cond.Reason).To(gomega.Equal("RequiredComponentsUnknown"))
+
+	updateRequiredComponentsStatus(status, &requiredComponentsCheck{}, nil)
+
+	cond = getCondition(status, Degraded)
+	g.Expect(cond.Status).To(gomega.Equal(corev1.ConditionFalse))
+	g.Expect(cond.Reason).To(gomega.Equal("RequiredComponentsPresent"))
+
+	updateRequiredComponentsStatus(status, nil, errors.New("invalid annotation"))
+
+	cond = getCondition(status, Degraded)
+	g.Expect(cond.Status).To(gomega.Equal(corev1.ConditionTrue))
+	g.Expect(cond.Reason).To(gomega.Equal("InvalidRequiredComponents"))
+}
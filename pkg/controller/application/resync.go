@@ -0,0 +1,95 @@
+// Copyright 2019 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package application
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"k8s.io/klog"
+	appv1beta1 "sigs.k8s.io/application/api/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+)
+
+// ResyncPath is served on the metrics address when the resync endpoint is enabled, a POST enqueues every
+// application of the namespace query parameter, or of the cluster when it is not set
+const ResyncPath = "/admin/resync"
+
+// resyncEvents feeds the applications enqueued through the resync endpoint to the controller
+var resyncEvents = make(chan event.GenericEvent, 1024)
+
+type resyncHandler struct {
+	reader client.Reader
+	token  string
+	events chan<- event.GenericEvent
+}
+
+// RegisterResyncHandler serves the resync endpoint on the manager's metrics server, the requests must carry
+// the token as a bearer token
+func RegisterResyncHandler(mgr manager.Manager, token string) error {
+	if token == "" {
+		return errors.New("the resync endpoint requires a token")
+	}
+
+	return mgr.AddMetricsExtraHandler(ResyncPath, &resyncHandler{reader: mgr.GetClient(), token: token, events: resyncEvents})
+}
+
+func (h *resyncHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+
+		return
+	}
+
+	if subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), []byte("Bearer "+h.token)) != 1 {
+		http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+		return
+	}
+
+	namespace := r.URL.Query().Get("namespace")
+
+	apps := &appv1beta1.ApplicationList{}
+	if err := h.reader.List(r.Context(), apps, client.InNamespace(namespace)); err != nil {
+		klog.Error("Failed to list applications to resync in namespace ", namespace, " error: ", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+
+		return
+	}
+
+	enqueued := 0
+
+	for i := range apps.Items {
+		select {
+		case h.events <- event.GenericEvent{Object: &apps.Items[i]}:
+			enqueued++
+		case <-r.Context().Done():
+			klog.Warning("Resync request canceled after enqueuing ", enqueued, " applications")
+			return
+		}
+	}
+
+	klog.Info("Enqueued ", enqueued, " applications for resync, namespace: ", namespace)
+
+	w.Header().Set("Content-Type", "application/json")
+
+	if err := json.NewEncoder(w).Encode(map[string]int{"enqueued": enqueued}); err != nil {
+		klog.Error("Failed to write the resync response, error: ", err)
+	}
+}
@@ -0,0 +1,62 @@
+// Copyright 2019 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package application
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/runtime"
+	appv1beta1 "sigs.k8s.io/application/api/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+)
+
+func TestResyncHandler(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	s := runtime.NewScheme()
+	g.Expect(appv1beta1.AddToScheme(s)).To(gomega.Succeed())
+
+	other := newTestApplication()
+	other.Namespace = "other"
+
+	events := make(chan event.GenericEvent, 2)
+	h := &resyncHandler{
+		reader: fake.NewClientBuilder().WithScheme(s).WithObjects(newTestApplication(), other).Build(),
+		token:  "secret",
+		events: events,
+	}
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodPost, ResyncPath+"?namespace=default", nil))
+	g.Expect(w.Code).To(gomega.Equal(http.StatusUnauthorized))
+
+	req := httptest.NewRequest(http.MethodPost, ResyncPath+"?namespace=default", nil)
+	req.Header.Set("Authorization", "Bearer secret")
+
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, req)
+	g.Expect(w.Code).To(gomega.Equal(http.StatusOK))
+	g.Expect(w.Body.String()).To(gomega.ContainSubstring(`"enqueued":1`))
+	g.Expect(events).To(gomega.HaveLen(1))
+	g.Expect((<-events).Object.GetNamespace()).To(gomega.Equal("default"))
+
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, ResyncPath, nil))
+	g.Expect(w.Code).To(gomega.Equal(http.StatusMethodNotAllowed))
+}
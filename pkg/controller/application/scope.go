@@ -0,0 +1,54 @@
+// Copyright 2019 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package application
+
+import (
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+)
+
+// inScope returns true when the labels of the application match the selector of the operator instance, a nil
+// selector scopes every application in
+func inScope(selector labels.Selector, obj client.Object) bool {
+	return selector == nil || selector.Matches(labels.Set(obj.GetLabels()))
+}
+
+// scopePredicate lets through the events of the applications in the scope of the operator instance
+func scopePredicate(selector labels.Selector) predicate.Predicate {
+	return predicate.NewPredicateFuncs(func(obj client.Object) bool {
+		return inScope(selector, obj)
+	})
+}
+
+// scopeEnteredPredicate lets through the applications relabeled into the scope, their metadata only update is
+// skipped by the application predicate otherwise
+func scopeEnteredPredicate(selector labels.Selector) predicate.Predicate {
+	return predicate.Funcs{
+		CreateFunc: func(e event.CreateEvent) bool {
+			return false
+		},
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			return !inScope(selector, e.ObjectOld) && inScope(selector, e.ObjectNew)
+		},
+		DeleteFunc: func(e event.DeleteEvent) bool {
+			return false
+		},
+		GenericFunc: func(e event.GenericEvent) bool {
+			return false
+		},
+	}
+}
@@ -0,0 +1,77 @@
+// Copyright 2019 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package application
+
+import (
+	"context"
+	"testing"
+
+	"github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	appv1beta1 "sigs.k8s.io/application/api/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+func TestScopePredicates(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	selector := labels.SelectorFromSet(labels.Set{"operator": "team-a"})
+
+	owned := newTestApplication()
+	owned.Labels = map[string]string{"operator": "team-a"}
+	other := newTestApplication()
+
+	g.Expect(inScope(nil, other)).To(gomega.BeTrue())
+	g.Expect(inScope(selector, owned)).To(gomega.BeTrue())
+	g.Expect(inScope(selector, other)).To(gomega.BeFalse())
+
+	scope := scopePredicate(selector)
+	g.Expect(scope.Create(event.CreateEvent{Object: owned})).To(gomega.BeTrue())
+	g.Expect(scope.Create(event.CreateEvent{Object: other})).To(gomega.BeFalse())
+
+	entered := scopeEnteredPredicate(selector)
+	g.Expect(entered.Update(event.UpdateEvent{ObjectOld: other, ObjectNew: owned})).To(gomega.BeTrue())
+	g.Expect(entered.Update(event.UpdateEvent{ObjectOld: owned, ObjectNew: owned})).To(gomega.BeFalse())
+	g.Expect(entered.Update(event.UpdateEvent{ObjectOld: owned, ObjectNew: other})).To(gomega.BeFalse())
+}
+
+func TestReconcileOutOfScope(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	s := runtime.NewScheme()
+	g.Expect(clientgoscheme.AddToScheme(s)).To(gomega.Succeed())
+	g.Expect(appv1beta1.AddToScheme(s)).To(gomega.Succeed())
+
+	app := newTestApplication(configMapGK)
+
+	r := newTestReconciler()
+	r.Client = fake.NewClientBuilder().WithScheme(s).WithObjects(app).Build()
+	r.options.ApplicationSelector = labels.SelectorFromSet(labels.Set{"operator": "team-a"})
+
+	key := types.NamespacedName{Namespace: app.Namespace, Name: app.Name}
+
+	result, err := r.Reconcile(context.TODO(), reconcile.Request{NamespacedName: key})
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(result).To(gomega.Equal(reconcile.Result{}))
+
+	// the status of the application of another instance is left untouched
+	g.Expect(r.Get(context.TODO(), key, app)).To(gomega.Succeed())
+	g.Expect(app.Status.Conditions).To(gomega.BeEmpty())
+}
@@ -0,0 +1,116 @@
+// Copyright 2019 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package application
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog"
+	appv1beta1 "sigs.k8s.io/application/api/v1beta1"
+)
+
+const statusSinkTimeout = 5 * time.Second
+
+// StatusReport is the summary of an application status pushed to a StatusSink
+type StatusReport struct {
+	Namespace       string      `json:"namespace"`
+	Name            string      `json:"name"`
+	UID             types.UID   `json:"uid"`
+	Health          HealthState `json:"health"`
+	ComponentsReady string      `json:"componentsReady"`
+	Components      int         `json:"components"`
+	Time            metav1.Time `json:"time"`
+}
+
+// StatusSink receives the status computed by every reconcile in addition to the application CR
+type StatusSink interface {
+	Publish(ctx context.Context, report StatusReport) error
+}
+
+type httpStatusSink struct {
+	url    string
+	token  string
+	client *http.Client
+}
+
+// NewHTTPStatusSink returns a sink posting the status reports as JSON to url, the token is sent as
+// a bearer token when it is not empty
+func NewHTTPStatusSink(url, token string) StatusSink {
+	return &httpStatusSink{
+		url:    url,
+		token:  token,
+		client: &http.Client{Timeout: statusSinkTimeout},
+	}
+}
+
+func (s *httpStatusSink) Publish(ctx context.Context, report StatusReport) error {
+	body, err := json.Marshal(report)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	if s.token != "" {
+		req.Header.Set("Authorization", "Bearer "+s.token)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("status sink responded %s", resp.Status)
+	}
+
+	return nil
+}
+
+// publishStatus pushes the status to the configured sink, a sink failure never fails the reconcile
+func (r *ReconcileApplication) publishStatus(ctx context.Context, app *appv1beta1.Application,
+	status *appv1beta1.ApplicationStatus, rollup *healthRollup) {
+	if r.options.StatusSink == nil {
+		return
+	}
+
+	report := StatusReport{
+		Namespace:       app.Namespace,
+		Name:            app.Name,
+		UID:             app.UID,
+		Health:          rollup.state,
+		ComponentsReady: status.ComponentsReady,
+		Components:      rollup.total,
+		Time:            metav1.Now(),
+	}
+
+	if err := r.options.StatusSink.Publish(ctx, report); err != nil {
+		klog.Error("Failed to publish status of application ", app.Namespace+"/"+app.Name, " to the status sink, error: ", err)
+	}
+}
@@ -0,0 +1,55 @@
+// Copyright 2019 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package application
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/onsi/gomega"
+)
+
+func TestHTTPStatusSink(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	var (
+		gotAuth   string
+		gotReport StatusReport
+	)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotAuth = r.Header.Get("Authorization")
+		_ = json.NewDecoder(r.Body).Decode(&gotReport)
+	}))
+	defer srv.Close()
+
+	sink := NewHTTPStatusSink(srv.URL, "secret")
+	report := StatusReport{Namespace: "default", Name: "test-app", Health: HealthHealthy, ComponentsReady: "1/1", Components: 1}
+
+	g.Expect(sink.Publish(context.TODO(), report)).To(gomega.Succeed())
+	g.Expect(gotAuth).To(gomega.Equal("Bearer secret"))
+	g.Expect(gotReport.Name).To(gomega.Equal("test-app"))
+	g.Expect(gotReport.Health).To(gomega.Equal(HealthHealthy))
+
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer failing.Close()
+
+	g.Expect(NewHTTPStatusSink(failing.URL, "").Publish(context.TODO(), report)).NotTo(gomega.Succeed())
+}
@@ -0,0 +1,86 @@
+// Copyright 2019 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package application
+
+import (
+	"context"
+
+	"github.com/stolostron/multicloud-operators-application/pkg/apis"
+	"github.com/stolostron/multicloud-operators-application/utils"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/klog"
+	appv1beta1 "sigs.k8s.io/application/api/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func softOwnerKey(app *appv1beta1.Application) string {
+	return app.Namespace + "/" + app.Name
+}
+
+// isSoftOwned returns true if the component is stamped with the application as its soft owner
+func isSoftOwned(u metav1.Object, app *appv1beta1.Application) bool {
+	return u.GetAnnotations()[utils.AnnotationOwnerApplication] == softOwnerKey(app)
+}
+
+// withoutPredecessorOwnerRefs drops the owner references to a deleted application of the same name, the
+// application being recreated under a new uid
+func withoutPredecessorOwnerRefs(refs []metav1.OwnerReference, app *appv1beta1.Application) []metav1.OwnerReference {
+	kept := make([]metav1.OwnerReference, 0, len(refs))
+
+	for _, ref := range refs {
+		if ref.Kind == "Application" && ref.APIVersion == apis.ApplicationGroupVersion.String() &&
+			ref.Name == app.Name && ref.UID != app.UID {
+			continue
+		}
+
+		kept = append(kept, ref)
+	}
+
+	return kept
+}
+
+// setSoftOwner stamps the components missing it with the application as their soft owner, a component
+// stamped with another application is restamped. A component that fails to be patched is logged and does
+// not stop the others from being patched. The components past the write budget are left for the next reconcile.
+func (r *ReconcileApplication) setSoftOwner(ctx context.Context, app *appv1beta1.Application,
+	components []*unstructured.Unstructured, budget *writeBudget) {
+	for _, u := range components {
+		if isSoftOwned(u, app) || !budget.take() {
+			continue
+		}
+
+		gk := u.GroupVersionKind().GroupKind().String()
+
+		orig := u.DeepCopy()
+
+		annotations := u.GetAnnotations()
+		if annotations == nil {
+			annotations = map[string]string{}
+		}
+
+		annotations[utils.AnnotationOwnerApplication] = softOwnerKey(app)
+		u.SetAnnotations(annotations)
+
+		if err := r.Patch(ctx, u, client.MergeFrom(orig)); err != nil {
+			klog.Error("Failed to set soft owner application ", softOwnerKey(app), " on ",
+				gk, " ", u.GetNamespace()+"/"+u.GetName(), " error: ", err)
+
+			continue
+		}
+
+		klog.V(1).Info("Set soft owner application ", softOwnerKey(app), " on ", gk, " ", u.GetNamespace()+"/"+u.GetName())
+	}
+}
@@ -0,0 +1,67 @@
+// Copyright 2019 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package application
+
+import (
+	"context"
+	"testing"
+
+	"github.com/onsi/gomega"
+	"github.com/stolostron/multicloud-operators-application/utils"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func TestSoftOwnerReadoption(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	r := newTestReconciler(newTestConfigMap("matched", map[string]string{"app": "test-app"}))
+
+	app := newTestApplication(configMapGK)
+	app.UID = types.UID("test-app-uid")
+	app.Annotations = map[string]string{utils.AnnotationSoftOwner: "true"}
+
+	res := r.resolveComponents(context.TODO(), app)
+	g.Expect(res.components).To(gomega.HaveLen(1))
+
+	r.setOwnerRefs(context.TODO(), app, res.components, nil)
+	r.setSoftOwner(context.TODO(), app, res.components, nil)
+
+	key := types.NamespacedName{Namespace: "default", Name: "matched"}
+
+	cm := &corev1.ConfigMap{}
+	g.Expect(r.Get(context.TODO(), key, cm)).To(gomega.Succeed())
+	g.Expect(cm.Annotations).To(gomega.HaveKeyWithValue(utils.AnnotationOwnerApplication, "default/test-app"))
+
+	// the application is deleted and recreated under a new uid before the garbage collector runs
+	recreated := app.DeepCopy()
+	recreated.UID = types.UID("recreated-app-uid")
+
+	res = r.resolveComponents(context.TODO(), recreated)
+	r.setOwnerRefs(context.TODO(), recreated, res.components, nil)
+
+	g.Expect(r.Get(context.TODO(), key, cm)).To(gomega.Succeed())
+	g.Expect(cm.OwnerReferences).To(gomega.HaveLen(1))
+	g.Expect(cm.OwnerReferences[0].UID).To(gomega.Equal(recreated.UID))
+
+	// an application of the same name is not a predecessor of the components it is not the soft owner of
+	refs := []metav1.OwnerReference{applicationOwnerRef(app)}
+	other := newTestApplication(configMapGK)
+	other.Namespace = "other"
+	other.UID = types.UID("other-app-uid")
+	g.Expect(isSoftOwned(cm, other)).To(gomega.BeFalse())
+	g.Expect(withoutPredecessorOwnerRefs(refs, app)).To(gomega.HaveLen(1))
+}
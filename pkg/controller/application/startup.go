@@ -0,0 +1,187 @@
+// Copyright 2019 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package application
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync/atomic"
+	"time"
+
+	"github.com/stolostron/multicloud-operators-application/utils"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/klog"
+	appv1beta1 "sigs.k8s.io/application/api/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+)
+
+// startupListRetryPeriod is how often the initial pass retries listing the applications
+const startupListRetryPeriod = 5 * time.Second
+
+// cacheSyncer waits for the informer cache to sync, as the cache of the manager
+type cacheSyncer interface {
+	WaitForCacheSync(ctx context.Context) bool
+}
+
+// startupOrder runs the initial pass of the ordered startup. The create events of the applications replayed
+// by the informers as they sync are dropped, and the applications are enqueued once the cache is synced, the
+// children before their parents so the first rollups of the parents see reconciled children, and the
+// applications with a stale status ahead of the others so the restored applications converge first. The
+// events of the component watches still enqueue the applications in any order, the ordering is best effort.
+// The create events are let through again once the initial pass started.
+type startupOrder struct {
+	cache  cacheSyncer
+	reader client.Reader
+	events chan<- event.GenericEvent
+	// leavesFirst enqueues the children before their parents
+	leavesFirst bool
+	// staleFirst enqueues the applications with a stale status first, after the leaves first ordering
+	staleFirst bool
+	passed     int32
+}
+
+// startupEvents feeds the applications of the initial pass to the controller
+var startupEvents = make(chan event.GenericEvent, 1024)
+
+// predicate drops the create events until the initial pass started
+func (o *startupOrder) predicate() predicate.Funcs {
+	return predicate.Funcs{
+		CreateFunc: func(e event.CreateEvent) bool {
+			return atomic.LoadInt32(&o.passed) == 1
+		},
+	}
+}
+
+// Start enqueues the applications in order once the cache is synced, the create events are let through
+// before listing them so an application created meanwhile is enqueued either way
+func (o *startupOrder) Start(ctx context.Context) error {
+	if !o.cache.WaitForCacheSync(ctx) {
+		return errors.New("failed to sync the cache before the ordered startup")
+	}
+
+	atomic.StoreInt32(&o.passed, 1)
+
+	apps := &appv1beta1.ApplicationList{}
+
+	err := wait.PollImmediateUntil(startupListRetryPeriod, func() (bool, error) {
+		if err := o.reader.List(ctx, apps); err != nil {
+			klog.Error("Failed to list the applications of the ordered startup, error: ", err)
+			return false, nil
+		}
+
+		return true, nil
+	}, ctx.Done())
+	if err != nil {
+		// the poll only gives up once the manager stops
+		return nil
+	}
+
+	if o.leavesFirst {
+		leavesFirst(apps.Items)
+	}
+
+	stale := 0
+	if o.staleFirst {
+		stale = staleStatusFirst(apps.Items)
+	}
+
+	for i := range apps.Items {
+		select {
+		case o.events <- event.GenericEvent{Object: &apps.Items[i]}:
+		case <-ctx.Done():
+			return nil
+		}
+	}
+
+	klog.Info("Enqueued ", len(apps.Items), " applications for the ordered startup, ", stale, " with a stale status first")
+
+	return nil
+}
+
+// leavesFirst sorts the applications by decreasing depth in their hierarchy, then by namespace and name. The
+// depth of an application is the number of its ancestors among the applications, a cycle is cut short.
+func leavesFirst(apps []appv1beta1.Application) {
+	byName := make(map[types.NamespacedName]*appv1beta1.Application, len(apps))
+	for i := range apps {
+		byName[types.NamespacedName{Namespace: apps[i].Namespace, Name: apps[i].Name}] = &apps[i]
+	}
+
+	depths := make(map[types.NamespacedName]int, len(apps))
+
+	for key, app := range byName {
+		depth := 0
+
+		for depth < len(apps) {
+			parent, err := utils.GetParent(app)
+			if err != nil || parent == "" {
+				break
+			}
+
+			app = byName[types.NamespacedName{Namespace: app.Namespace, Name: parent}]
+			if app == nil {
+				break
+			}
+
+			depth++
+		}
+
+		depths[key] = depth
+	}
+
+	depthOf := func(app *appv1beta1.Application) int {
+		return depths[types.NamespacedName{Namespace: app.Namespace, Name: app.Name}]
+	}
+
+	sort.SliceStable(apps, func(i, j int) bool {
+		if di, dj := depthOf(&apps[i]), depthOf(&apps[j]); di != dj {
+			return di > dj
+		}
+
+		if apps[i].Namespace != apps[j].Namespace {
+			return apps[i].Namespace < apps[j].Namespace
+		}
+
+		return apps[i].Name < apps[j].Name
+	})
+}
+
+// hasStaleStatus returns true if the status of the application was not written for its current generation, as
+// the applications restored from a backup. Their status is empty, or observed a generation of the original
+// object, possibly ahead of the generation the restored object restarted from.
+func hasStaleStatus(app *appv1beta1.Application) bool {
+	return len(app.Status.Conditions) == 0 || app.Status.ObservedGeneration != app.Generation
+}
+
+// staleStatusFirst moves the applications with a stale status ahead of the others, keeping their order. It
+// returns the number of applications with a stale status.
+func staleStatusFirst(apps []appv1beta1.Application) int {
+	sort.SliceStable(apps, func(i, j int) bool {
+		return hasStaleStatus(&apps[i]) && !hasStaleStatus(&apps[j])
+	})
+
+	stale := 0
+
+	for i := range apps {
+		if hasStaleStatus(&apps[i]) {
+			stale++
+		}
+	}
+
+	return stale
+}
@@ -0,0 +1,123 @@
+// Copyright 2019 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package application
+
+import (
+	"context"
+	"testing"
+
+	"github.com/onsi/gomega"
+	"github.com/stolostron/multicloud-operators-application/utils"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	appv1beta1 "sigs.k8s.io/application/api/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+)
+
+type syncedCache struct{}
+
+func (syncedCache) WaitForCacheSync(ctx context.Context) bool { return true }
+
+func newTestChildApplication(name, parent string) *appv1beta1.Application {
+	app := &appv1beta1.Application{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"}}
+	if parent != "" {
+		app.Annotations = map[string]string{utils.AnnotationParent: parent}
+	}
+
+	return app
+}
+
+func TestLeavesFirst(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	apps := []appv1beta1.Application{
+		*newTestChildApplication("a-parent", ""),
+		*newTestChildApplication("b-standalone", ""),
+		*newTestChildApplication("c-child", "a-parent"),
+		*newTestChildApplication("d-orphan", "missing"),
+		*newTestChildApplication("e-grandchild", "c-child"),
+		*newTestChildApplication("f-cycle", "g-cycle"),
+		*newTestChildApplication("g-cycle", "f-cycle"),
+	}
+
+	leavesFirst(apps)
+
+	names := make([]string, len(apps))
+	for i := range apps {
+		names[i] = apps[i].Name
+	}
+
+	g.Expect(names).To(gomega.Equal([]string{"f-cycle", "g-cycle", "e-grandchild", "c-child", "a-parent",
+		"b-standalone", "d-orphan"}))
+}
+
+func TestStartupOrder(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	s := runtime.NewScheme()
+	g.Expect(clientgoscheme.AddToScheme(s)).To(gomega.Succeed())
+	g.Expect(appv1beta1.AddToScheme(s)).To(gomega.Succeed())
+
+	c := fake.NewClientBuilder().WithScheme(s).WithObjects(
+		newTestChildApplication("parent", ""),
+		newTestChildApplication("child", "parent"),
+	).Build()
+
+	events := make(chan event.GenericEvent, 2)
+	order := &startupOrder{cache: syncedCache{}, reader: c, events: events, leavesFirst: true}
+	p := order.predicate()
+
+	create := event.CreateEvent{Object: newTestChildApplication("parent", "")}
+	g.Expect(p.Create(create)).To(gomega.BeFalse())
+	g.Expect(p.Update(event.UpdateEvent{ObjectOld: create.Object, ObjectNew: create.Object})).To(gomega.BeTrue())
+
+	g.Expect(order.Start(context.TODO())).To(gomega.Succeed())
+	g.Expect(p.Create(create)).To(gomega.BeTrue())
+
+	g.Expect((<-events).Object.GetName()).To(gomega.Equal("child"))
+	g.Expect((<-events).Object.GetName()).To(gomega.Equal("parent"))
+}
+
+func TestStaleStatusFirst(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	converged := func(name string, generation, observed int64) appv1beta1.Application {
+		app := newTestChildApplication(name, "")
+		app.Generation = generation
+		app.Status.ObservedGeneration = observed
+		app.Status.Conditions = []appv1beta1.Condition{{Type: appv1beta1.Ready}}
+
+		return *app
+	}
+
+	apps := []appv1beta1.Application{
+		converged("a-current", 2, 2),
+		*newTestChildApplication("b-empty", ""),
+		converged("c-behind", 3, 1),
+		converged("d-current", 1, 1),
+		converged("e-restored", 1, 7),
+	}
+
+	g.Expect(staleStatusFirst(apps)).To(gomega.Equal(3))
+
+	names := make([]string, len(apps))
+	for i := range apps {
+		names[i] = apps[i].Name
+	}
+
+	g.Expect(names).To(gomega.Equal([]string{"b-empty", "c-behind", "e-restored", "a-current", "d-current"}))
+}
@@ -0,0 +1,233 @@
+// Copyright 2019 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package application
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/stolostron/multicloud-operators-application/utils"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/klog"
+	appv1beta1 "sigs.k8s.io/application/api/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// selectorSuggestionMaxKinds bounds the component kinds scanned for the labels of the candidates
+	selectorSuggestionMaxKinds = 10
+	// selectorSuggestionMaxObjects bounds the candidates listed per kind
+	selectorSuggestionMaxObjects = 200
+	// selectorSuggestionMaxDistance is the largest edit distance of a suggested label key or value
+	selectorSuggestionMaxDistance = 2
+	// selectorSuggestionsReason is the reason of the event carrying the suggestions
+	selectorSuggestionsReason = "SelectorSuggestions"
+)
+
+// recordSelectorSuggestions records the suggestions of suggestSelectorFixes in an event when the selector of the
+// application resolved no component without any failure
+func (r *ReconcileApplication) recordSelectorSuggestions(ctx context.Context, app *appv1beta1.Application,
+	res *componentResolution) {
+	if len(res.components) > 0 || len(res.children) > 0 || len(res.failures) > 0 || res.selectorErr != nil || len(res.suspended) > 0 {
+		return
+	}
+
+	// the scan would read as the operator what the ServiceAccount the application is resolved as may not read
+	if res.reader != nil {
+		return
+	}
+
+	// the components listed in a ConfigMap are not selected
+	if _, found, _ := utils.GetComponentsConfigMap(app); found {
+		return
+	}
+
+	suggestions := r.suggestSelectorFixes(ctx, app)
+	if len(suggestions) == 0 {
+		return
+	}
+
+	r.eventRecorder.RecordEvent(app, selectorSuggestionsReason, "No component matches the selector: "+
+		strings.Join(suggestions, " "), nil)
+}
+
+// suggestSelectorFixes looks for the likely typos of spec.selector when it matches no component. It scans a
+// bounded number of the resources of the component kinds in their namespace, and suggests the
+// closest label key to the keys no candidate carries, and the closest value to the match labels values no
+// candidate carries. The suggestions are advisory, a failing kind is left out of the scan.
+func (r *ReconcileApplication) suggestSelectorFixes(ctx context.Context, app *appv1beta1.Application) []string {
+	sel := app.Spec.Selector
+	if sel == nil || (len(sel.MatchLabels) == 0 && len(sel.MatchExpressions) == 0) {
+		return nil
+	}
+
+	namespaces, err := utils.GetComponentNamespaces(app)
+	if err != nil {
+		return nil
+	}
+
+	candidates := map[string]map[string]bool{}
+
+	for i, gk := range app.Spec.ComponentGroupKinds {
+		if i == selectorSuggestionMaxKinds {
+			break
+		}
+
+		ns, ok := namespaces[normalizedGroupKind(gk)]
+		if !ok {
+			ns = app.Namespace
+		}
+
+		if excluded, err := r.options.ExcludedNamespaces.excludes(ctx, r, ns); err != nil || excluded {
+			continue
+		}
+
+		mapping, err := r.mapper.RESTMapping(schema.GroupKind{Group: appv1beta1.StripVersion(gk.Group), Kind: gk.Kind})
+		if err != nil {
+			continue
+		}
+
+		list := &unstructured.UnstructuredList{}
+		list.SetGroupVersionKind(mapping.GroupVersionKind)
+
+		if err := r.apiReader.List(ctx, list, client.InNamespace(ns), client.Limit(selectorSuggestionMaxObjects)); err != nil {
+			klog.V(1).Info("Failed to scan the kind ", gk.String(), " for selector suggestions of application ",
+				app.Namespace+"/"+app.Name, " error: ", err)
+
+			continue
+		}
+
+		for j := range list.Items {
+			for k, v := range list.Items[j].GetLabels() {
+				if candidates[k] == nil {
+					candidates[k] = map[string]bool{}
+				}
+
+				candidates[k][v] = true
+			}
+		}
+	}
+
+	if len(candidates) == 0 {
+		return nil
+	}
+
+	keys := make(map[string]bool, len(sel.MatchLabels)+len(sel.MatchExpressions))
+	for k := range sel.MatchLabels {
+		keys[k] = true
+	}
+
+	for _, req := range sel.MatchExpressions {
+		keys[req.Key] = true
+	}
+
+	candidateKeys := make(map[string]bool, len(candidates))
+	for k := range candidates {
+		candidateKeys[k] = true
+	}
+
+	var suggestions []string
+
+	for _, key := range sortedKeys(keys) {
+		values, found := candidates[key]
+		if !found {
+			if closest := closestString(key, sortedKeys(candidateKeys)); closest != "" {
+				suggestions = append(suggestions, fmt.Sprintf("label key %q matches no component, did you mean %q?", key, closest))
+			}
+
+			continue
+		}
+
+		value, matching := sel.MatchLabels[key]
+		if !matching || values[value] {
+			continue
+		}
+
+		if closest := closestString(value, sortedKeys(values)); closest != "" {
+			suggestions = append(suggestions, fmt.Sprintf("label %s=%s matches no component, did you mean %s=%s?",
+				key, value, key, closest))
+		}
+	}
+
+	return suggestions
+}
+
+// sortedKeys returns the keys of the set in order, for the suggestions to be stable
+func sortedKeys(m map[string]bool) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
+
+// closestString returns the candidate with the smallest edit distance to s within the maximum distance, the
+// first in order on a tie, empty if none is close enough
+func closestString(s string, candidates []string) string {
+	closest, best := "", selectorSuggestionMaxDistance+1
+
+	for _, c := range candidates {
+		// a candidate as short as the distance is close to anything
+		if len(c) <= selectorSuggestionMaxDistance {
+			continue
+		}
+
+		if d := editDistance(s, c); d > 0 && d < best {
+			closest, best = c, d
+		}
+	}
+
+	return closest
+}
+
+// editDistance is the Levenshtein distance between the strings
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+
+			curr[j] = prev[j-1] + cost
+			if prev[j]+1 < curr[j] {
+				curr[j] = prev[j] + 1
+			}
+
+			if curr[j-1]+1 < curr[j] {
+				curr[j] = curr[j-1] + 1
+			}
+		}
+
+		prev, curr = curr, prev
+	}
+
+	return prev[len(b)]
+}
@@ -0,0 +1,61 @@
+// Copyright 2019 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package application
+
+import (
+	"context"
+	"testing"
+
+	"github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestEditDistance(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	g.Expect(editDistance("frontend", "frontend")).To(gomega.Equal(0))
+	g.Expect(editDistance("frontnd", "frontend")).To(gomega.Equal(1))
+	g.Expect(editDistance("ap", "app")).To(gomega.Equal(1))
+	g.Expect(editDistance("kitten", "sitting")).To(gomega.Equal(3))
+	g.Expect(editDistance("", "abc")).To(gomega.Equal(3))
+}
+
+func TestSuggestSelectorFixes(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	r := newTestReconciler(
+		newTestConfigMap("web", map[string]string{"app.kubernetes.io/name": "frontend", "tier": "web"}),
+		newTestConfigMap("db", map[string]string{"app.kubernetes.io/name": "database", "tier": "db"}),
+	)
+
+	app := newTestApplication(configMapGK)
+	app.Spec.Selector = &metav1.LabelSelector{
+		MatchLabels: map[string]string{"app.kubernetes.io/nmae": "frontend", "tier": "wbe"},
+	}
+
+	g.Expect(r.suggestSelectorFixes(context.TODO(), app)).To(gomega.Equal([]string{
+		`label key "app.kubernetes.io/nmae" matches no component, did you mean "app.kubernetes.io/name"?`,
+		`label tier=wbe matches no component, did you mean tier=web?`,
+	}))
+
+	// nothing close enough to suggest
+	app.Spec.Selector.MatchLabels = map[string]string{"team": "payments"}
+	g.Expect(r.suggestSelectorFixes(context.TODO(), app)).To(gomega.BeEmpty())
+
+	app.Spec.ComponentGroupKinds = append(app.Spec.ComponentGroupKinds, unknownGK)
+	app.Spec.Selector.MatchLabels = map[string]string{"app.kubernetes.io/name": "frontent"}
+	g.Expect(r.suggestSelectorFixes(context.TODO(), app)).To(gomega.ConsistOf(
+		`label app.kubernetes.io/name=frontent matches no component, did you mean app.kubernetes.io/name=frontend?`))
+}
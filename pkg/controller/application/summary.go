@@ -0,0 +1,115 @@
+// Copyright 2019 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package application
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog"
+)
+
+// The phases of a reconcile timed by the reconcile summary
+const (
+	phaseResolve = "resolve"
+	phasePatch   = "patch"
+	phaseHealth  = "health"
+	phaseStatus  = "status"
+)
+
+// reconcileSummary collects the phase durations and counts of a reconcile, logged as one line once it returns
+type reconcileSummary struct {
+	start      time.Time
+	phases     []string
+	durations  map[string]time.Duration
+	components int
+	failures   int
+	// partial is the error of the components that failed to resolve while the reconcile went on
+	partial error
+}
+
+func newReconcileSummary() *reconcileSummary {
+	return &reconcileSummary{start: time.Now(), durations: map[string]time.Duration{}}
+}
+
+// time starts timing the phase, the returned func ends it. A phase timed more than once adds up.
+func (s *reconcileSummary) time(phase string) func() {
+	start := time.Now()
+
+	return func() {
+		if _, ok := s.durations[phase]; !ok {
+			s.phases = append(s.phases, phase)
+		}
+
+		s.durations[phase] += time.Since(start)
+	}
+}
+
+// fields returns the key value pairs of the summary, the phases in the order they ran
+func (s *reconcileSummary) fields(key types.NamespacedName, err error) []interface{} {
+	fields := []interface{}{"application", key.String(), "totalSeconds", time.Since(s.start).Seconds()}
+
+	for _, phase := range s.phases {
+		fields = append(fields, phase+"Seconds", s.durations[phase].Seconds())
+	}
+
+	fields = append(fields, "components", s.components, "failures", s.failures)
+
+	if err == nil {
+		err = s.partial
+	}
+
+	if err != nil {
+		fields = append(fields, "error", err.Error())
+	}
+
+	return fields
+}
+
+// log writes the summary at V(1) as logfmt key value pairs, so the fields are extracted without parsing a message
+func (s *reconcileSummary) log(key types.NamespacedName, err error) {
+	if !klog.V(1) {
+		return
+	}
+
+	klog.Info(formatFields("Reconcile summary", s.fields(key, err)...))
+}
+
+// formatFields renders the message and the key value pairs as klog structured logging does, strings quoted
+func formatFields(msg string, kv ...interface{}) string {
+	var sb strings.Builder
+
+	sb.WriteString(strconv.Quote(msg))
+
+	for i := 0; i+1 < len(kv); i += 2 {
+		sb.WriteString(" ")
+		sb.WriteString(fmt.Sprint(kv[i]))
+		sb.WriteString("=")
+
+		switch v := kv[i+1].(type) {
+		case string:
+			sb.WriteString(strconv.Quote(v))
+		case float64:
+			sb.WriteString(strconv.FormatFloat(v, 'f', -1, 64))
+		default:
+			sb.WriteString(fmt.Sprint(v))
+		}
+	}
+
+	return sb.String()
+}
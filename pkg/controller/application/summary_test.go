@@ -0,0 +1,56 @@
+// Copyright 2019 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package application
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func TestReconcileSummary(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	key := types.NamespacedName{Namespace: "default", Name: "test-app"}
+
+	summary := newReconcileSummary()
+	summary.time(phaseResolve)()
+	summary.time(phasePatch)()
+	summary.time(phaseResolve)()
+	summary.components = 3
+
+	fields := summary.fields(key, nil)
+	g.Expect(fields).To(gomega.HaveLen(12))
+
+	keys := []interface{}{}
+	for i := 0; i < len(fields); i += 2 {
+		keys = append(keys, fields[i])
+	}
+
+	g.Expect(keys).To(gomega.Equal([]interface{}{
+		"application", "totalSeconds", "resolveSeconds", "patchSeconds", "components", "failures",
+	}))
+
+	// a partial failure is tagged, the error returned by the reconcile takes precedence
+	summary.failures = 1
+	summary.partial = fmt.Errorf("failed to resolve components of kinds ConfigMap: forbidden")
+	g.Expect(summary.fields(key, nil)).To(gomega.ContainElements("error", "failed to resolve components of kinds ConfigMap: forbidden"))
+	g.Expect(summary.fields(key, fmt.Errorf("conflict"))).To(gomega.ContainElements("error", "conflict"))
+
+	g.Expect(formatFields("Reconcile summary", "application", "default/test-app", "totalSeconds", 0.25, "components", 3)).
+		To(gomega.Equal(`"Reconcile summary" application="default/test-app" totalSeconds=0.25 components=3`))
+}
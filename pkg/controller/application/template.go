@@ -0,0 +1,312 @@
+// Copyright 2019 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package application
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/stolostron/multicloud-operators-application/utils"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/klog"
+	appv1beta1 "sigs.k8s.io/application/api/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// templateCleanupFinalizer holds the template deletion until its copies are deleted, the copies live in
+// other namespaces and cannot be garbage collected through owner references
+const templateCleanupFinalizer = "apps.open-cluster-management.io/template-cleanup"
+
+// templateAnnotations are not copied, they are either specific to the template or computed per copy
+var templateAnnotations = []string{
+	utils.AnnotationTemplate,
+	utils.AnnotationTemplateNamespaceSelector,
+	utils.AnnotationTemplateCleanup,
+	"apps.open-cluster-management.io/subscriptions",
+	"apps.open-cluster-management.io/deployables",
+	utils.AnnotationLastReconciledBy,
+	utils.AnnotationRebuildStatus,
+	utils.AnnotationReconcileHistory,
+	"kubectl.kubernetes.io/last-applied-configuration",
+}
+
+func templateCopyLabels(tmpl *appv1beta1.Application) client.MatchingLabels {
+	return client.MatchingLabels{
+		utils.LabelTemplateName:      tmpl.Name,
+		utils.LabelTemplateNamespace: tmpl.Namespace,
+	}
+}
+
+func isTemplateCopyOf(app, tmpl *appv1beta1.Application) bool {
+	return app.Labels[utils.LabelTemplateName] == tmpl.Name && app.Labels[utils.LabelTemplateNamespace] == tmpl.Namespace
+}
+
+// newTemplateCopy returns the application the template materializes into namespace
+func newTemplateCopy(tmpl *appv1beta1.Application, namespace string) *appv1beta1.Application {
+	app := &appv1beta1.Application{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        tmpl.Name,
+			Namespace:   namespace,
+			Labels:      map[string]string{},
+			Annotations: map[string]string{},
+		},
+		Spec: *tmpl.Spec.DeepCopy(),
+	}
+
+	for k, v := range tmpl.Labels {
+		app.Labels[k] = v
+	}
+
+	for k, v := range templateCopyLabels(tmpl) {
+		app.Labels[k] = v
+	}
+
+	for k, v := range tmpl.Annotations {
+		app.Annotations[k] = v
+	}
+
+	for _, k := range templateAnnotations {
+		delete(app.Annotations, k)
+	}
+
+	return app
+}
+
+// syncTemplateCopy creates or updates the copy of the template in namespace, an application of the same
+// name that is not a copy of the template is left alone
+func (r *ReconcileApplication) syncTemplateCopy(ctx context.Context, tmpl *appv1beta1.Application, namespace string) error {
+	desired := newTemplateCopy(tmpl, namespace)
+
+	existing := &appv1beta1.Application{}
+
+	err := r.Get(ctx, types.NamespacedName{Namespace: namespace, Name: tmpl.Name}, existing)
+	if errors.IsNotFound(err) {
+		klog.Info("Creating copy of template application ", tmpl.Namespace+"/"+tmpl.Name, " in namespace ", namespace)
+
+		return r.Create(ctx, desired)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if !isTemplateCopyOf(existing, tmpl) {
+		return fmt.Errorf("application %s/%s exists and is not a copy of the template", namespace, tmpl.Name)
+	}
+
+	// the annotations of the copy computed by its own reconcile are kept
+	for _, k := range templateAnnotations {
+		if v, ok := existing.Annotations[k]; ok {
+			desired.Annotations[k] = v
+		}
+	}
+
+	if equality.Semantic.DeepEqual(existing.Spec, desired.Spec) &&
+		equality.Semantic.DeepEqual(existing.Labels, desired.Labels) &&
+		equality.Semantic.DeepEqual(existing.Annotations, desired.Annotations) {
+		return nil
+	}
+
+	existing.Spec = desired.Spec
+	existing.Labels = desired.Labels
+	existing.Annotations = desired.Annotations
+
+	klog.Info("Updating copy of template application ", tmpl.Namespace+"/"+tmpl.Name, " in namespace ", namespace)
+
+	return r.Update(ctx, existing)
+}
+
+// listTemplateCopies returns the copies of the template in every namespace
+func (r *ReconcileApplication) listTemplateCopies(ctx context.Context, tmpl *appv1beta1.Application) ([]appv1beta1.Application, error) {
+	copies := &appv1beta1.ApplicationList{}
+	if err := r.List(ctx, copies, templateCopyLabels(tmpl)); err != nil {
+		return nil, err
+	}
+
+	return copies.Items, nil
+}
+
+// reconcileTemplate materializes the template into the namespaces matching its namespace selector and
+// deletes the copies from the namespaces no longer matching. The terminating namespaces are skipped, their
+// copies are neither updated nor deleted and go away with the namespace, and returned sorted.
+func (r *ReconcileApplication) reconcileTemplate(ctx context.Context, tmpl *appv1beta1.Application) ([]string, error) {
+	selector, err := utils.GetTemplateNamespaceSelector(tmpl)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := r.updateTemplateFinalizer(ctx, tmpl); err != nil {
+		return nil, err
+	}
+
+	nsList := &corev1.NamespaceList{}
+	if err := r.List(ctx, nsList, client.MatchingLabelsSelector{Selector: selector}); err != nil {
+		return nil, err
+	}
+
+	selected := make(map[string]bool)
+
+	var (
+		terminating []string
+		errs        []error
+	)
+
+	for _, ns := range nsList.Items {
+		if ns.Name == tmpl.Namespace {
+			continue
+		}
+
+		if ns.DeletionTimestamp != nil {
+			// the copy is kept rather than churned while the namespace drains
+			selected[ns.Name] = true
+			terminating = append(terminating, ns.Name)
+
+			continue
+		}
+
+		selected[ns.Name] = true
+
+		if err := r.syncTemplateCopy(ctx, tmpl, ns.Name); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	sort.Strings(terminating)
+
+	copies, err := r.listTemplateCopies(ctx, tmpl)
+	if err != nil {
+		return terminating, utilerrors.NewAggregate(append(errs, err))
+	}
+
+	for i := range copies {
+		if selected[copies[i].Namespace] {
+			continue
+		}
+
+		klog.Info("Deleting copy of template application ", tmpl.Namespace+"/"+tmpl.Name, " from namespace ", copies[i].Namespace)
+
+		if err := r.Delete(ctx, &copies[i]); err != nil && !errors.IsNotFound(err) {
+			errs = append(errs, err)
+		}
+	}
+
+	return terminating, utilerrors.NewAggregate(errs)
+}
+
+// updateTerminatingNamespacesCondition reports the terminating namespaces the template was not materialized into
+func updateTerminatingNamespacesCondition(status *appv1beta1.ApplicationStatus, terminating []string) {
+	if len(terminating) == 0 {
+		clearCondition(status, TerminatingNamespacesSkipped, "NoTerminatingNamespace",
+			"no namespace selected by the template is terminating")
+		return
+	}
+
+	setCondition(status, TerminatingNamespacesSkipped, corev1.ConditionTrue, "NamespacesTerminating",
+		"the template skips the terminating namespaces "+strings.Join(terminating, ", "))
+}
+
+// updateTemplateFinalizer adds the cleanup finalizer to the templates asking for their copies to be deleted
+// with them, and removes it from the others
+func (r *ReconcileApplication) updateTemplateFinalizer(ctx context.Context, tmpl *appv1beta1.Application) error {
+	cleanup := tmpl.GetAnnotations()[utils.AnnotationTemplateCleanup] == "true"
+
+	if cleanup == controllerutil.ContainsFinalizer(tmpl, templateCleanupFinalizer) {
+		return nil
+	}
+
+	if cleanup {
+		controllerutil.AddFinalizer(tmpl, templateCleanupFinalizer)
+	} else {
+		controllerutil.RemoveFinalizer(tmpl, templateCleanupFinalizer)
+	}
+
+	return r.Update(ctx, tmpl)
+}
+
+// finalizeTemplate deletes the copies of a template being deleted and releases its cleanup finalizer, the
+// copies are kept in read-only mode
+func (r *ReconcileApplication) finalizeTemplate(ctx context.Context, tmpl *appv1beta1.Application) (reconcile.Result, error) {
+	if !controllerutil.ContainsFinalizer(tmpl, templateCleanupFinalizer) {
+		return reconcile.Result{}, nil
+	}
+
+	var copies []appv1beta1.Application
+
+	if !r.options.ReadOnly {
+		var err error
+
+		copies, err = r.listTemplateCopies(ctx, tmpl)
+		if err != nil {
+			return reconcile.Result{}, err
+		}
+	}
+
+	for i := range copies {
+		klog.Info("Deleting copy of template application ", tmpl.Namespace+"/"+tmpl.Name, " from namespace ", copies[i].Namespace)
+
+		if err := r.Delete(ctx, &copies[i]); err != nil && !errors.IsNotFound(err) {
+			return reconcile.Result{}, err
+		}
+	}
+
+	controllerutil.RemoveFinalizer(tmpl, templateCleanupFinalizer)
+
+	return reconcile.Result{}, r.Update(ctx, tmpl)
+}
+
+type templateMapper struct {
+	client.Client
+}
+
+// Map enqueues every template application when a namespace is created or relabeled
+func (mapper *templateMapper) Map(obj client.Object) []reconcile.Request {
+	var requests []reconcile.Request
+
+	applicationList := &appv1beta1.ApplicationList{}
+
+	err := mapper.List(context.TODO(), applicationList)
+	if err != nil {
+		klog.Error("Failed to list all application objects. ", "error: ", err)
+		return requests
+	}
+
+	for _, app := range applicationList.Items {
+		app := app
+		if utils.IsTemplate(&app) {
+			requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Name: app.Name, Namespace: app.Namespace}})
+		}
+	}
+
+	return requests
+}
+
+// mapTemplateCopy enqueues the template of a copy so changes made to the copy are reverted
+func mapTemplateCopy(obj client.Object) []reconcile.Request {
+	name, ok := obj.GetLabels()[utils.LabelTemplateName]
+	if !ok {
+		return nil
+	}
+
+	return []reconcile.Request{{NamespacedName: types.NamespacedName{Name: name, Namespace: obj.GetLabels()[utils.LabelTemplateNamespace]}}}
+}
@@ -0,0 +1,137 @@
+// Copyright 2019 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package application
+
+import (
+	"context"
+	"testing"
+
+	"github.com/onsi/gomega"
+	"github.com/stolostron/multicloud-operators-application/utils"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	appv1beta1 "sigs.k8s.io/application/api/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func newTestNamespace(name string, labels map[string]string) *corev1.Namespace {
+	return &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: name, Labels: labels}}
+}
+
+func TestReconcileTemplate(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	s := runtime.NewScheme()
+	g.Expect(clientgoscheme.AddToScheme(s)).To(gomega.Succeed())
+	g.Expect(appv1beta1.AddToScheme(s)).To(gomega.Succeed())
+
+	tmpl := newTestApplication(configMapGK)
+	tmpl.Annotations = map[string]string{
+		utils.AnnotationTemplate:                  "true",
+		utils.AnnotationTemplateNamespaceSelector: "team",
+		utils.AnnotationTemplateCleanup:           "true",
+		utils.AnnotationRequiredComponents:        `[{"kind":"ConfigMap","name":"settings"}]`,
+	}
+
+	stale := newTemplateCopy(tmpl, "team-old")
+
+	c := fake.NewClientBuilder().WithScheme(s).WithObjects(
+		tmpl,
+		stale,
+		newTestNamespace("default", map[string]string{"team": "platform"}),
+		newTestNamespace("team-a", map[string]string{"team": "a"}),
+		newTestNamespace("team-old", nil),
+		newTestNamespace("other", nil),
+	).Build()
+
+	r := &ReconcileApplication{Client: c, apiReader: c, scheme: s}
+
+	terminating, err := r.reconcileTemplate(context.TODO(), tmpl)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(terminating).To(gomega.BeEmpty())
+
+	copies := &appv1beta1.ApplicationList{}
+	g.Expect(c.List(context.TODO(), copies, templateCopyLabels(tmpl))).To(gomega.Succeed())
+	g.Expect(copies.Items).To(gomega.HaveLen(1))
+
+	copied := copies.Items[0]
+	g.Expect(copied.Namespace).To(gomega.Equal("team-a"))
+	g.Expect(copied.Spec.ComponentGroupKinds).To(gomega.Equal(tmpl.Spec.ComponentGroupKinds))
+	g.Expect(copied.Annotations).To(gomega.HaveKey(utils.AnnotationRequiredComponents))
+	g.Expect(copied.Annotations).NotTo(gomega.HaveKey(utils.AnnotationTemplate))
+
+	updated := &appv1beta1.Application{}
+	g.Expect(c.Get(context.TODO(), types.NamespacedName{Namespace: "default", Name: "test-app"}, updated)).To(gomega.Succeed())
+	g.Expect(updated.Finalizers).To(gomega.ContainElement(templateCleanupFinalizer))
+
+	_, err = r.finalizeTemplate(context.TODO(), updated)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(c.List(context.TODO(), copies, templateCopyLabels(tmpl))).To(gomega.Succeed())
+	g.Expect(copies.Items).To(gomega.BeEmpty())
+}
+
+func TestReconcileTemplateTerminatingNamespace(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	s := runtime.NewScheme()
+	g.Expect(clientgoscheme.AddToScheme(s)).To(gomega.Succeed())
+	g.Expect(appv1beta1.AddToScheme(s)).To(gomega.Succeed())
+
+	tmpl := newTestApplication(configMapGK)
+	tmpl.Annotations = map[string]string{
+		utils.AnnotationTemplate:                  "true",
+		utils.AnnotationTemplateNamespaceSelector: "team",
+	}
+
+	draining := newTestNamespace("team-b", map[string]string{"team": "b"})
+	now := metav1.Now()
+	draining.DeletionTimestamp = &now
+	draining.Finalizers = []string{"kubernetes"}
+
+	// the copy in the draining namespace predates a template change
+	stale := newTemplateCopy(tmpl, "team-b")
+	stale.Spec.ComponentGroupKinds = nil
+
+	c := fake.NewClientBuilder().WithScheme(s).WithObjects(
+		tmpl,
+		stale,
+		newTestNamespace("team-a", map[string]string{"team": "a"}),
+		draining,
+	).Build()
+
+	r := &ReconcileApplication{Client: c, apiReader: c, scheme: s}
+
+	terminating, err := r.reconcileTemplate(context.TODO(), tmpl)
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(terminating).To(gomega.Equal([]string{"team-b"}))
+
+	kept := &appv1beta1.Application{}
+	g.Expect(c.Get(context.TODO(), types.NamespacedName{Namespace: "team-b", Name: tmpl.Name}, kept)).To(gomega.Succeed())
+	g.Expect(kept.Spec.ComponentGroupKinds).To(gomega.BeEmpty())
+
+	g.Expect(c.Get(context.TODO(), types.NamespacedName{Namespace: "team-a", Name: tmpl.Name}, kept)).To(gomega.Succeed())
+
+	updateTerminatingNamespacesCondition(&tmpl.Status, terminating)
+	cond := getCondition(&tmpl.Status, TerminatingNamespacesSkipped)
+	g.Expect(cond).NotTo(gomega.BeNil())
+	g.Expect(cond.Status).To(gomega.Equal(corev1.ConditionTrue))
+	g.Expect(cond.Message).To(gomega.ContainSubstring("team-b"))
+
+	updateTerminatingNamespacesCondition(&tmpl.Status, nil)
+	g.Expect(getCondition(&tmpl.Status, TerminatingNamespacesSkipped).Status).To(gomega.Equal(corev1.ConditionFalse))
+}
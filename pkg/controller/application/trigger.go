@@ -0,0 +1,119 @@
+// Copyright 2019 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package application
+
+import (
+	"context"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/klog"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// triggerMapper enqueues the applications listed in the trigger ConfigMap, every entry holds one application
+// per line, as namespace/name or as the name of an application in the namespace of the ConfigMap. The entries
+// are removed once their applications are enqueued, the update clearing them triggers no reconcile.
+type triggerMapper struct {
+	// reader reads the ConfigMap from the API server, only the metadata of the ConfigMaps is cached
+	reader client.Reader
+	writer client.Writer
+	key    types.NamespacedName
+}
+
+// triggerPredicate only lets the events of the trigger ConfigMap through, its deletion enqueues nothing
+func triggerPredicate(key types.NamespacedName) predicate.Funcs {
+	isTrigger := func(obj client.Object) bool {
+		return obj.GetNamespace() == key.Namespace && obj.GetName() == key.Name
+	}
+
+	return predicate.Funcs{
+		CreateFunc:  func(e event.CreateEvent) bool { return isTrigger(e.Object) },
+		UpdateFunc:  func(e event.UpdateEvent) bool { return isTrigger(e.ObjectNew) },
+		DeleteFunc:  func(e event.DeleteEvent) bool { return false },
+		GenericFunc: func(e event.GenericEvent) bool { return isTrigger(e.Object) },
+	}
+}
+
+func (mapper *triggerMapper) Map(obj client.Object) []reconcile.Request {
+	cm := &corev1.ConfigMap{}
+	if err := mapper.reader.Get(context.TODO(), mapper.key, cm); err != nil {
+		klog.Error("Failed to get the trigger configmap ", mapper.key, " error: ", err)
+		return nil
+	}
+
+	if len(cm.Data) == 0 {
+		return nil
+	}
+
+	apps := triggeredApplications(cm)
+
+	requests := make([]reconcile.Request, 0, len(apps))
+	for _, app := range apps {
+		requests = append(requests, reconcile.Request{NamespacedName: app})
+	}
+
+	// the resource version guards the entries added since the ConfigMap was read, a conflict is followed by
+	// the event of the newer ConfigMap and its entries are enqueued again
+	cm.Data = nil
+	if err := mapper.writer.Update(context.TODO(), cm); err != nil {
+		klog.Error("Failed to clear the processed entries of the trigger configmap ", mapper.key, " error: ", err)
+	}
+
+	klog.Info("Enqueued ", len(requests), " applications listed in the trigger configmap ", mapper.key)
+
+	return requests
+}
+
+// triggeredApplications returns the applications listed in the entries of the trigger ConfigMap, the invalid
+// lines are logged and skipped
+func triggeredApplications(cm *corev1.ConfigMap) []types.NamespacedName {
+	seen := map[types.NamespacedName]bool{}
+
+	var apps []types.NamespacedName
+
+	for key, value := range cm.Data {
+		for _, line := range strings.Split(value, "\n") {
+			line = strings.TrimSpace(line)
+			if line == "" {
+				continue
+			}
+
+			app := types.NamespacedName{Namespace: cm.Namespace, Name: line}
+			if i := strings.Index(line, "/"); i >= 0 {
+				app = types.NamespacedName{Namespace: line[:i], Name: line[i+1:]}
+			}
+
+			if len(validation.IsDNS1123Label(app.Namespace)) > 0 || len(validation.IsDNS1123Subdomain(app.Name)) > 0 {
+				klog.Warning("Skipped the invalid application ", line, " in entry ", key, " of the trigger configmap ",
+					cm.Namespace+"/"+cm.Name)
+
+				continue
+			}
+
+			if !seen[app] {
+				seen[app] = true
+				apps = append(apps, app)
+			}
+		}
+	}
+
+	return apps
+}
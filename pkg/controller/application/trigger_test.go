@@ -0,0 +1,62 @@
+// Copyright 2019 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package application
+
+import (
+	"context"
+	"testing"
+
+	"github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+func TestTriggerMapper(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	key := types.NamespacedName{Namespace: "operator", Name: "app-trigger"}
+	trigger := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Namespace: key.Namespace, Name: key.Name},
+		Data: map[string]string{
+			"kick": "default/web\n  default/db  \n\nlocal\ndefault/web\n",
+			"bad":  "Not_Valid/app",
+		},
+	}
+
+	c := fake.NewClientBuilder().WithObjects(trigger).Build()
+	mapper := &triggerMapper{reader: c, writer: c, key: key}
+
+	g.Expect(mapper.Map(trigger)).To(gomega.ConsistOf(
+		reconcile.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: "web"}},
+		reconcile.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: "db"}},
+		reconcile.Request{NamespacedName: types.NamespacedName{Namespace: "operator", Name: "local"}},
+	))
+
+	cleared := &corev1.ConfigMap{}
+	g.Expect(c.Get(context.TODO(), key, cleared)).To(gomega.Succeed())
+	g.Expect(cleared.Data).To(gomega.BeEmpty())
+
+	g.Expect(mapper.Map(cleared)).To(gomega.BeEmpty())
+
+	pred := triggerPredicate(key)
+	g.Expect(pred.Update(event.UpdateEvent{ObjectOld: cleared, ObjectNew: cleared})).To(gomega.BeTrue())
+	g.Expect(pred.Delete(event.DeleteEvent{Object: cleared})).To(gomega.BeFalse())
+	other := newTestConfigMap("other", nil)
+	g.Expect(pred.Create(event.CreateEvent{Object: &other})).To(gomega.BeFalse())
+}
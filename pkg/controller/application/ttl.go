@@ -0,0 +1,63 @@
+// Copyright 2019 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package application
+
+import (
+	"context"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog"
+	appv1beta1 "sigs.k8s.io/application/api/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// updateAssemblySucceededCondition records since when the applications with a TTL after succeeded are
+// Succeeded, the TTL runs from the transition time of the condition
+func updateAssemblySucceededCondition(appStatus *appv1beta1.ApplicationStatus, app *appv1beta1.Application, hasTTL bool) {
+	if hasTTL && app.Spec.AssemblyPhase == appv1beta1.Succeeded {
+		setCondition(appStatus, AssemblySucceeded, corev1.ConditionTrue, "AssemblySucceeded", "spec.assemblyPhase is Succeeded")
+		return
+	}
+
+	clearCondition(appStatus, AssemblySucceeded, "NotSucceeded", "spec.assemblyPhase is not Succeeded")
+}
+
+// ttlRemaining returns how long until the TTL of a Succeeded application expires, false when it is not Succeeded
+func ttlRemaining(appStatus *appv1beta1.ApplicationStatus, ttl time.Duration, now time.Time) (time.Duration, bool) {
+	c := getCondition(appStatus, AssemblySucceeded)
+	if c == nil || c.Status != corev1.ConditionTrue {
+		return 0, false
+	}
+
+	return c.LastTransitionTime.Add(ttl).Sub(now), true
+}
+
+// deleteExpired deletes the application whose TTL expired, its components owned through owner references are
+// deleted by the garbage collection in the background
+func (r *ReconcileApplication) deleteExpired(ctx context.Context, app *appv1beta1.Application) error {
+	klog.Info("Deleting application ", app.Namespace+"/"+app.Name, ", Succeeded past its TTL")
+
+	err := r.Delete(ctx, app, client.PropagationPolicy(metav1.DeletePropagationBackground), client.Preconditions{UID: &app.UID})
+	if err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+
+	r.eventRecorder.RecordEvent(app, "TTLExpired", "The app is deleted, Succeeded past its TTL. App:"+app.Namespace+"/"+app.Name, nil)
+
+	return nil
+}
@@ -0,0 +1,93 @@
+// Copyright 2019 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package application
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/onsi/gomega"
+	"github.com/stolostron/multicloud-operators-application/utils"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	appv1beta1 "sigs.k8s.io/application/api/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestTTLAfterSucceeded(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	app := newTestApplication(configMapGK)
+	app.Spec.AssemblyPhase = appv1beta1.Pending
+
+	status := &appv1beta1.ApplicationStatus{}
+	updateAssemblySucceededCondition(status, app, true)
+	g.Expect(getCondition(status, AssemblySucceeded)).To(gomega.BeNil())
+
+	_, ok := ttlRemaining(status, time.Hour, time.Now())
+	g.Expect(ok).To(gomega.BeFalse())
+
+	app.Spec.AssemblyPhase = appv1beta1.Succeeded
+	updateAssemblySucceededCondition(status, app, true)
+	g.Expect(getCondition(status, AssemblySucceeded).Status).To(gomega.Equal(corev1.ConditionTrue))
+
+	since := getCondition(status, AssemblySucceeded).LastTransitionTime.Time
+
+	remaining, ok := ttlRemaining(status, time.Hour, since.Add(20*time.Minute))
+	g.Expect(ok).To(gomega.BeTrue())
+	g.Expect(remaining).To(gomega.Equal(40 * time.Minute))
+
+	remaining, _ = ttlRemaining(status, 0, since)
+	g.Expect(remaining).To(gomega.BeZero())
+
+	// the application failing again stops the TTL
+	app.Spec.AssemblyPhase = appv1beta1.Failed
+	updateAssemblySucceededCondition(status, app, true)
+	g.Expect(getCondition(status, AssemblySucceeded).Status).To(gomega.Equal(corev1.ConditionFalse))
+
+	_, ok = ttlRemaining(status, time.Hour, time.Now())
+	g.Expect(ok).To(gomega.BeFalse())
+}
+
+func TestDeleteExpired(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	s := runtime.NewScheme()
+	g.Expect(clientgoscheme.AddToScheme(s)).To(gomega.Succeed())
+	g.Expect(appv1beta1.AddToScheme(s)).To(gomega.Succeed())
+
+	app := newTestApplication(configMapGK)
+
+	r := newTestReconciler()
+	r.Client = fake.NewClientBuilder().WithScheme(s).WithObjects(app).Build()
+	recorder := record.NewFakeRecorder(1)
+	r.eventRecorder = &utils.EventRecorder{EventRecorder: recorder}
+
+	g.Expect(r.Get(context.TODO(), types.NamespacedName{Namespace: app.Namespace, Name: app.Name}, app)).To(gomega.Succeed())
+	g.Expect(r.deleteExpired(context.TODO(), app)).To(gomega.Succeed())
+
+	err := r.Get(context.TODO(), types.NamespacedName{Namespace: app.Namespace, Name: app.Name}, &appv1beta1.Application{})
+	g.Expect(errors.IsNotFound(err)).To(gomega.BeTrue())
+
+	g.Expect(<-recorder.Events).To(gomega.HavePrefix("Normal TTLExpired"))
+
+	// deleted in the meantime
+	g.Expect(r.deleteExpired(context.TODO(), app)).To(gomega.Succeed())
+}
@@ -0,0 +1,798 @@
+// Copyright 2019 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ghodss/yaml"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/validation"
+	appv1beta1 "sigs.k8s.io/application/api/v1beta1"
+)
+
+// The application spec is owned by sigs.k8s.io/application, the settings below extend it through annotations
+const (
+	// AnnotationRequiredComponents is a JSON list of {group, kind, name} that must exist in the application namespace
+	AnnotationRequiredComponents = "apps.open-cluster-management.io/required-components"
+	// AnnotationComponentsConfigMap points at a "<configmap>/<key>" in the application namespace holding
+	// the YAML list of components, it replaces the selector based resolution
+	AnnotationComponentsConfigMap = "apps.open-cluster-management.io/components-configmap"
+	// AnnotationReconcileRequest forces a full reconcile of the application whenever its value changes
+	AnnotationReconcileRequest = "apps.open-cluster-management.io/reconcile-request"
+	// AnnotationReconcileInterval is a duration, such as 30s, the application is reconciled again after every
+	// successful reconcile, in place of the reconcile interval of the operator
+	AnnotationReconcileInterval = "apps.open-cluster-management.io/reconcile-interval"
+	// AnnotationFallbackSelectors is a JSON list of label selectors tried in order after spec.selector, the
+	// components are resolved with the first selector matching any
+	AnnotationFallbackSelectors = "apps.open-cluster-management.io/fallback-selectors"
+	// AnnotationComponentImage keeps the workload components running a container image containing the substring
+	AnnotationComponentImage = "apps.open-cluster-management.io/component-image"
+	// AnnotationComponentImageRegex keeps the workload components running a container image matching the regex
+	AnnotationComponentImageRegex = "apps.open-cluster-management.io/component-image-regex"
+	// AnnotationHealthAggregation is the rule combining the component health into the application health,
+	// one of All (default), Any or Majority
+	AnnotationHealthAggregation = "apps.open-cluster-management.io/health-aggregation"
+	// AnnotationHealthKinds is a JSON list of {group, kind} the application health is evaluated from, in place of
+	// spec.componentGroupKinds. The kinds only listed there, such as Pods, are resolved for the health and not
+	// listed as components.
+	AnnotationHealthKinds = "apps.open-cluster-management.io/health-kinds"
+	// AnnotationHealthMaxComponents is the number of components above which the health of the application is not
+	// evaluated, it is reported as Unknown and only the inventory is kept. It overrides the operator threshold,
+	// 0 always evaluates the health.
+	AnnotationHealthMaxComponents = "apps.open-cluster-management.io/health-max-components"
+	// AnnotationHealthWeights is a JSON list of {group, kind, name, weight} weighting the components in the
+	// application health, the entries without a name weight every component of the kind. The components weigh 1
+	// by default, those weighing 0 are listed but left out of the application health.
+	AnnotationHealthWeights = "apps.open-cluster-management.io/health-weights"
+	// AnnotationPropagateLabels is a JSON map of labels set on every component of the application
+	AnnotationPropagateLabels = "apps.open-cluster-management.io/propagate-labels"
+	// AnnotationPropagateKindLabels is a JSON map of labels set on the components of a kind only, keyed by
+	// "<kind>.<group>", they take precedence over the labels set on every component
+	AnnotationPropagateKindLabels = "apps.open-cluster-management.io/propagate-kind-labels"
+	// AnnotationLabelCleanupPolicy is what becomes of the propagated labels and annotations no longer wanted on a
+	// component, a key removed from the propagation annotations or a component no longer selected, one of Remove
+	// (default) or Keep
+	AnnotationLabelCleanupPolicy = "apps.open-cluster-management.io/label-cleanup-policy"
+	// AnnotationPropagatedLabelKeys is recorded on the components, the comma separated keys of the labels
+	// propagated to them by their application
+	AnnotationPropagatedLabelKeys = "apps.open-cluster-management.io/propagated-label-keys"
+	// AnnotationPropagateAnnotations is a JSON list of keys of the annotations of the application, such as its
+	// source revision, copied onto every component. The keys missing on the application are not propagated.
+	// The propagation is disabled along the AnnotationPropagation feature gate.
+	AnnotationPropagateAnnotations = "apps.open-cluster-management.io/propagate-annotations"
+	// AnnotationPropagatedAnnotationKeys is recorded on the components, the comma separated keys of the
+	// annotations propagated to them by their application
+	AnnotationPropagatedAnnotationKeys = "apps.open-cluster-management.io/propagated-annotation-keys"
+	// AnnotationComponentNamespaces is a JSON map of namespaces keyed by "<kind>.<group>", the components of
+	// those kinds are resolved in the given namespace rather than the application namespace
+	AnnotationComponentNamespaces = "apps.open-cluster-management.io/component-namespaces"
+	// AnnotationSoftOwner set to "true" stamps the components of the application with AnnotationOwnerApplication.
+	// The stamp is a hint with no effect on garbage collection, it survives the application being deleted so
+	// tooling can bridge a delete and recreate. With spec.addOwnerRef, an application recreated with the same
+	// name re-adopts the components it finds stamped, replacing the owner reference of its deleted
+	// predecessor rather than waiting for the garbage collector to drop it. The components left with no
+	// other owner are still collected if the application is not recreated before the garbage collector runs.
+	AnnotationSoftOwner = "apps.open-cluster-management.io/soft-owner"
+	// AnnotationOwnerApplication is the "<namespace>/<name>" of the soft owner application of a component
+	AnnotationOwnerApplication = "apps.open-cluster-management.io/owner-application"
+	// AnnotationNoAdopt set to "true" on a component resource keeps it out of every application, it is neither
+	// resolved nor adopted whatever the selectors matching it. The operator can watch another annotation key.
+	AnnotationNoAdopt = "apps.open-cluster-management.io/no-adopt"
+	// AnnotationAllowAssemblyPhaseTransition set to "true" lets the webhook accept any spec.assemblyPhase transition
+	AnnotationAllowAssemblyPhaseTransition = "apps.open-cluster-management.io/allow-assembly-phase-transition"
+	// AnnotationAllowEmptyComponentKinds set to "true" lets the webhook accept an application without
+	// componentGroupKinds, an inventory application resolving no component on purpose
+	AnnotationAllowEmptyComponentKinds = "apps.open-cluster-management.io/allow-empty-component-kinds"
+	// AnnotationRebuildStatus makes the next reconcile discard the application status and rebuild it from the
+	// live cluster state, the annotation is removed once the rebuilt status is written
+	AnnotationRebuildStatus = "apps.open-cluster-management.io/rebuild-status"
+	// AnnotationLastReconciledBy is the operator replica, its pod name, that last updated the application
+	AnnotationLastReconciledBy = "apps.open-cluster-management.io/last-reconciled-by"
+	// AnnotationDashboardLinks is written by the controller, a JSON map of the dashboard URLs of the application
+	// keyed by the link name, rendered from the URL templates configured on the operator
+	AnnotationDashboardLinks = "apps.open-cluster-management.io/dashboard-links"
+	// AnnotationExportComponents set to "true" makes the controller write the resolved components into
+	// AnnotationExportedComponents, for the tools that ignore the status subresource
+	AnnotationExportComponents = "apps.open-cluster-management.io/export-components"
+	// AnnotationExportedComponents is written by the controller, the sorted JSON list of {group, kind, namespace,
+	// name} of the components, or {"truncated": true, "count": N} past the size cap of the operator
+	AnnotationExportedComponents = "apps.open-cluster-management.io/exported-components"
+	// AnnotationClusterComponents is written by the controller on the hub, the JSON map of the managed clusters the
+	// deployables of the application target to their {health, components}, the components mapping each deployable
+	// to the phase the cluster reported. The components are left out past the size cap of the operator.
+	AnnotationClusterComponents = "apps.open-cluster-management.io/cluster-components"
+	// AnnotationDiagnoseSelector set to "true" makes the controller count the components each term of the
+	// selector filters out, by resolving them again with the term removed, and report the counts in the
+	// SelectorDiagnostics condition. It lists the component kinds once per term, it is meant for debugging.
+	AnnotationDiagnoseSelector = "apps.open-cluster-management.io/diagnose-selector"
+	// AnnotationParent is the name of the parent application in the same namespace, the health of the parent
+	// rolls up the health of its child applications. The hierarchy is two levels deep, a parent has no parent.
+	AnnotationParent = "apps.open-cluster-management.io/parent"
+	// AnnotationReconcileHistory is written by the controller, the JSON list of the last reconcile outcomes of the
+	// application, oldest first, each {"time", "error"} or {"time", "success": true}. The successes do not clear
+	// the errors, a repeated outcome is only recorded once.
+	AnnotationReconcileHistory = "apps.open-cluster-management.io/reconcile-history"
+	// AnnotationPause pauses the reconciles of the application during maintenance, in one of three modes:
+	//   - not set or empty, the application is not paused
+	//   - "true", everything is frozen, the components are left untouched and the status is not updated
+	//   - "mutations", the components are left untouched, no owner reference, soft owner stamp, propagated label
+	//     or annotation, nor template copy is written, but the components are still resolved and the status and
+	//     health published. The Paused condition reports the mode.
+	// The deletion of a paused template application still deletes its copies when it asks for it.
+	AnnotationPause = "apps.open-cluster-management.io/pause"
+	// AnnotationFailOnForbidden set to "true" makes the controller report the components it is forbidden to
+	// resolve or patch in the Degraded and AccessForbidden conditions, naming the kind, the verb and the service account denied, as
+	// the operator does for every application with --fail-on-forbidden
+	AnnotationFailOnForbidden = "apps.open-cluster-management.io/fail-on-forbidden"
+	// AnnotationResolveAs names a ServiceAccount of the application namespace the components are resolved as,
+	// the controller impersonating it so the RBAC of the tenant scopes what the application can read. It needs
+	// the operator to run with --impersonate-service-accounts, the resolution fails otherwise.
+	AnnotationResolveAs = "apps.open-cluster-management.io/resolve-as"
+	// AnnotationTTLSecondsAfterSucceeded deletes the application, and the components it owns through the garbage
+	// collection, once spec.assemblyPhase has been Succeeded for that many seconds, as the TTL of the Jobs. 0
+	// deletes it as soon as it is Succeeded. The empty phase does not count as Succeeded here.
+	AnnotationTTLSecondsAfterSucceeded = "apps.open-cluster-management.io/ttl-seconds-after-succeeded"
+	// AnnotationMode set to "Inventory" makes the application a pure grouping of components, as for RBAC or
+	// billing: its components are resolved, listed and optionally owned, but their health is not evaluated and
+	// the application health is reported as NotApplicable
+	AnnotationMode = "apps.open-cluster-management.io/mode"
+	// AnnotationTemplate set to "true" marks the application as a template copied into other namespaces
+	AnnotationTemplate = "apps.open-cluster-management.io/template"
+	// AnnotationTemplateNamespaceSelector is the label selector of the namespaces receiving a copy of the
+	// template, every namespace is selected when it is empty
+	AnnotationTemplateNamespaceSelector = "apps.open-cluster-management.io/template-namespace-selector"
+	// AnnotationTemplateCleanup set to "true" deletes the copies of the template when the template is deleted
+	AnnotationTemplateCleanup = "apps.open-cluster-management.io/template-cleanup"
+)
+
+// Health aggregation rules, the application is healthy when all, any or a strict majority of its components are
+const (
+	HealthAggregationAll      = "All"
+	HealthAggregationAny      = "Any"
+	HealthAggregationMajority = "Majority"
+)
+
+// GetHealthAggregation returns the health aggregation rule of the application, All when it is not set
+func GetHealthAggregation(app *appv1beta1.Application) (string, error) {
+	val, ok := app.GetAnnotations()[AnnotationHealthAggregation]
+	if !ok || val == "" {
+		return HealthAggregationAll, nil
+	}
+
+	switch val {
+	case HealthAggregationAll, HealthAggregationAny, HealthAggregationMajority:
+		return val, nil
+	}
+
+	return HealthAggregationAll, fmt.Errorf("invalid %s annotation %q: expected one of %s, %s, %s", AnnotationHealthAggregation, val,
+		HealthAggregationAll, HealthAggregationAny, HealthAggregationMajority)
+}
+
+// GetReconcileInterval returns the reconcile interval of the application, found is false when it is not set
+func GetReconcileInterval(app *appv1beta1.Application) (interval time.Duration, found bool, err error) {
+	val, ok := app.GetAnnotations()[AnnotationReconcileInterval]
+	if !ok || val == "" {
+		return 0, false, nil
+	}
+
+	interval, err = time.ParseDuration(val)
+	if err != nil {
+		return 0, true, fmt.Errorf("invalid %s annotation: %w", AnnotationReconcileInterval, err)
+	}
+
+	if interval <= 0 {
+		return 0, true, fmt.Errorf("invalid %s annotation %q: the interval must be positive", AnnotationReconcileInterval, val)
+	}
+
+	return interval, true, nil
+}
+
+// GetTTLAfterSucceeded returns how long after it is Succeeded the application is deleted, found is false when
+// it is not set
+func GetTTLAfterSucceeded(app *appv1beta1.Application) (ttl time.Duration, found bool, err error) {
+	val, ok := app.GetAnnotations()[AnnotationTTLSecondsAfterSucceeded]
+	if !ok || val == "" {
+		return 0, false, nil
+	}
+
+	seconds, err := strconv.Atoi(val)
+	if err != nil {
+		return 0, true, fmt.Errorf("invalid %s annotation: %w", AnnotationTTLSecondsAfterSucceeded, err)
+	}
+
+	if seconds < 0 {
+		return 0, true, fmt.Errorf("invalid %s annotation %q: the TTL must not be negative", AnnotationTTLSecondsAfterSucceeded, val)
+	}
+
+	return time.Duration(seconds) * time.Second, true, nil
+}
+
+// GetHealthMaxComponents returns the component count above which the health of the application is not evaluated,
+// found is false when it is not set
+func GetHealthMaxComponents(app *appv1beta1.Application) (max int, found bool, err error) {
+	val, ok := app.GetAnnotations()[AnnotationHealthMaxComponents]
+	if !ok || val == "" {
+		return 0, false, nil
+	}
+
+	max, err = strconv.Atoi(val)
+	if err != nil {
+		return 0, true, fmt.Errorf("invalid %s annotation: %w", AnnotationHealthMaxComponents, err)
+	}
+
+	if max < 0 {
+		return 0, true, fmt.Errorf("invalid %s annotation %q: the count must not be negative", AnnotationHealthMaxComponents, val)
+	}
+
+	return max, true, nil
+}
+
+// Label cleanup policies, the propagated labels no longer wanted are removed or kept in place
+const (
+	LabelCleanupPolicyRemove = "Remove"
+	LabelCleanupPolicyKeep   = "Keep"
+)
+
+// GetLabelCleanupPolicy returns the label cleanup policy of the application, Remove when it is not set, and
+// Keep along with the error when it is invalid
+func GetLabelCleanupPolicy(app *appv1beta1.Application) (string, error) {
+	val, ok := app.GetAnnotations()[AnnotationLabelCleanupPolicy]
+	if !ok || val == "" {
+		return LabelCleanupPolicyRemove, nil
+	}
+
+	switch val {
+	case LabelCleanupPolicyRemove, LabelCleanupPolicyKeep:
+		return val, nil
+	}
+
+	return LabelCleanupPolicyKeep, fmt.Errorf("invalid %s annotation %q: expected one of %s, %s", AnnotationLabelCleanupPolicy, val,
+		LabelCleanupPolicyRemove, LabelCleanupPolicyKeep)
+}
+
+// Pause modes, the application is either not paused, frozen, or paused for the writes to its components only
+const (
+	PauseNone      = ""
+	PauseAll       = "true"
+	PauseMutations = "mutations"
+)
+
+// GetPauseMode returns the pause mode of the application, PauseNone when it is not set, and PauseAll along with
+// the error when it is invalid
+func GetPauseMode(app *appv1beta1.Application) (string, error) {
+	val := app.GetAnnotations()[AnnotationPause]
+
+	switch val {
+	case PauseNone, PauseAll, PauseMutations:
+		return val, nil
+	}
+
+	return PauseAll, fmt.Errorf("invalid %s annotation %q: expected one of %q, %q, %q", AnnotationPause, val,
+		PauseNone, PauseAll, PauseMutations)
+}
+
+// Application modes, the default mode evaluates the health of the components and the inventory mode does not
+const (
+	ModeDefault   = ""
+	ModeInventory = "Inventory"
+)
+
+// GetMode returns the mode of the application, ModeDefault when it is not set, and ModeDefault along with the
+// error when it is invalid
+func GetMode(app *appv1beta1.Application) (string, error) {
+	val := app.GetAnnotations()[AnnotationMode]
+
+	switch val {
+	case ModeDefault, ModeInventory:
+		return val, nil
+	}
+
+	return ModeDefault, fmt.Errorf("invalid %s annotation %q: expected %q or empty", AnnotationMode, val, ModeInventory)
+}
+
+// ReservedKeyPrefix is the prefix of the annotations and labels of the operator. The applications can neither
+// select on the labels it sets for its own tracking nor propagate any of its labels and annotations.
+const ReservedKeyPrefix = "apps.open-cluster-management.io/"
+
+// IsReservedLabel returns true if the label key is under the prefix reserved for the operator
+func IsReservedLabel(key string) bool {
+	return strings.HasPrefix(key, ReservedKeyPrefix)
+}
+
+// LabelPropagatedBy is set on the components labels are propagated to, to the uid of the application, so the
+// components no longer selected can be found and cleaned up
+const LabelPropagatedBy = "apps.open-cluster-management.io/propagated-by"
+
+// The copies of a template application are labeled with the template they are created from
+const (
+	LabelTemplateName      = "apps.open-cluster-management.io/template-name"
+	LabelTemplateNamespace = "apps.open-cluster-management.io/template-namespace"
+)
+
+// IsTemplate returns true if the application is a template copied into other namespaces
+func IsTemplate(app *appv1beta1.Application) bool {
+	return app.GetAnnotations()[AnnotationTemplate] == "true"
+}
+
+// GetParent returns the name of the parent application, empty when the application has none
+func GetParent(app *appv1beta1.Application) (string, error) {
+	parent := app.GetAnnotations()[AnnotationParent]
+	if parent == "" {
+		return "", nil
+	}
+
+	if errs := validation.IsDNS1123Subdomain(parent); len(errs) > 0 {
+		return "", fmt.Errorf("invalid %s annotation %q: %s", AnnotationParent, parent, strings.Join(errs, ", "))
+	}
+
+	if parent == app.Name {
+		return "", fmt.Errorf("invalid %s annotation: the application cannot be its own parent", AnnotationParent)
+	}
+
+	return parent, nil
+}
+
+// GetResolveAs returns the name of the ServiceAccount the components of the application are resolved as, empty
+// when they are resolved with the identity of the operator
+func GetResolveAs(app *appv1beta1.Application) (string, error) {
+	sa := app.GetAnnotations()[AnnotationResolveAs]
+	if sa == "" {
+		return "", nil
+	}
+
+	if errs := validation.IsDNS1123Subdomain(sa); len(errs) > 0 {
+		return "", fmt.Errorf("invalid %s annotation %q: %s", AnnotationResolveAs, sa, strings.Join(errs, ", "))
+	}
+
+	return sa, nil
+}
+
+// IsExportingComponents returns true if the application opts in the exported components annotation
+func IsExportingComponents(app *appv1beta1.Application) bool {
+	return app.GetAnnotations()[AnnotationExportComponents] == "true"
+}
+
+// IsFailingOnForbidden returns true if the application reports its forbidden accesses in the Degraded condition
+func IsFailingOnForbidden(app *appv1beta1.Application) bool {
+	return app.GetAnnotations()[AnnotationFailOnForbidden] == "true"
+}
+
+// IsDiagnosingSelector returns true if the application asks for the selector diagnostics
+func IsDiagnosingSelector(app *appv1beta1.Application) bool {
+	return app.GetAnnotations()[AnnotationDiagnoseSelector] == "true"
+}
+
+// IsSoftOwner returns true if the application stamps its components with the owner application annotation
+func IsSoftOwner(app *appv1beta1.Application) bool {
+	return app.GetAnnotations()[AnnotationSoftOwner] == "true"
+}
+
+// GetTemplateNamespaceSelector parses the namespace selector of a template application
+func GetTemplateNamespaceSelector(app *appv1beta1.Application) (labels.Selector, error) {
+	selector, err := labels.Parse(app.GetAnnotations()[AnnotationTemplateNamespaceSelector])
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s annotation: %w", AnnotationTemplateNamespaceSelector, err)
+	}
+
+	return selector, nil
+}
+
+// RequiredComponent is a component the application asserts to exist
+type RequiredComponent struct {
+	Group string `json:"group,omitempty"`
+	Kind  string `json:"kind"`
+	Name  string `json:"name"`
+}
+
+// GroupKind returns the group kind of the required component
+func (rc RequiredComponent) GroupKind() metav1.GroupKind {
+	return metav1.GroupKind{Group: rc.Group, Kind: rc.Kind}
+}
+
+func (rc RequiredComponent) String() string {
+	gk := rc.GroupKind()
+
+	return gk.String() + "/" + rc.Name
+}
+
+// GetRequiredComponents parses the required components annotation of the application
+func GetRequiredComponents(app *appv1beta1.Application) ([]RequiredComponent, error) {
+	val, ok := app.GetAnnotations()[AnnotationRequiredComponents]
+	if !ok || val == "" {
+		return nil, nil
+	}
+
+	var rcs []RequiredComponent
+	if err := json.Unmarshal([]byte(val), &rcs); err != nil {
+		return nil, fmt.Errorf("invalid %s annotation: %w", AnnotationRequiredComponents, err)
+	}
+
+	for i, rc := range rcs {
+		if rc.Kind == "" || rc.Name == "" {
+			return nil, fmt.Errorf("invalid %s annotation: entry %d requires both kind and name", AnnotationRequiredComponents, i)
+		}
+	}
+
+	return rcs, nil
+}
+
+// GetHealthKinds parses the health kinds annotation of the application, nil when the health is evaluated from
+// spec.componentGroupKinds
+func GetHealthKinds(app *appv1beta1.Application) ([]metav1.GroupKind, error) {
+	val, ok := app.GetAnnotations()[AnnotationHealthKinds]
+	if !ok || val == "" {
+		return nil, nil
+	}
+
+	var gks []metav1.GroupKind
+	if err := json.Unmarshal([]byte(val), &gks); err != nil {
+		return nil, fmt.Errorf("invalid %s annotation: %w", AnnotationHealthKinds, err)
+	}
+
+	for i, gk := range gks {
+		if gk.Kind == "" {
+			return nil, fmt.Errorf("invalid %s annotation: entry %d requires a kind", AnnotationHealthKinds, i)
+		}
+	}
+
+	return gks, nil
+}
+
+// HealthWeight is the weight of the components of a kind, or of a single component when it is named, in the
+// application health
+type HealthWeight struct {
+	Group  string `json:"group,omitempty"`
+	Kind   string `json:"kind"`
+	Name   string `json:"name,omitempty"`
+	Weight *int   `json:"weight"`
+}
+
+// HealthWeights are the weights of the health weights annotation
+type HealthWeights []HealthWeight
+
+// Weight returns the weight of a component, the entry naming it wins over the entry of its kind, and the
+// components without an entry weigh 1
+func (hw HealthWeights) Weight(group, kind, name string) int {
+	weight := 1
+
+	for _, w := range hw {
+		if appv1beta1.StripVersion(w.Group) != group || w.Kind != kind {
+			continue
+		}
+
+		if w.Name == name {
+			return *w.Weight
+		}
+
+		if w.Name == "" {
+			weight = *w.Weight
+		}
+	}
+
+	return weight
+}
+
+// GetHealthWeights parses the health weights annotation of the application, nil when every component weighs 1
+func GetHealthWeights(app *appv1beta1.Application) (HealthWeights, error) {
+	val, ok := app.GetAnnotations()[AnnotationHealthWeights]
+	if !ok || val == "" {
+		return nil, nil
+	}
+
+	var weights HealthWeights
+	if err := json.Unmarshal([]byte(val), &weights); err != nil {
+		return nil, fmt.Errorf("invalid %s annotation: %w", AnnotationHealthWeights, err)
+	}
+
+	for i, w := range weights {
+		switch {
+		case w.Kind == "":
+			return nil, fmt.Errorf("invalid %s annotation: entry %d requires a kind", AnnotationHealthWeights, i)
+		case w.Weight == nil:
+			return nil, fmt.Errorf("invalid %s annotation: entry %d requires a weight", AnnotationHealthWeights, i)
+		case *w.Weight < 0:
+			return nil, fmt.Errorf("invalid %s annotation: entry %d weight %d must not be negative", AnnotationHealthWeights, i, *w.Weight)
+		}
+	}
+
+	return weights, nil
+}
+
+// GetSelectors returns spec.selector followed by the fallback selectors of the application, in priority order
+func GetSelectors(app *appv1beta1.Application) ([]*metav1.LabelSelector, error) {
+	selectors := []*metav1.LabelSelector{app.Spec.Selector}
+
+	val, ok := app.GetAnnotations()[AnnotationFallbackSelectors]
+	if !ok || val == "" {
+		return selectors, nil
+	}
+
+	var fallbacks []*metav1.LabelSelector
+	if err := json.Unmarshal([]byte(val), &fallbacks); err != nil {
+		return selectors, fmt.Errorf("invalid %s annotation: %w", AnnotationFallbackSelectors, err)
+	}
+
+	for i, sel := range fallbacks {
+		if sel == nil {
+			return selectors, fmt.Errorf("invalid %s annotation: entry %d is null", AnnotationFallbackSelectors, i)
+		}
+	}
+
+	return append(selectors, fallbacks...), nil
+}
+
+// ImageFilter matches container images against the image annotations of an application
+type ImageFilter struct {
+	Substring string
+	Regex     *regexp.Regexp
+}
+
+// Match returns true if the image contains the substring and matches the regex, when they are set
+func (f *ImageFilter) Match(image string) bool {
+	if f.Substring != "" && !strings.Contains(image, f.Substring) {
+		return false
+	}
+
+	if f.Regex != nil && !f.Regex.MatchString(image) {
+		return false
+	}
+
+	return true
+}
+
+// GetImageFilter parses the image annotations of the application, the filter is nil when none is set
+func GetImageFilter(app *appv1beta1.Application) (*ImageFilter, error) {
+	substring := app.GetAnnotations()[AnnotationComponentImage]
+	expr := app.GetAnnotations()[AnnotationComponentImageRegex]
+
+	if substring == "" && expr == "" {
+		return nil, nil
+	}
+
+	filter := &ImageFilter{Substring: substring}
+
+	if expr != "" {
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s annotation: %w", AnnotationComponentImageRegex, err)
+		}
+
+		filter.Regex = re
+	}
+
+	return filter, nil
+}
+
+// PropagatedLabels are the labels an application sets on its components
+type PropagatedLabels struct {
+	All    map[string]string
+	ByKind map[schema.GroupKind]map[string]string
+}
+
+// ForKind returns the labels to set on the components of the kind, none when nothing is propagated
+func (p *PropagatedLabels) ForKind(gk schema.GroupKind) map[string]string {
+	if p == nil {
+		return map[string]string{}
+	}
+
+	merged := make(map[string]string, len(p.All)+len(p.ByKind[gk]))
+
+	for k, v := range p.All {
+		merged[k] = v
+	}
+
+	for k, v := range p.ByKind[gk] {
+		merged[k] = v
+	}
+
+	return merged
+}
+
+// GetPropagatedLabels parses the label propagation annotations of the application, nil when none is set
+func GetPropagatedLabels(app *appv1beta1.Application) (*PropagatedLabels, error) {
+	all := app.GetAnnotations()[AnnotationPropagateLabels]
+	byKind := app.GetAnnotations()[AnnotationPropagateKindLabels]
+
+	if all == "" && byKind == "" {
+		return nil, nil
+	}
+
+	p := &PropagatedLabels{ByKind: map[schema.GroupKind]map[string]string{}}
+
+	if all != "" {
+		if err := json.Unmarshal([]byte(all), &p.All); err != nil {
+			return nil, fmt.Errorf("invalid %s annotation: %w", AnnotationPropagateLabels, err)
+		}
+
+		if err := validateLabels(p.All); err != nil {
+			return nil, fmt.Errorf("invalid %s annotation: %w", AnnotationPropagateLabels, err)
+		}
+	}
+
+	if byKind != "" {
+		var raw map[string]map[string]string
+		if err := json.Unmarshal([]byte(byKind), &raw); err != nil {
+			return nil, fmt.Errorf("invalid %s annotation: %w", AnnotationPropagateKindLabels, err)
+		}
+
+		for key, labels := range raw {
+			gk := schema.ParseGroupKind(key)
+			if gk.Kind == "" {
+				return nil, fmt.Errorf("invalid %s annotation: %q is not a <kind>.<group>", AnnotationPropagateKindLabels, key)
+			}
+
+			if err := validateLabels(labels); err != nil {
+				return nil, fmt.Errorf("invalid %s annotation, kind %s: %w", AnnotationPropagateKindLabels, key, err)
+			}
+
+			p.ByKind[gk] = labels
+		}
+	}
+
+	return p, nil
+}
+
+// GetPropagatedAnnotations returns the annotations of the application its propagate annotations annotation
+// selects, nil when none is set. The keys in the apps.open-cluster-management.io domain are reserved for the
+// operator and cannot be propagated.
+func GetPropagatedAnnotations(app *appv1beta1.Application) (map[string]string, error) {
+	val := app.GetAnnotations()[AnnotationPropagateAnnotations]
+	if val == "" {
+		return nil, nil
+	}
+
+	var keys []string
+	if err := json.Unmarshal([]byte(val), &keys); err != nil {
+		return nil, fmt.Errorf("invalid %s annotation: %w", AnnotationPropagateAnnotations, err)
+	}
+
+	propagated := make(map[string]string, len(keys))
+
+	for _, k := range keys {
+		if errs := validation.IsQualifiedName(k); len(errs) > 0 {
+			return nil, fmt.Errorf("invalid %s annotation, key %q: %s", AnnotationPropagateAnnotations, k, strings.Join(errs, ", "))
+		}
+
+		if strings.HasPrefix(k, ReservedKeyPrefix) {
+			return nil, fmt.Errorf("invalid %s annotation, key %q: the %s prefix is reserved for the operator",
+				AnnotationPropagateAnnotations, k, ReservedKeyPrefix)
+		}
+
+		if v, ok := app.GetAnnotations()[k]; ok {
+			propagated[k] = v
+		}
+	}
+
+	return propagated, nil
+}
+
+// GetComponentNamespaces parses the per kind component namespaces of the application, nil when none is set
+func GetComponentNamespaces(app *appv1beta1.Application) (map[schema.GroupKind]string, error) {
+	val := app.GetAnnotations()[AnnotationComponentNamespaces]
+	if val == "" {
+		return nil, nil
+	}
+
+	var raw map[string]string
+	if err := json.Unmarshal([]byte(val), &raw); err != nil {
+		return nil, fmt.Errorf("invalid %s annotation: %w", AnnotationComponentNamespaces, err)
+	}
+
+	namespaces := make(map[schema.GroupKind]string, len(raw))
+
+	for key, ns := range raw {
+		gk := schema.ParseGroupKind(key)
+		if gk.Kind == "" {
+			return nil, fmt.Errorf("invalid %s annotation: %q is not a <kind>.<group>", AnnotationComponentNamespaces, key)
+		}
+
+		if errs := validation.IsDNS1123Label(ns); len(errs) > 0 {
+			return nil, fmt.Errorf("invalid %s annotation, kind %s namespace %q: %s", AnnotationComponentNamespaces, key, ns,
+				strings.Join(errs, ", "))
+		}
+
+		namespaces[gk] = ns
+	}
+
+	return namespaces, nil
+}
+
+func validateLabels(labels map[string]string) error {
+	for k, v := range labels {
+		if errs := validation.IsQualifiedName(k); len(errs) > 0 {
+			return fmt.Errorf("label key %q: %s", k, strings.Join(errs, ", "))
+		}
+
+		if errs := validation.IsValidLabelValue(v); len(errs) > 0 {
+			return fmt.Errorf("label %s value %q: %s", k, v, strings.Join(errs, ", "))
+		}
+	}
+
+	return nil
+}
+
+// ComponentReference identifies a component listed explicitly rather than selected
+type ComponentReference struct {
+	Group     string `json:"group,omitempty"`
+	Kind      string `json:"kind"`
+	Namespace string `json:"namespace,omitempty"`
+	Name      string `json:"name"`
+}
+
+// GroupKind returns the group kind of the referenced component
+func (ref ComponentReference) GroupKind() metav1.GroupKind {
+	return metav1.GroupKind{Group: ref.Group, Kind: ref.Kind}
+}
+
+// ConfigMapKeyReference is a key of a ConfigMap in the application namespace
+type ConfigMapKeyReference struct {
+	Name string
+	Key  string
+}
+
+func (ref ConfigMapKeyReference) String() string {
+	return ref.Name + "/" + ref.Key
+}
+
+// GetComponentsConfigMap parses the components ConfigMap annotation, found is false when it is not set
+func GetComponentsConfigMap(app *appv1beta1.Application) (ref ConfigMapKeyReference, found bool, err error) {
+	val, ok := app.GetAnnotations()[AnnotationComponentsConfigMap]
+	if !ok {
+		return ref, false, nil
+	}
+
+	parts := strings.Split(val, "/")
+	if len(parts) != 2 {
+		return ref, true, fmt.Errorf("invalid %s annotation %q: expected <configmap>/<key>", AnnotationComponentsConfigMap, val)
+	}
+
+	ref = ConfigMapKeyReference{Name: parts[0], Key: parts[1]}
+
+	if errs := validation.IsDNS1123Subdomain(ref.Name); len(errs) > 0 {
+		return ref, true, fmt.Errorf("invalid %s annotation, configmap name %q: %s", AnnotationComponentsConfigMap, ref.Name,
+			strings.Join(errs, ", "))
+	}
+
+	if errs := validation.IsConfigMapKey(ref.Key); len(errs) > 0 {
+		return ref, true, fmt.Errorf("invalid %s annotation, configmap key %q: %s", AnnotationComponentsConfigMap, ref.Key,
+			strings.Join(errs, ", "))
+	}
+
+	return ref, true, nil
+}
+
+// ParseComponentList parses the YAML list of components stored in a components ConfigMap
+func ParseComponentList(data string) ([]ComponentReference, error) {
+	var refs []ComponentReference
+	if err := yaml.Unmarshal([]byte(data), &refs); err != nil {
+		return nil, err
+	}
+
+	for i, ref := range refs {
+		if ref.Kind == "" || ref.Name == "" {
+			return nil, fmt.Errorf("component %d requires both kind and name", i)
+		}
+	}
+
+	return refs, nil
+}
@@ -15,6 +15,8 @@
 package utils
 
 import (
+	"time"
+
 	corev1 "k8s.io/api/core/v1"
 	apiruntime "k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/kubernetes"
@@ -33,6 +35,9 @@ const NoiseLogLel = 5
 // VeryNoisy = show call stack, routine  and everything
 const VeryNoisy = 10
 
+// DefaultEventAggregationWindow is the client-go default window of the event aggregation
+const DefaultEventAggregationWindow = 10 * time.Minute
+
 // EventRecorder - record kubernetes event
 type EventRecorder struct {
 	record.EventRecorder
@@ -40,6 +45,13 @@ type EventRecorder struct {
 
 // NewEventRecorder - create new event recorder from rect config
 func NewEventRecorder(cfg *rest.Config, scheme *apiruntime.Scheme) (*EventRecorder, error) {
+	return NewEventRecorderWithWindow(cfg, scheme, DefaultEventAggregationWindow)
+}
+
+// NewEventRecorderWithWindow creates an event recorder coalescing the events on the same object within the window.
+// The identical events are recorded once with a count, and past a handful of similar events, differing only by
+// their message, they are combined into a single event.
+func NewEventRecorderWithWindow(cfg *rest.Config, scheme *apiruntime.Scheme, window time.Duration) (*EventRecorder, error) {
 	reccs, err := kubernetes.NewForConfig(cfg)
 	if err != nil {
 		klog.Error("Failed to new clientset for event recorder. err: ", err)
@@ -47,7 +59,7 @@ func NewEventRecorder(cfg *rest.Config, scheme *apiruntime.Scheme) (*EventRecord
 	}
 
 	rec := &EventRecorder{}
-	eventBroadcaster := record.NewBroadcaster()
+	eventBroadcaster := record.NewBroadcasterWithCorrelatorOptions(eventCorrelatorOptions(window))
 	eventBroadcaster.StartLogging(klog.Infof)
 	eventBroadcaster.StartRecordingToSink(&typedcorev1.EventSinkImpl{Interface: reccs.CoreV1().Events("")})
 
@@ -56,6 +68,15 @@ func NewEventRecorder(cfg *rest.Config, scheme *apiruntime.Scheme) (*EventRecord
 	return rec, nil
 }
 
+// eventCorrelatorOptions sets the aggregation window, the other settings keep the client-go defaults
+func eventCorrelatorOptions(window time.Duration) record.CorrelatorOptions {
+	if window < time.Second {
+		window = DefaultEventAggregationWindow
+	}
+
+	return record.CorrelatorOptions{MaxIntervalInSeconds: int(window.Seconds())}
+}
+
 // RecordEvent - record kuberentes event
 func (rec *EventRecorder) RecordEvent(obj apiruntime.Object, reason, msg string, err error) {
 	eventType := corev1.EventTypeNormal
@@ -0,0 +1,127 @@
+// Copyright 2019 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Feature names an optional behavior of the operator enabled through its gate
+type Feature string
+
+// The feature gates, the experimental behaviors are expected to ship disabled by default
+const (
+	// AnnotationPropagation copies the annotations selected by the propagate annotations annotation onto the components
+	AnnotationPropagation Feature = "AnnotationPropagation"
+)
+
+// defaultFeatureGates are the known features and whether they are enabled by default
+var defaultFeatureGates = map[Feature]bool{
+	AnnotationPropagation: true,
+}
+
+// FeatureGates holds whether each known feature is enabled
+type FeatureGates struct {
+	lock    sync.RWMutex
+	enabled map[Feature]bool
+}
+
+// DefaultFeatureGates are the gates of the operator, set at startup from the FEATURE_GATES env var
+var DefaultFeatureGates = NewFeatureGates()
+
+// NewFeatureGates returns the gates of the known features set to their defaults
+func NewFeatureGates() *FeatureGates {
+	enabled := make(map[Feature]bool, len(defaultFeatureGates))
+	for f, on := range defaultFeatureGates {
+		enabled[f] = on
+	}
+
+	return &FeatureGates{enabled: enabled}
+}
+
+// Set parses the comma separated Feature=bool pairs and applies them. The unknown features are returned rather
+// than failing, so a gate removed from the operator does not break its deployments. Nothing is applied when a
+// pair is malformed.
+func (g *FeatureGates) Set(spec string) ([]string, error) {
+	parsed := map[Feature]bool{}
+
+	var unknown []string
+
+	for _, pair := range strings.Split(spec, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+
+		kv := strings.SplitN(pair, "=", 2)
+		if len(kv) != 2 {
+			return nil, fmt.Errorf("invalid feature gate %q: expected <feature>=<bool>", pair)
+		}
+
+		on, err := strconv.ParseBool(strings.TrimSpace(kv[1]))
+		if err != nil {
+			return nil, fmt.Errorf("invalid feature gate %q: %w", pair, err)
+		}
+
+		f := Feature(strings.TrimSpace(kv[0]))
+		if _, known := defaultFeatureGates[f]; !known {
+			unknown = append(unknown, string(f))
+			continue
+		}
+
+		parsed[f] = on
+	}
+
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	for f, on := range parsed {
+		g.enabled[f] = on
+	}
+
+	return unknown, nil
+}
+
+// Enabled returns true if the feature is enabled, an unknown feature is disabled
+func (g *FeatureGates) Enabled(f Feature) bool {
+	g.lock.RLock()
+	defer g.lock.RUnlock()
+
+	return g.enabled[f]
+}
+
+// String lists the gates as sorted Feature=bool pairs
+func (g *FeatureGates) String() string {
+	g.lock.RLock()
+	defer g.lock.RUnlock()
+
+	pairs := make([]string, 0, len(g.enabled))
+	for f, on := range g.enabled {
+		pairs = append(pairs, string(f)+"="+strconv.FormatBool(on))
+	}
+
+	sort.Strings(pairs)
+
+	return strings.Join(pairs, ",")
+}
+
+// FeatureEnabled returns true if the feature is enabled in the default gates
+func FeatureEnabled(f Feature) bool {
+	return DefaultFeatureGates.Enabled(f)
+}
@@ -0,0 +1,46 @@
+// Copyright 2019 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import (
+	"testing"
+
+	"github.com/onsi/gomega"
+)
+
+func TestFeatureGates(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	gates := NewFeatureGates()
+	g.Expect(gates.Enabled(AnnotationPropagation)).To(gomega.BeTrue())
+	g.Expect(gates.Enabled(Feature("Unknown"))).To(gomega.BeFalse())
+
+	unknown, err := gates.Set("AnnotationPropagation=false, Retired=true,")
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(unknown).To(gomega.Equal([]string{"Retired"}))
+	g.Expect(gates.Enabled(AnnotationPropagation)).To(gomega.BeFalse())
+	g.Expect(gates.String()).To(gomega.Equal("AnnotationPropagation=false"))
+
+	// a malformed pair applies nothing
+	_, err = gates.Set("AnnotationPropagation=true,Retired")
+	g.Expect(err).To(gomega.HaveOccurred())
+	_, err = gates.Set("AnnotationPropagation=yes")
+	g.Expect(err).To(gomega.HaveOccurred())
+	g.Expect(gates.Enabled(AnnotationPropagation)).To(gomega.BeFalse())
+
+	unknown, err = gates.Set("")
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(unknown).To(gomega.BeEmpty())
+}
@@ -0,0 +1,47 @@
+// Copyright 2019 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import (
+	"fmt"
+	"unicode"
+)
+
+// DefaultFieldManager is the field manager the controller writes the applications and their components with, it
+// owns the fields it sets in their managed fields
+const DefaultFieldManager = "multicloud-operators-application"
+
+// maxFieldManagerLength is the longest field manager the API server accepts
+const maxFieldManagerLength = 128
+
+// ValidateFieldManager checks the field manager is accepted by the API server, a non empty string of at most 128
+// printable characters
+func ValidateFieldManager(name string) error {
+	if name == "" {
+		return fmt.Errorf("the field manager is empty")
+	}
+
+	if len(name) > maxFieldManagerLength {
+		return fmt.Errorf("the field manager %q is longer than %d characters", name, maxFieldManagerLength)
+	}
+
+	for _, r := range name {
+		if !unicode.IsPrint(r) {
+			return fmt.Errorf("the field manager %q has the non printable character %U", name, r)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,33 @@
+// Copyright 2019 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/onsi/gomega"
+)
+
+func TestValidateFieldManager(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	g.Expect(ValidateFieldManager(DefaultFieldManager)).To(gomega.Succeed())
+	g.Expect(ValidateFieldManager("acm/application-controller")).To(gomega.Succeed())
+
+	g.Expect(ValidateFieldManager("")).NotTo(gomega.Succeed())
+	g.Expect(ValidateFieldManager(strings.Repeat("a", 129))).NotTo(gomega.Succeed())
+	g.Expect(ValidateFieldManager("application\ncontroller")).NotTo(gomega.Succeed())
+}
@@ -0,0 +1,110 @@
+// Copyright 2019 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// NamespacedResourceDiscovery lists the namespaced resources served by the cluster in their preferred version,
+// as discovery.DiscoveryInterface does
+type NamespacedResourceDiscovery interface {
+	ServerPreferredNamespacedResources() ([]*metav1.APIResourceList, error)
+}
+
+// DiscoverComponentGroupKinds returns the distinct group kinds of the resources of the namespace matching the
+// selector, sorted by group and kind, to populate the componentGroupKinds of an application. It only reads the
+// cluster, each listable kind is listed once for a single match. The kinds the discovery or the lists fail for
+// are left out and their errors aggregated, along the group kinds found in the other kinds.
+func DiscoverComponentGroupKinds(ctx context.Context, disc NamespacedResourceDiscovery, c client.Reader,
+	namespace string, selector labels.Selector) ([]metav1.GroupKind, error) {
+	// a partial discovery failure still returns the resources of the groups that succeeded
+	resources, err := disc.ServerPreferredNamespacedResources()
+
+	var errs []error
+	if err != nil {
+		errs = append(errs, err)
+	}
+
+	found := map[metav1.GroupKind]bool{}
+
+	for _, list := range resources {
+		gv, err := schema.ParseGroupVersion(list.GroupVersion)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		for _, res := range list.APIResources {
+			// the subresources, such as deployments/status, share the kind of their resource
+			if strings.Contains(res.Name, "/") || !hasVerb(res.Verbs, "list") {
+				continue
+			}
+
+			gk := metav1.GroupKind{Group: gv.Group, Kind: res.Kind}
+			if found[gk] {
+				continue
+			}
+
+			items := &unstructured.UnstructuredList{}
+			items.SetGroupVersionKind(gv.WithKind(res.Kind))
+
+			if err := c.List(ctx, items, client.InNamespace(namespace), client.MatchingLabelsSelector{Selector: selector},
+				client.Limit(1)); err != nil {
+				errs = append(errs, fmt.Errorf("failed to list %s: %w", gk.String(), err))
+				continue
+			}
+
+			if len(items.Items) > 0 {
+				found[gk] = true
+			}
+		}
+	}
+
+	gks := make([]metav1.GroupKind, 0, len(found))
+	for gk := range found {
+		gks = append(gks, gk)
+	}
+
+	sort.Slice(gks, func(i, j int) bool {
+		if gks[i].Group != gks[j].Group {
+			return gks[i].Group < gks[j].Group
+		}
+
+		return gks[i].Kind < gks[j].Kind
+	})
+
+	return gks, utilerrors.NewAggregate(errs)
+}
+
+func hasVerb(verbs metav1.Verbs, verb string) bool {
+	for _, v := range verbs {
+		if v == verb {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,88 @@
+// Copyright 2019 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+// failingClient fails the lists of the widgets, as a kind the operator is not permitted to list
+type failingClient struct {
+	client.Client
+}
+
+func (c *failingClient) List(ctx context.Context, list client.ObjectList, opts ...client.ListOption) error {
+	if list.GetObjectKind().GroupVersionKind().Kind == "Widget" {
+		return errors.New("forbidden")
+	}
+
+	return c.Client.List(ctx, list, opts...)
+}
+
+type staticDiscovery []*metav1.APIResourceList
+
+func (d staticDiscovery) ServerPreferredNamespacedResources() ([]*metav1.APIResourceList, error) {
+	return d, nil
+}
+
+func TestDiscoverComponentGroupKinds(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	matching := map[string]string{"app": "web"}
+
+	c := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(
+		&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default", Labels: matching}},
+		&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "db", Namespace: "default", Labels: map[string]string{"app": "db"}}},
+		&corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "other", Labels: matching}},
+		&appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default", Labels: matching}},
+	).Build()
+
+	disc := staticDiscovery{
+		{GroupVersion: "v1", APIResources: []metav1.APIResource{
+			{Name: "configmaps", Namespaced: true, Kind: "ConfigMap", Verbs: metav1.Verbs{"get", "list"}},
+			{Name: "secrets", Namespaced: true, Kind: "Secret", Verbs: metav1.Verbs{"get", "list"}},
+			{Name: "services", Namespaced: true, Kind: "Service", Verbs: metav1.Verbs{"get", "list"}},
+			{Name: "bindings", Namespaced: true, Kind: "Binding", Verbs: metav1.Verbs{"create"}},
+		}},
+		{GroupVersion: "apps/v1", APIResources: []metav1.APIResource{
+			{Name: "deployments", Namespaced: true, Kind: "Deployment", Verbs: metav1.Verbs{"get", "list"}},
+			{Name: "deployments/status", Namespaced: true, Kind: "Deployment", Verbs: metav1.Verbs{"get"}},
+		}},
+	}
+
+	gks, err := DiscoverComponentGroupKinds(context.TODO(), disc, c, "default", labels.SelectorFromSet(matching))
+	g.Expect(err).NotTo(gomega.HaveOccurred())
+	g.Expect(gks).To(gomega.Equal([]metav1.GroupKind{{Kind: "ConfigMap"}, {Group: "apps", Kind: "Deployment"}}))
+
+	// a kind failing to list is reported along the others
+	disc = append(disc, &metav1.APIResourceList{GroupVersion: "example.com/v1", APIResources: []metav1.APIResource{
+		{Name: "widgets", Namespaced: true, Kind: "Widget", Verbs: metav1.Verbs{"list"}},
+	}})
+
+	gks, err = DiscoverComponentGroupKinds(context.TODO(), disc, &failingClient{c}, "default", labels.SelectorFromSet(matching))
+	g.Expect(err).To(gomega.MatchError(gomega.ContainSubstring("failed to list Widget.example.com")))
+	g.Expect(gks).To(gomega.HaveLen(2))
+}
@@ -0,0 +1,77 @@
+// Copyright 2019 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"k8s.io/client-go/rest"
+)
+
+// ConfigIdentity returns the user the config authenticates as when the config alone tells it, the subject of a
+// service account token, as system:serviceaccount:<namespace>:<name>, or the basic auth user name. It is empty
+// for the other authentications, the token is decoded without being verified.
+func ConfigIdentity(cfg *rest.Config) string {
+	if cfg == nil {
+		return ""
+	}
+
+	token := cfg.BearerToken
+
+	if token == "" && cfg.BearerTokenFile != "" {
+		data, err := ioutil.ReadFile(filepath.Clean(cfg.BearerTokenFile))
+		if err == nil {
+			token = strings.TrimSpace(string(data))
+		}
+	}
+
+	if sub := tokenSubject(token); sub != "" {
+		return sub
+	}
+
+	return cfg.Username
+}
+
+// ServiceAccountUser returns the user name the API server authenticates the ServiceAccount as
+func ServiceAccountUser(namespace, name string) string {
+	return "system:serviceaccount:" + namespace + ":" + name
+}
+
+// tokenSubject returns the sub claim of a JWT, empty when the token is not a JWT
+func tokenSubject(token string) string {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return ""
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return ""
+	}
+
+	claims := struct {
+		Sub string `json:"sub"`
+	}{}
+
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return ""
+	}
+
+	return claims.Sub
+}
@@ -0,0 +1,35 @@
+// Copyright 2019 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import (
+	"encoding/base64"
+	"testing"
+
+	"github.com/onsi/gomega"
+	"k8s.io/client-go/rest"
+)
+
+func TestConfigIdentity(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	payload := base64.RawURLEncoding.EncodeToString([]byte(`{"sub":"system:serviceaccount:open-cluster-management:application"}`))
+
+	g.Expect(ConfigIdentity(&rest.Config{BearerToken: "header." + payload + ".signature"})).
+		To(gomega.Equal("system:serviceaccount:open-cluster-management:application"))
+	g.Expect(ConfigIdentity(&rest.Config{BearerToken: "opaque", Username: "admin"})).To(gomega.Equal("admin"))
+	g.Expect(ConfigIdentity(&rest.Config{})).To(gomega.BeEmpty())
+	g.Expect(ConfigIdentity(nil)).To(gomega.BeEmpty())
+}
@@ -47,7 +47,8 @@ func ConvertLabels(labelSelector *metav1.LabelSelector) (labels.Selector, error)
 
 // CheckAndInstallCRD checks if deployable belongs to this cluster
 // managed cluster annotation matches or no managed cluster annotation (local)
-func CheckAndInstallCRD(crdconfig *rest.Config, pathname string) error {
+// The mutations are applied to the CRD read from the file before it is compared to the installed one.
+func CheckAndInstallCRD(crdconfig *rest.Config, pathname string, mutations ...func(*crdv1.CustomResourceDefinition)) error {
 	var err error
 
 	crdClient, err := crdclientset.NewForConfig(crdconfig)
@@ -76,6 +77,10 @@ func CheckAndInstallCRD(crdconfig *rest.Config, pathname string) error {
 
 	klog.V(10).Info("Loaded Application CRD: ", crdobj, "\n - From - \n", string(crddata))
 
+	for _, mutate := range mutations {
+		mutate(&crdobj)
+	}
+
 	crd, err := crdClient.ApiextensionsV1().CustomResourceDefinitions().Get(context.TODO(), crdobj.GetName(), metav1.GetOptions{})
 	if errors.IsNotFound(err) {
 		klog.Info("Installing SIG Application CRD from File: ", pathname)
@@ -52,3 +52,10 @@ func TestEventlog(t *testing.T) {
 
 	time.Sleep(1 * time.Second)
 }
+
+func TestEventCorrelatorOptions(t *testing.T) {
+	g := gomega.NewGomegaWithT(t)
+
+	g.Expect(eventCorrelatorOptions(time.Minute).MaxIntervalInSeconds).To(gomega.Equal(60))
+	g.Expect(eventCorrelatorOptions(0).MaxIntervalInSeconds).To(gomega.Equal(600))
+}
@@ -15,11 +15,14 @@
 package webhook
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
 
+	"k8s.io/apimachinery/pkg/runtime"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
 
@@ -29,6 +32,7 @@ import (
 type AppValidator struct {
 	client.Client
 	decoder *admission.Decoder
+	opts    ValidatorOptions
 }
 
 // AppValidator denys a application creat/update if the application had bad input like this
@@ -43,13 +47,24 @@ func (v *AppValidator) Handle(ctx context.Context, req admission.Request) admiss
 	log.Info("entry webhook handle")
 	defer log.Info("exit webhook handle")
 
+	// the rules only match the applications resource, subresource requests such as status updates made by
+	// controllers are let through untouched should they reach the handler
+	if req.SubResource != "" {
+		return admission.Allowed("subresource " + req.SubResource + " is not validated")
+	}
+
+	// the requests failing to decode are bad requests carrying the decoder error, the validation failures denials
 	app := &appv1beta1.Application{}
 
-	err := v.decoder.Decode(req, app)
+	err := v.decode(req.Object, app)
 	if err != nil {
 		return admission.Errored(http.StatusBadRequest, err)
 	}
 
+	if isSelfTest(app, req.DryRun) {
+		return admission.Denied(selfTestDenial)
+	}
+
 	appJSON, err := json.Marshal(app)
 	if err != nil {
 		return admission.Denied(fmt.Sprint("convert to JSON failed: ", err))
@@ -62,7 +77,79 @@ func (v *AppValidator) Handle(ctx context.Context, req admission.Request) admiss
 		return admission.Denied(fmt.Sprint("Invalid application object: ", err))
 	}
 
-	return admission.Allowed("")
+	// the manifest checks are shared with offline validation, checks needing the cluster go after them
+	if errs := ValidateApplication(newApp, v.opts.Validation); len(errs) > 0 {
+		return admission.Denied(utilerrors.NewAggregate(errs).Error())
+	}
+
+	warnings := v.warnings(ctx, newApp)
+
+	oldApp, err := v.decodeOldApp(req)
+	if err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+
+	warnings = append(warnings, v.transitionWarnings(oldApp, newApp)...)
+
+	for _, c := range transitionChecks {
+		if err := c.check(oldApp, newApp); err != nil {
+			return admission.Denied(err.Error())
+		}
+	}
+
+	if err := v.opts.CELPolicy.Validate(oldApp, newApp); err != nil {
+		return admission.Denied(err.Error())
+	}
+
+	if resp := runValidators(ctx, oldApp, newApp); resp != nil {
+		return resp.WithWarnings(warnings...)
+	}
+
+	return admission.Allowed("").WithWarnings(warnings...)
+}
+
+// transitionChecks validate an application against the one it replaces, in order, oldApp is nil on create
+var transitionChecks = []struct {
+	name  string
+	check func(oldApp, newApp *appv1beta1.Application) error
+}{
+	{name: "assembly-phase-transition", check: validateAssemblyPhaseTransition},
+	{name: "component-kinds-not-emptied", check: validateComponentKindsNotEmptied},
+}
+
+// decodeOldApp returns the application being replaced by an update, nil on create
+func (v *AppValidator) decodeOldApp(req admission.Request) (*appv1beta1.Application, error) {
+	if len(req.OldObject.Raw) == 0 {
+		return nil, nil
+	}
+
+	oldApp := &appv1beta1.Application{}
+	if err := v.decoder.DecodeRaw(req.OldObject, oldApp); err != nil {
+		return nil, fmt.Errorf("decoding the old application failed: %w", err)
+	}
+
+	return oldApp, nil
+}
+
+// decode decodes the application of the request, rejecting the fields unknown to the Application type with
+// strict decoding. The error names the field failing to decode.
+func (v *AppValidator) decode(raw runtime.RawExtension, app *appv1beta1.Application) error {
+	if v.opts.StrictDecoding {
+		dec := json.NewDecoder(bytes.NewReader(raw.Raw))
+		dec.DisallowUnknownFields()
+
+		if err := dec.Decode(app); err != nil {
+			return fmt.Errorf("decoding the application failed: %w", err)
+		}
+
+		return nil
+	}
+
+	if err := v.decoder.DecodeRaw(raw, app); err != nil {
+		return fmt.Errorf("decoding the application failed: %w", err)
+	}
+
+	return nil
 }
 
 // AppValidator implements admission.DecoderInjector.
@@ -0,0 +1,527 @@
+// Copyright 2019 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webhook
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/stolostron/multicloud-operators-application/utils"
+	admissionv1 "k8s.io/api/admission/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	appv1beta1 "sigs.k8s.io/application/api/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+func newTestApp(annotations map[string]string, gks ...metav1.GroupKind) *appv1beta1.Application {
+	return &appv1beta1.Application{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "test-app",
+			Namespace:   "default",
+			Annotations: annotations,
+		},
+		Spec: appv1beta1.ApplicationSpec{
+			ComponentGroupKinds: gks,
+		},
+	}
+}
+
+func TestValidateRequiredComponents(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	deployGK := metav1.GroupKind{Group: "apps", Kind: "Deployment"}
+
+	app := newTestApp(nil, deployGK)
+	g.Expect(validateRequiredComponents(app)).Should(Succeed())
+
+	app = newTestApp(map[string]string{
+		utils.AnnotationRequiredComponents: `[{"group":"apps","kind":"Deployment","name":"api"}]`,
+	}, deployGK)
+	g.Expect(validateRequiredComponents(app)).Should(Succeed())
+
+	app = newTestApp(map[string]string{
+		utils.AnnotationRequiredComponents: `[{"kind":"Service","name":"api"}]`,
+	}, deployGK)
+	g.Expect(validateRequiredComponents(app)).ShouldNot(Succeed())
+
+	app = newTestApp(map[string]string{
+		utils.AnnotationRequiredComponents: `[{"group":"apps","kind":"Deployment"}]`,
+	}, deployGK)
+	g.Expect(validateRequiredComponents(app)).ShouldNot(Succeed())
+
+	app = newTestApp(map[string]string{
+		utils.AnnotationRequiredComponents: `not-json`,
+	}, deployGK)
+	g.Expect(validateRequiredComponents(app)).ShouldNot(Succeed())
+}
+
+func TestWarnings(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	v := &AppValidator{opts: ValidatorOptions{Warnings: AllWarnings}}
+
+	app := newTestApp(nil)
+	g.Expect(v.warnings(context.TODO(), app)).Should(HaveLen(3))
+
+	app = newTestApp(nil, metav1.GroupKind{Group: "apps", Kind: "Deployment"})
+	app.Spec.Descriptor.Type = "web"
+	app.Spec.Selector = &metav1.LabelSelector{MatchLabels: map[string]string{"app": "test-app"}}
+	g.Expect(v.warnings(context.TODO(), app)).Should(BeEmpty())
+
+	v.opts.Warnings = []string{WarningBroadSelector}
+	g.Expect(v.warnings(context.TODO(), newTestApp(nil))).Should(HaveLen(1))
+
+	g.Expect(validateWarningNames(AllWarnings)).Should(Succeed())
+	g.Expect(validateWarningNames([]string{"unknown"})).ShouldNot(Succeed())
+}
+
+func TestOwnerRefRemovalWarning(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	v := &AppValidator{opts: ValidatorOptions{Warnings: AllWarnings}}
+
+	oldApp := newTestApp(nil)
+	oldApp.Spec.AddOwnerRef = true
+	newApp := oldApp.DeepCopy()
+	g.Expect(v.transitionWarnings(nil, newApp)).Should(BeEmpty())
+	g.Expect(v.transitionWarnings(oldApp, newApp)).Should(BeEmpty())
+
+	newApp.Spec.AddOwnerRef = false
+	g.Expect(v.transitionWarnings(oldApp, newApp)).Should(HaveLen(1))
+	g.Expect(v.transitionWarnings(newApp, oldApp)).Should(BeEmpty())
+
+	v.opts.Warnings = []string{WarningBroadSelector}
+	g.Expect(v.transitionWarnings(oldApp, newApp)).Should(BeEmpty())
+}
+
+func TestLargeSelector(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	app := newTestApp(nil, metav1.GroupKind{Group: "apps", Kind: "Deployment"})
+	app.Spec.Descriptor.Type = "web"
+	app.Spec.Selector = &metav1.LabelSelector{MatchLabels: map[string]string{}}
+
+	for i := 0; i < 12; i++ {
+		app.Spec.Selector.MatchLabels[fmt.Sprintf("key-%d", i)] = "value"
+	}
+
+	v := &AppValidator{opts: ValidatorOptions{Warnings: AllWarnings}}
+	g.Expect(v.warnings(context.TODO(), app)).Should(BeEmpty())
+
+	v.opts.SelectorTermsWarning = DefaultSelectorTermsWarning
+	g.Expect(v.warnings(context.TODO(), app)).Should(ConsistOf(ContainSubstring("spec.selector has 12 label keys and expressions")))
+
+	g.Expect(ValidateApplication(app, ValidationOptions{})).Should(BeEmpty())
+	g.Expect(ValidateApplication(app, ValidationOptions{MaxSelectorTerms: 12})).Should(BeEmpty())
+
+	app.Annotations = map[string]string{utils.AnnotationFallbackSelectors: `[{"matchExpressions":[` +
+		strings.Repeat(`{"key":"tier","operator":"Exists"},`, 13) + `{"key":"tier","operator":"Exists"}]}]`}
+	g.Expect(ValidateApplication(app, ValidationOptions{MaxSelectorTerms: 12})).Should(ConsistOf(
+		MatchError(ContainSubstring("selector 0 has 14 label keys and expressions, more than the limit of 12"))))
+}
+
+func TestValidateRequiredLabels(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	app := newTestApp(nil, metav1.GroupKind{Group: "apps", Kind: "Deployment"})
+	app.Labels = map[string]string{"owner": "team-a"}
+
+	g.Expect(ValidateApplication(app, ValidationOptions{})).Should(BeEmpty())
+	g.Expect(ValidateApplication(app, ValidationOptions{RequiredLabels: []string{"owner"}})).Should(BeEmpty())
+
+	// the selector labels do not count
+	app.Spec.Selector = &metav1.LabelSelector{MatchLabels: map[string]string{"cost-center": "1234"}}
+	g.Expect(ValidateApplication(app, ValidationOptions{RequiredLabels: []string{"cost-center", "owner", "tier"}})).Should(ConsistOf(
+		MatchError("metadata.labels is missing the required labels cost-center, tier")))
+}
+
+func TestValidateInfo(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	app := newTestApp(nil)
+	app.Spec.Info = []appv1beta1.InfoItem{
+		{Name: "owner", Value: "team-a"},
+		{Name: "config", ValueFrom: &appv1beta1.InfoItemSource{Type: appv1beta1.ConfigMapKeyRefInfoItemSourceType}},
+	}
+	g.Expect(validateInfo(app)).Should(Succeed())
+
+	app.Spec.Info = append(app.Spec.Info, appv1beta1.InfoItem{Name: "owner", Value: "team-b"})
+	g.Expect(validateInfo(app)).Should(MatchError(ContainSubstring(`duplicated name "owner"`)))
+
+	app.Spec.Info = []appv1beta1.InfoItem{{Name: "empty"}}
+	g.Expect(validateInfo(app)).ShouldNot(Succeed())
+}
+
+func TestValidateComponentsConfigMap(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	for val, valid := range map[string]bool{
+		"inventory/components.yaml": true,
+		"inventory":                 false,
+		"Inventory/components":      false,
+		"inventory/bad key":         false,
+		"a/b/c":                     false,
+	} {
+		app := newTestApp(map[string]string{utils.AnnotationComponentsConfigMap: val})
+		_, found, err := utils.GetComponentsConfigMap(app)
+
+		g.Expect(found).Should(BeTrue())
+		g.Expect(err == nil).Should(Equal(valid), val)
+	}
+}
+
+func TestValidateApplication(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	app := newTestApp(nil, metav1.GroupKind{Group: "apps", Kind: "Deployment"})
+	app.Spec.Selector = &metav1.LabelSelector{MatchLabels: map[string]string{"app": "test-app"}}
+	app.Spec.Descriptor.Links = []appv1beta1.Link{{Description: "docs", URL: "https://example.com/docs"}}
+	g.Expect(ValidateApplication(app, ValidationOptions{})).Should(BeEmpty())
+
+	app.Spec.Selector.MatchExpressions = []metav1.LabelSelectorRequirement{{Key: "tier", Operator: "Bogus"}}
+	app.Spec.Descriptor.Links = append(app.Spec.Descriptor.Links, appv1beta1.Link{Description: "relative", URL: "docs/index.html"})
+	g.Expect(ValidateApplication(app, ValidationOptions{})).Should(HaveLen(2))
+
+	g.Expect(ValidateApplication(app, ValidationOptions{SkipChecks: []string{CheckSelector, CheckDescriptorLinks}})).Should(BeEmpty())
+
+	app = newTestApp(map[string]string{utils.AnnotationFallbackSelectors: `[{"matchLabels":{"app":"legacy"}}]`})
+	g.Expect(validateSelector(app)).Should(Succeed())
+
+	app.Annotations[utils.AnnotationFallbackSelectors] = `[{"matchExpressions":[{"key":"app","operator":"Bogus"}]}]`
+	g.Expect(validateSelector(app)).Should(MatchError(ContainSubstring("selector 0")))
+}
+
+func TestValidateHealthAggregation(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	g.Expect(validateHealthAggregation(newTestApp(nil))).Should(Succeed())
+	g.Expect(validateHealthAggregation(newTestApp(map[string]string{utils.AnnotationHealthAggregation: "Any"}))).Should(Succeed())
+	g.Expect(validateHealthAggregation(newTestApp(map[string]string{utils.AnnotationHealthAggregation: "any"}))).ShouldNot(Succeed())
+}
+
+func TestValidateReconcileInterval(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	g.Expect(validateReconcileInterval(newTestApp(nil))).Should(Succeed())
+	g.Expect(validateReconcileInterval(newTestApp(map[string]string{utils.AnnotationReconcileInterval: "30s"}))).Should(Succeed())
+	g.Expect(validateReconcileInterval(newTestApp(map[string]string{utils.AnnotationReconcileInterval: "30"}))).ShouldNot(Succeed())
+	g.Expect(validateReconcileInterval(newTestApp(map[string]string{utils.AnnotationReconcileInterval: "-1m"}))).
+		Should(MatchError(ContainSubstring("must be positive")))
+}
+
+func TestValidateHealthMaxComponents(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	g.Expect(validateHealthMaxComponents(newTestApp(nil))).Should(Succeed())
+	g.Expect(validateHealthMaxComponents(newTestApp(map[string]string{utils.AnnotationHealthMaxComponents: "0"}))).Should(Succeed())
+	g.Expect(validateHealthMaxComponents(newTestApp(map[string]string{utils.AnnotationHealthMaxComponents: "500"}))).Should(Succeed())
+	g.Expect(validateHealthMaxComponents(newTestApp(map[string]string{utils.AnnotationHealthMaxComponents: "many"}))).ShouldNot(Succeed())
+	g.Expect(validateHealthMaxComponents(newTestApp(map[string]string{utils.AnnotationHealthMaxComponents: "-1"}))).
+		Should(MatchError(ContainSubstring("must not be negative")))
+}
+
+func TestValidateHealthWeights(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	validate := func(weights string) error {
+		return validateHealthWeights(newTestApp(map[string]string{utils.AnnotationHealthWeights: weights}))
+	}
+
+	g.Expect(validateHealthWeights(newTestApp(nil))).Should(Succeed())
+	g.Expect(validate(`[{"group":"apps","kind":"Deployment","weight":2},{"kind":"Service","name":"metrics","weight":0}]`)).
+		Should(Succeed())
+	g.Expect(validate(`{"kind":"Service","weight":1}`)).ShouldNot(Succeed())
+	g.Expect(validate(`[{"group":"apps","weight":1}]`)).Should(MatchError(ContainSubstring("entry 0 requires a kind")))
+	g.Expect(validate(`[{"kind":"Service"}]`)).Should(MatchError(ContainSubstring("entry 0 requires a weight")))
+	g.Expect(validate(`[{"kind":"Service","weight":-1}]`)).Should(MatchError(ContainSubstring("must not be negative")))
+}
+
+func TestValidateTTLAfterSucceeded(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	validate := func(ttl string) error {
+		return validateTTLAfterSucceeded(newTestApp(map[string]string{utils.AnnotationTTLSecondsAfterSucceeded: ttl}))
+	}
+
+	g.Expect(validateTTLAfterSucceeded(newTestApp(nil))).Should(Succeed())
+	g.Expect(validate("0")).Should(Succeed())
+	g.Expect(validate("3600")).Should(Succeed())
+	g.Expect(validate("1h")).ShouldNot(Succeed())
+	g.Expect(validate("-1")).Should(MatchError(ContainSubstring("must not be negative")))
+}
+
+func TestValidateReservedLabels(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	app := newTestApp(nil, metav1.GroupKind{Group: "apps", Kind: "Deployment"})
+	g.Expect(validateReservedLabels(app)).Should(Succeed())
+
+	app.Spec.Selector = &metav1.LabelSelector{MatchLabels: map[string]string{"app": "web", utils.LabelPropagatedBy: "uid"}}
+	g.Expect(validateReservedLabels(app)).Should(MatchError(
+		"spec.selector uses the label key apps.open-cluster-management.io/propagated-by, " +
+			"the apps.open-cluster-management.io/ prefix is reserved for the operator"))
+
+	app = newTestApp(map[string]string{
+		utils.AnnotationFallbackSelectors: `[{"matchExpressions":[{"key":"apps.open-cluster-management.io/template-name","operator":"Exists"}]}]`,
+	}, metav1.GroupKind{Group: "apps", Kind: "Deployment"})
+	g.Expect(validateReservedLabels(app)).Should(MatchError(ContainSubstring("selector 0 uses the label key")))
+
+	app = newTestApp(map[string]string{utils.AnnotationPropagateLabels: `{"team":"a","apps.open-cluster-management.io/owner":"b"}`},
+		metav1.GroupKind{Group: "apps", Kind: "Deployment"})
+	g.Expect(validateReservedLabels(app)).Should(MatchError(ContainSubstring(utils.AnnotationPropagateLabels)))
+
+	app = newTestApp(map[string]string{utils.AnnotationPropagateKindLabels: `{"Deployment.apps":{"apps.open-cluster-management.io/owner":"b"}}`},
+		metav1.GroupKind{Group: "apps", Kind: "Deployment"})
+	g.Expect(validateReservedLabels(app)).Should(MatchError(ContainSubstring("kind Deployment.apps uses the label key")))
+
+	// the kinds are checked in order, the same reserved key is reported on every request
+	app = newTestApp(map[string]string{utils.AnnotationPropagateKindLabels: `{"Deployment.apps":{"apps.open-cluster-management.io/a":"b"},` +
+		`"StatefulSet.apps":{"apps.open-cluster-management.io/b":"b"},"ConfigMap":{"apps.open-cluster-management.io/c":"b"}}`},
+		metav1.GroupKind{Group: "apps", Kind: "Deployment"})
+
+	for i := 0; i < 10; i++ {
+		g.Expect(validateReservedLabels(app)).Should(MatchError(ContainSubstring("kind ConfigMap uses the label key")))
+	}
+}
+
+func TestValidateMode(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	g.Expect(validateMode(newTestApp(nil))).Should(Succeed())
+	g.Expect(validateMode(newTestApp(map[string]string{utils.AnnotationMode: utils.ModeInventory}))).Should(Succeed())
+	g.Expect(validateMode(newTestApp(map[string]string{utils.AnnotationMode: "inventory"}))).Should(
+		MatchError(ContainSubstring(`expected "Inventory" or empty`)))
+}
+
+func TestValidateDescriptorKeywords(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	app := newTestApp(nil)
+	app.Spec.Descriptor.Keywords = []string{"web", "frontend", "web"}
+	g.Expect(validateDescriptorKeywords(app)).Should(Succeed())
+	g.Expect(ValidateApplication(app, ValidationOptions{MaxKeywords: 2})).Should(BeEmpty())
+	g.Expect(ValidateApplication(app, ValidationOptions{MaxKeywords: 1})).Should(ConsistOf(
+		MatchError(ContainSubstring("spec.descriptor.keywords has 2 distinct keywords, more than the limit of 1"))))
+
+	app.Spec.Descriptor.Keywords = []string{"web", " "}
+	g.Expect(validateDescriptorKeywords(app)).Should(MatchError(ContainSubstring("spec.descriptor.keywords[1]: must not be empty")))
+}
+
+func TestValidateDescriptorIcons(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	app := newTestApp(nil)
+	app.Spec.Descriptor.Icons = []appv1beta1.ImageSpec{
+		{Source: "https://example.com/icon.png", Size: "64x64", Type: "image/png"},
+		{Source: "data:image/svg+xml;base64,PHN2Zz48L3N2Zz4="},
+	}
+	g.Expect(validateDescriptorIcons(app)).Should(Succeed())
+
+	app.Spec.Descriptor.Icons = append(app.Spec.Descriptor.Icons, appv1beta1.ImageSpec{Source: "icons/app.png"})
+	g.Expect(validateDescriptorIcons(app)).Should(MatchError(ContainSubstring("spec.descriptor.icons[2]: \"icons/app.png\" is not an absolute URL")))
+
+	app.Spec.Descriptor.Icons[2] = appv1beta1.ImageSpec{Source: "https://example.com/icon.png", Size: "64"}
+	g.Expect(validateDescriptorIcons(app)).Should(MatchError(ContainSubstring("spec.descriptor.icons[2]: invalid size \"64\"")))
+
+	app.Spec.Descriptor.Icons[2] = appv1beta1.ImageSpec{Source: "https://example.com/icon.png", Type: "image/"}
+	g.Expect(validateDescriptorIcons(app)).Should(MatchError(ContainSubstring("spec.descriptor.icons[2]: invalid type")))
+
+	app.Spec.Descriptor.Icons[2] = appv1beta1.ImageSpec{Source: "data:image/png"}
+	g.Expect(validateDescriptorIcons(app)).Should(MatchError(ContainSubstring("is not a valid data URL")))
+}
+
+func TestValidateLabelCleanupPolicy(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	g.Expect(validateLabelCleanupPolicy(newTestApp(nil))).Should(Succeed())
+	g.Expect(validateLabelCleanupPolicy(newTestApp(map[string]string{utils.AnnotationLabelCleanupPolicy: "Keep"}))).Should(Succeed())
+	g.Expect(validateLabelCleanupPolicy(newTestApp(map[string]string{utils.AnnotationLabelCleanupPolicy: "keep"}))).
+		Should(MatchError(ContainSubstring("expected one of Remove, Keep")))
+}
+
+func TestValidatePause(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	g.Expect(validatePause(newTestApp(nil))).Should(Succeed())
+	g.Expect(validatePause(newTestApp(map[string]string{utils.AnnotationPause: "true"}))).Should(Succeed())
+	g.Expect(validatePause(newTestApp(map[string]string{utils.AnnotationPause: "mutations"}))).Should(Succeed())
+	g.Expect(validatePause(newTestApp(map[string]string{utils.AnnotationPause: "status"}))).
+		Should(MatchError(ContainSubstring(`expected one of "", "true", "mutations"`)))
+}
+
+func TestValidateResolveAs(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	g.Expect(validateResolveAs(newTestApp(nil))).Should(Succeed())
+	g.Expect(validateResolveAs(newTestApp(map[string]string{utils.AnnotationResolveAs: "tenant-reader"}))).Should(Succeed())
+	g.Expect(validateResolveAs(newTestApp(map[string]string{utils.AnnotationResolveAs: "system:serviceaccount:ns:sa"}))).
+		Should(MatchError(ContainSubstring("invalid " + utils.AnnotationResolveAs)))
+}
+
+func TestValidatePropagateAnnotations(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	g.Expect(validatePropagateAnnotations(newTestApp(nil))).Should(Succeed())
+	g.Expect(validatePropagateAnnotations(newTestApp(map[string]string{
+		utils.AnnotationPropagateAnnotations: `["example.com/revision"]`,
+	}))).Should(Succeed())
+	g.Expect(validatePropagateAnnotations(newTestApp(map[string]string{
+		utils.AnnotationPropagateAnnotations: `["apps.open-cluster-management.io/template"]`,
+	}))).Should(MatchError(ContainSubstring("reserved")))
+	g.Expect(validatePropagateAnnotations(newTestApp(map[string]string{
+		utils.AnnotationPropagateAnnotations: `"example.com/revision"`,
+	}))).ShouldNot(Succeed())
+}
+
+func TestValidatePropagateLabels(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	app := newTestApp(map[string]string{
+		utils.AnnotationPropagateKindLabels: `{"Service":{"mesh":"enabled"}}`,
+	}, metav1.GroupKind{Group: "v1", Kind: "Service"})
+	g.Expect(validatePropagateLabels(app)).Should(Succeed())
+
+	app.Annotations[utils.AnnotationPropagateKindLabels] = `{"Deployment.apps":{"mesh":"enabled"}}`
+	g.Expect(validatePropagateLabels(app)).Should(MatchError(ContainSubstring("Deployment.apps")))
+
+	app.Annotations[utils.AnnotationPropagateKindLabels] = `{"Service":{"mesh":"not valid"}}`
+	g.Expect(validatePropagateLabels(app)).ShouldNot(Succeed())
+}
+
+func TestValidateComponentNamespaces(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	app := newTestApp(map[string]string{
+		utils.AnnotationComponentNamespaces: `{"Secret":"ns-b"}`,
+	}, metav1.GroupKind{Group: "v1", Kind: "Secret"})
+	g.Expect(validateComponentNamespaces(app)).Should(Succeed())
+
+	app.Annotations[utils.AnnotationComponentNamespaces] = `{"Deployment.apps":"ns-a"}`
+	g.Expect(validateComponentNamespaces(app)).Should(MatchError(ContainSubstring("Deployment.apps")))
+
+	app.Annotations[utils.AnnotationComponentNamespaces] = `{"Secret":"NS_B"}`
+	g.Expect(validateComponentNamespaces(app)).ShouldNot(Succeed())
+
+	app.Annotations[utils.AnnotationComponentNamespaces] = `["ns-b"]`
+	g.Expect(validateComponentNamespaces(app)).ShouldNot(Succeed())
+}
+
+func TestValidateComponentKinds(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	app := newTestApp(map[string]string{
+		utils.AnnotationHealthKinds: `[{"kind":"Pod"}]`,
+	}, metav1.GroupKind{Group: "apps", Kind: "Deployment"})
+	g.Expect(validateComponentKinds(app)).Should(Succeed())
+
+	app.Annotations[utils.AnnotationHealthKinds] = `[{"group":"apps"}]`
+	g.Expect(validateComponentKinds(app)).Should(MatchError(ContainSubstring("entry 0 requires a kind")))
+
+	app.Annotations[utils.AnnotationHealthKinds] = `{"kind":"Pod"}`
+	g.Expect(validateComponentKinds(app)).ShouldNot(Succeed())
+
+	delete(app.Annotations, utils.AnnotationHealthKinds)
+	app.Spec.ComponentGroupKinds = append(app.Spec.ComponentGroupKinds, metav1.GroupKind{Group: "apps"})
+	g.Expect(validateComponentKinds(app)).Should(MatchError(ContainSubstring("spec.componentKinds entry 1")))
+}
+
+func TestHandleStatusSubresource(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	// the decoder is not injected, a status update must not get as far as decoding
+	v := &AppValidator{}
+
+	resp := v.Handle(context.TODO(), admission.Request{AdmissionRequest: admissionv1.AdmissionRequest{
+		Operation:   admissionv1.Update,
+		SubResource: "status",
+		Object:      runtime.RawExtension{Raw: []byte(`{"status":{"componentsReady":"not-json-validated"}}`)},
+	}})
+	g.Expect(resp.Allowed).Should(BeTrue())
+	g.Expect(resp.Patches).Should(BeEmpty())
+
+	cfg := newValidatingWebhookCfg("svc", "validator", "default", ValidatorPath, nil)
+	for _, rule := range cfg.Webhooks[0].Rules {
+		g.Expect(rule.Resources).Should(Equal([]string{"applications"}))
+	}
+}
+
+func TestValidateAssemblyPhaseTransition(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	withPhase := func(phase appv1beta1.ApplicationAssemblyPhase) *appv1beta1.Application {
+		app := newTestApp(map[string]string{})
+		app.Spec.AssemblyPhase = phase
+
+		return app
+	}
+
+	g.Expect(validateAssemblyPhaseTransition(nil, withPhase(appv1beta1.Pending))).Should(Succeed())
+	g.Expect(validateAssemblyPhaseTransition(withPhase(appv1beta1.Pending), withPhase(appv1beta1.Succeeded))).Should(Succeed())
+	g.Expect(validateAssemblyPhaseTransition(withPhase(appv1beta1.Failed), withPhase(appv1beta1.Pending))).Should(Succeed())
+	g.Expect(validateAssemblyPhaseTransition(withPhase(""), withPhase(appv1beta1.Succeeded))).Should(Succeed())
+
+	g.Expect(validateAssemblyPhaseTransition(withPhase(appv1beta1.Succeeded), withPhase(appv1beta1.Pending))).
+		Should(MatchError(ContainSubstring("valid next phases are [Failed]")))
+	g.Expect(validateAssemblyPhaseTransition(withPhase(""), withPhase(appv1beta1.Pending))).ShouldNot(Succeed())
+
+	forced := withPhase(appv1beta1.Pending)
+	forced.Annotations[utils.AnnotationAllowAssemblyPhaseTransition] = "true"
+	g.Expect(validateAssemblyPhaseTransition(withPhase(appv1beta1.Succeeded), forced)).Should(Succeed())
+}
+
+func TestHandleDecodeFailure(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	s := runtime.NewScheme()
+	g.Expect(appv1beta1.AddToScheme(s)).Should(Succeed())
+
+	decoder, err := admission.NewDecoder(s)
+	g.Expect(err).ShouldNot(HaveOccurred())
+
+	request := func(raw string) admission.Request {
+		return admission.Request{AdmissionRequest: admissionv1.AdmissionRequest{
+			Operation: admissionv1.Create,
+			Object:    runtime.RawExtension{Raw: []byte(raw)},
+		}}
+	}
+
+	typo := `{"apiVersion":"app.k8s.io/v1beta1","kind":"Application","metadata":{"name":"app","namespace":"default"},` +
+		`"spec":{"selecter":{"matchLabels":{"app":"app"}}}}`
+
+	v := &AppValidator{}
+	g.Expect(v.InjectDecoder(decoder)).Should(Succeed())
+
+	resp := v.Handle(context.TODO(), request(`{"spec":{"selector":`))
+	g.Expect(resp.Allowed).Should(BeFalse())
+	g.Expect(resp.Result.Code).Should(Equal(int32(http.StatusBadRequest)))
+	g.Expect(resp.Result.Message).Should(HavePrefix("decoding the application failed"))
+
+	// the unknown fields are dropped without strict decoding, the application is then denied for its selector
+	resp = v.Handle(context.TODO(), request(typo))
+	g.Expect(resp.Allowed).Should(BeFalse())
+	g.Expect(resp.Result.Code).Should(Equal(int32(http.StatusForbidden)))
+
+	v.opts.StrictDecoding = true
+
+	resp = v.Handle(context.TODO(), request(typo))
+	g.Expect(resp.Allowed).Should(BeFalse())
+	g.Expect(resp.Result.Code).Should(Equal(int32(http.StatusBadRequest)))
+	g.Expect(resp.Result.Message).Should(ContainSubstring(`unknown field "selecter"`))
+}
@@ -0,0 +1,74 @@
+// Copyright 2019 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webhook
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/ghodss/yaml"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	appv1beta1 "sigs.k8s.io/application/api/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// DescriptorTypeCatalogValidatorName is the name the descriptor type catalog validator is registered with
+	DescriptorTypeCatalogValidatorName = "descriptor-type-catalog"
+	// DescriptorTypesKey is the key of the catalog ConfigMap holding the YAML list of allowed descriptor types
+	DescriptorTypesKey = "types.yaml"
+	// DescriptorCatalogURLKey is the optional key of the catalog ConfigMap pointing users at the catalog
+	DescriptorCatalogURLKey = "catalog-url"
+)
+
+// DescriptorTypeCatalogValidator denies the applications with a spec.descriptor.type missing from the catalog
+// ConfigMap. The ConfigMap is read from the API server on every admission of a new type, so the catalog changes
+// apply at once without caching the ConfigMaps of the cluster. An empty type, or a type left unchanged by an update, is allowed so shrinking the catalog does not
+// block the updates of existing applications.
+func DescriptorTypeCatalogValidator(c client.Reader, key types.NamespacedName) Validator {
+	return func(ctx context.Context, oldApp, newApp *appv1beta1.Application) (bool, string, error) {
+		appType := newApp.Spec.Descriptor.Type
+		if appType == "" || (oldApp != nil && oldApp.Spec.Descriptor.Type == appType) {
+			return true, "", nil
+		}
+
+		cm := &corev1.ConfigMap{}
+		if err := c.Get(ctx, key, cm); err != nil {
+			return false, "", fmt.Errorf("failed to get the descriptor type catalog configmap %s: %w", key, err)
+		}
+
+		var allowed []string
+		if err := yaml.Unmarshal([]byte(cm.Data[DescriptorTypesKey]), &allowed); err != nil {
+			return false, "", fmt.Errorf("invalid descriptor types in configmap %s: %w", key, err)
+		}
+
+		for _, t := range allowed {
+			if t == appType {
+				return true, "", nil
+			}
+		}
+
+		reason := fmt.Sprintf("spec.descriptor.type %q is not in the catalog, allowed types are [%s]", appType,
+			strings.Join(allowed, ", "))
+
+		if url := cm.Data[DescriptorCatalogURLKey]; url != "" {
+			reason += ", see " + url
+		}
+
+		return false, reason, nil
+	}
+}
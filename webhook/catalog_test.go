@@ -0,0 +1,73 @@
+// Copyright 2019 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webhook
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestDescriptorTypeCatalogValidator(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	key := types.NamespacedName{Namespace: "operator", Name: "catalog"}
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: key.Name, Namespace: key.Namespace},
+		Data: map[string]string{
+			DescriptorTypesKey:      "- web\n- batch\n",
+			DescriptorCatalogURLKey: "https://catalog.example.com",
+		},
+	}
+	c := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(cm).Build()
+
+	validate := DescriptorTypeCatalogValidator(c, key)
+
+	app := newTestApp(nil)
+	g.Expect(validate(context.TODO(), nil, app)).Should(BeTrue())
+
+	app.Spec.Descriptor.Type = "web"
+	g.Expect(validate(context.TODO(), nil, app)).Should(BeTrue())
+
+	typo := app.DeepCopy()
+	typo.Spec.Descriptor.Type = "wbe"
+
+	allowed, reason, err := validate(context.TODO(), app, typo)
+	g.Expect(err).ShouldNot(HaveOccurred())
+	g.Expect(allowed).Should(BeFalse())
+	g.Expect(reason).Should(ContainSubstring("allowed types are [web, batch]"))
+	g.Expect(reason).Should(ContainSubstring("https://catalog.example.com"))
+
+	// the catalog reloads, an existing application keeps its type across updates
+	cm.Data[DescriptorTypesKey] = "- batch\n"
+	g.Expect(c.Update(context.TODO(), cm)).Should(Succeed())
+
+	g.Expect(validate(context.TODO(), app, app.DeepCopy())).Should(BeTrue())
+
+	allowed, _, err = validate(context.TODO(), nil, app)
+	g.Expect(err).ShouldNot(HaveOccurred())
+	g.Expect(allowed).Should(BeFalse())
+
+	g.Expect(c.Delete(context.TODO(), cm)).Should(Succeed())
+
+	_, _, err = validate(context.TODO(), nil, app)
+	g.Expect(err).Should(HaveOccurred())
+}
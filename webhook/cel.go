@@ -0,0 +1,164 @@
+// Copyright 2019 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webhook
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ghodss/yaml"
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/checker/decls"
+	celtypes "github.com/google/cel-go/common/types"
+	exprpb "google.golang.org/genproto/googleapis/api/expr/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	appv1beta1 "sigs.k8s.io/application/api/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// CELRulesKey is the key of the rules ConfigMap holding the YAML list of rules
+const CELRulesKey = "rules.yaml"
+
+// CELRule is a CEL expression the applications must satisfy. The expression sees the application being admitted
+// as `object` and the application it replaces as `oldObject`, null on create, and must evaluate to a bool.
+type CELRule struct {
+	Name       string `json:"name"`
+	Expression string `json:"expression"`
+	// Message is the reason of the denial when the expression evaluates to false
+	Message string `json:"message"`
+}
+
+type compiledCELRule struct {
+	CELRule
+	program cel.Program
+}
+
+// CELPolicy is a compiled set of CEL rules, evaluated in order by the webhook after the built-in checks
+type CELPolicy struct {
+	rules []compiledCELRule
+}
+
+// CompileCELRules compiles the rules, any invalid rule fails the whole policy
+func CompileCELRules(rules []CELRule) (*CELPolicy, error) {
+	env, err := cel.NewEnv(cel.Declarations(
+		decls.NewVar("object", decls.Dyn),
+		decls.NewVar("oldObject", decls.Dyn),
+	))
+	if err != nil {
+		return nil, err
+	}
+
+	policy := &CELPolicy{}
+
+	for i, rule := range rules {
+		if rule.Name == "" || rule.Expression == "" {
+			return nil, fmt.Errorf("CEL rule %d requires both name and expression", i)
+		}
+
+		ast, iss := env.Compile(rule.Expression)
+		if iss.Err() != nil {
+			return nil, fmt.Errorf("CEL rule %s: %w", rule.Name, iss.Err())
+		}
+
+		if t := ast.ResultType(); t.GetPrimitive() != exprpb.Type_BOOL && t.GetDyn() == nil {
+			return nil, fmt.Errorf("CEL rule %s: the expression must evaluate to a bool", rule.Name)
+		}
+
+		prg, err := env.Program(ast)
+		if err != nil {
+			return nil, fmt.Errorf("CEL rule %s: %w", rule.Name, err)
+		}
+
+		policy.rules = append(policy.rules, compiledCELRule{CELRule: rule, program: prg})
+	}
+
+	return policy, nil
+}
+
+// LoadCELPolicy reads the rules from the ConfigMap and compiles them
+func LoadCELPolicy(ctx context.Context, c client.Reader, key types.NamespacedName) (*CELPolicy, error) {
+	cm := &corev1.ConfigMap{}
+	if err := c.Get(ctx, key, cm); err != nil {
+		return nil, fmt.Errorf("failed to get the CEL rules configmap %s: %w", key, err)
+	}
+
+	var rules []CELRule
+	if err := yaml.Unmarshal([]byte(cm.Data[CELRulesKey]), &rules); err != nil {
+		return nil, fmt.Errorf("invalid CEL rules in configmap %s: %w", key, err)
+	}
+
+	return CompileCELRules(rules)
+}
+
+// Names returns the names of the rules of the policy
+func (p *CELPolicy) Names() []string {
+	if p == nil {
+		return nil
+	}
+
+	names := make([]string, 0, len(p.rules))
+	for _, rule := range p.rules {
+		names = append(names, rule.Name)
+	}
+
+	return names
+}
+
+// Validate evaluates the rules in order and returns the denial of the first one not satisfied, oldApp is nil on create
+func (p *CELPolicy) Validate(oldApp, newApp *appv1beta1.Application) error {
+	if p == nil || len(p.rules) == 0 {
+		return nil
+	}
+
+	object, err := runtime.DefaultUnstructuredConverter.ToUnstructured(newApp)
+	if err != nil {
+		return err
+	}
+
+	var oldObject interface{}
+
+	if oldApp != nil {
+		if oldObject, err = runtime.DefaultUnstructuredConverter.ToUnstructured(oldApp); err != nil {
+			return err
+		}
+	}
+
+	vars := map[string]interface{}{"object": object, "oldObject": oldObject}
+
+	for _, rule := range p.rules {
+		out, _, err := rule.program.Eval(vars)
+		if err != nil {
+			return fmt.Errorf("CEL rule %s: %w", rule.Name, err)
+		}
+
+		allowed, ok := out.(celtypes.Bool)
+		if !ok {
+			return fmt.Errorf("CEL rule %s: the expression evaluated to %v rather than a bool", rule.Name, out)
+		}
+
+		if !allowed {
+			msg := rule.Message
+			if msg == "" {
+				msg = "failed " + rule.Expression
+			}
+
+			return fmt.Errorf("CEL rule %s: %s", rule.Name, msg)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,67 @@
+// Copyright 2019 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webhook
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestCELPolicy(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "rules", Namespace: "operator"},
+		Data: map[string]string{CELRulesKey: `
+- name: descriptor-type
+  expression: "has(object.spec.descriptor.type) && object.spec.descriptor.type != ''"
+  message: spec.descriptor.type is required
+- name: immutable-type
+  expression: "oldObject == null || object.spec.descriptor.type == oldObject.spec.descriptor.type"
+  message: spec.descriptor.type cannot change
+`},
+	}
+	c := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(cm).Build()
+
+	policy, err := LoadCELPolicy(context.TODO(), c, types.NamespacedName{Namespace: "operator", Name: "rules"})
+	g.Expect(err).Should(Succeed())
+	g.Expect(policy.Names()).Should(Equal([]string{"descriptor-type", "immutable-type"}))
+
+	app := newTestApp(nil)
+	g.Expect(policy.Validate(nil, app)).Should(MatchError(ContainSubstring("spec.descriptor.type is required")))
+
+	app.Spec.Descriptor.Type = "web"
+	g.Expect(policy.Validate(nil, app)).Should(Succeed())
+
+	updated := app.DeepCopy()
+	updated.Spec.Descriptor.Type = "batch"
+	g.Expect(policy.Validate(app, updated)).Should(MatchError(ContainSubstring("cannot change")))
+
+	_, err = CompileCELRules([]CELRule{{Name: "broken", Expression: "object.spec.("}})
+	g.Expect(err).ShouldNot(Succeed())
+
+	_, err = CompileCELRules([]CELRule{{Name: "not-bool", Expression: "'text'"}})
+	g.Expect(err).ShouldNot(Succeed())
+
+	var none *CELPolicy
+	g.Expect(none.Validate(nil, app)).Should(Succeed())
+}
@@ -145,9 +145,7 @@ func getSignedCert(clt client.Client, whKey types.NamespacedName,
 // GenerateWebhookCerts generate self singed CA and a signed cert pair. The
 // signed pair is stored at the certDir
 func GenerateWebhookCerts(clt client.Client, certDir string) ([]byte, error) {
-	if len(certDir) == 0 {
-		certDir = filepath.Join(os.TempDir(), "k8s-webhook-server", "application-serving-certs")
-	}
+	certDir = webhookCertDir(certDir)
 
 	podNs, err := findEnvVariable(podNamespaceEnvVar)
 	if err != nil {
@@ -172,19 +170,47 @@ func GenerateWebhookCerts(clt client.Client, certDir string) ([]byte, error) {
 		return nil, err
 	}
 
-	if err := os.MkdirAll(certDir, os.ModePerm); err != nil {
+	if err := writeWebhookCerts(certDir, ca, cert); err != nil {
 		return nil, err
 	}
 
+	return []byte(ca.Cert), nil
+}
+
+func webhookCertDir(certDir string) string {
+	if len(certDir) == 0 {
+		return filepath.Join(os.TempDir(), "k8s-webhook-server", "application-serving-certs")
+	}
+
+	return certDir
+}
+
+// writeWebhookCerts stores the serving pair and the CA that signed it at the certDir
+func writeWebhookCerts(certDir string, ca, cert Certificate) error {
+	if err := os.MkdirAll(certDir, os.ModePerm); err != nil {
+		return err
+	}
+
 	if err := ioutil.WriteFile(filepath.Join(certDir, tlsCrt), []byte(cert.Cert), os.FileMode(0600)); err != nil {
-		return nil, err
+		return err
 	}
 
 	if err := ioutil.WriteFile(filepath.Join(certDir, tlsKey), []byte(cert.Key), os.FileMode(0600)); err != nil {
-		return nil, err
+		return err
 	}
 
-	return []byte(ca.Cert), nil
+	return ioutil.WriteFile(filepath.Join(certDir, caCrt), []byte(ca.Cert), os.FileMode(0600))
+}
+
+// CABundle returns the PEM encoded CA bundle clients need to trust the webhook server, read from the certDir
+// the serving certificate was generated in, so it reflects the certificate currently served
+func CABundle(certDir string) ([]byte, error) {
+	bundle, err := ioutil.ReadFile(filepath.Join(webhookCertDir(certDir), caCrt))
+	if err != nil {
+		return nil, gerr.Wrap(err, "failed to read the webhook CA bundle")
+	}
+
+	return bundle, nil
 }
 
 // GenerateSelfSignedCACert generates a self signed CA
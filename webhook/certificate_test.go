@@ -16,7 +16,13 @@ package webhook
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"path/filepath"
+	"testing"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -136,3 +142,39 @@ var _ = Describe("self-sign cert", func() {
 		Expect(ca.Key).Should(Equal(key))
 	})
 })
+
+func TestCABundle(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	certDir := t.TempDir()
+
+	ca, err := GenerateSelfSignedCACert(certName)
+	g.Expect(err).Should(Succeed())
+
+	cert, err := GenerateSignedCert(WebhookServiceName, []string{"localhost"}, ca)
+	g.Expect(err).Should(Succeed())
+	g.Expect(writeWebhookCerts(certDir, ca, cert)).Should(Succeed())
+
+	// serve the pair from the certDir as the webhook server does
+	serving, err := tls.LoadX509KeyPair(filepath.Join(certDir, tlsCrt), filepath.Join(certDir, tlsKey))
+	g.Expect(err).Should(Succeed())
+
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	srv.TLS = &tls.Config{Certificates: []tls.Certificate{serving}, MinVersion: tls.VersionTLS12}
+	srv.StartTLS()
+
+	defer srv.Close()
+
+	bundle, err := CABundle(certDir)
+	g.Expect(err).Should(Succeed())
+
+	pool := x509.NewCertPool()
+	g.Expect(pool.AppendCertsFromPEM(bundle)).Should(BeTrue())
+
+	conn, err := tls.Dial("tcp", srv.Listener.Addr().String(), &tls.Config{RootCAs: pool, ServerName: "localhost", MinVersion: tls.VersionTLS12})
+	g.Expect(err).Should(Succeed())
+	g.Expect(conn.Close()).Should(Succeed())
+
+	_, err = CABundle(t.TempDir())
+	g.Expect(err).ShouldNot(Succeed())
+}
@@ -0,0 +1,48 @@
+// Copyright 2019 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webhook
+
+import (
+	"fmt"
+
+	"github.com/stolostron/multicloud-operators-application/utils"
+	appv1beta1 "sigs.k8s.io/application/api/v1beta1"
+)
+
+// validateComponentKindsNotEmptied rejects the applications created without componentGroupKinds and the updates
+// removing the last of them, such an application resolves nothing and is mostly an editing mistake. The
+// applications listing their components in a ConfigMap, the applications already without kinds and the
+// applications carrying the override annotation are let through. oldApp is nil on create.
+func validateComponentKindsNotEmptied(oldApp, newApp *appv1beta1.Application) error {
+	if len(newApp.Spec.ComponentGroupKinds) > 0 || newApp.GetAnnotations()[utils.AnnotationAllowEmptyComponentKinds] == "true" {
+		return nil
+	}
+
+	if _, found := newApp.GetAnnotations()[utils.AnnotationComponentsConfigMap]; found {
+		return nil
+	}
+
+	if oldApp == nil {
+		return fmt.Errorf("spec.componentKinds is empty, the application would resolve no component, set the %s "+
+			"annotation to \"true\" to create an empty inventory application on purpose", utils.AnnotationAllowEmptyComponentKinds)
+	}
+
+	if len(oldApp.Spec.ComponentGroupKinds) == 0 {
+		return nil
+	}
+
+	return fmt.Errorf("spec.componentKinds cannot be emptied, the application would resolve no component, set the %s "+
+		"annotation to \"true\" to keep an empty inventory application on purpose", utils.AnnotationAllowEmptyComponentKinds)
+}
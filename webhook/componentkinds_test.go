@@ -0,0 +1,46 @@
+// Copyright 2019 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webhook
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/stolostron/multicloud-operators-application/utils"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestValidateComponentKindsNotEmptied(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	deployment := metav1.GroupKind{Group: "apps", Kind: "Deployment"}
+
+	g.Expect(validateComponentKindsNotEmptied(nil, newTestApp(nil, deployment))).Should(Succeed())
+	g.Expect(validateComponentKindsNotEmptied(nil, newTestApp(nil))).
+		Should(MatchError(ContainSubstring("spec.componentKinds is empty")))
+	g.Expect(validateComponentKindsNotEmptied(newTestApp(nil, deployment), newTestApp(nil))).
+		Should(MatchError(ContainSubstring(utils.AnnotationAllowEmptyComponentKinds + " annotation to \"true\"")))
+
+	// the applications already without kinds are left alone
+	g.Expect(validateComponentKindsNotEmptied(newTestApp(nil), newTestApp(nil))).Should(Succeed())
+
+	// the applications listing their components in a ConfigMap do not need kinds
+	listed := newTestApp(map[string]string{utils.AnnotationComponentsConfigMap: "components/list"})
+	g.Expect(validateComponentKindsNotEmptied(nil, listed)).Should(Succeed())
+
+	inventory := newTestApp(map[string]string{utils.AnnotationAllowEmptyComponentKinds: "true"})
+	g.Expect(validateComponentKindsNotEmptied(nil, inventory)).Should(Succeed())
+	g.Expect(validateComponentKindsNotEmptied(newTestApp(nil, deployment), inventory)).Should(Succeed())
+}
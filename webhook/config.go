@@ -0,0 +1,261 @@
+// Copyright 2019 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webhook
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	admissionregistration "k8s.io/api/admissionregistration/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+)
+
+const (
+	// DebugConfigPath is served on the metrics address when debug endpoints are enabled
+	DebugConfigPath = "/debug/webhook-config"
+
+	webhookTimeoutSeconds = int32(30)
+	webhookFailurePolicy  = admissionregistration.Ignore
+)
+
+// ValidatorOptions are the operator level settings of the validating and mutating webhooks
+type ValidatorOptions struct {
+	// Warnings are the enabled warning checks, see AllWarnings
+	Warnings []string
+	// Validation tunes the cluster independent validation checks
+	Validation ValidationOptions
+	// CELPolicy optionally holds the CEL rules loaded at startup
+	CELPolicy *CELPolicy
+	// SelectorTermsWarning is the number of label keys and expressions of a selector past which the large-selector
+	// warning is returned, 0 disables it
+	SelectorTermsWarning int
+	// NormalizeComponentKinds registers the mutating webhook rewriting the component kinds to their canonical casing
+	NormalizeComponentKinds bool
+	// NormalizeKeywords registers the mutating webhook trimming and deduplicating the descriptor keywords
+	NormalizeKeywords bool
+	// LowercaseKeywords lowercases the descriptor keywords along their normalization
+	LowercaseKeywords bool
+	// AdmissionReviewVersions are the AdmissionReview versions the webhooks advertise, in order of preference,
+	// the API server sends the first one it supports and the response is written in the version of the request
+	AdmissionReviewVersions []string
+	// StrictDecoding rejects the applications carrying fields unknown to the Application type, as typos in their
+	// manifests. The API server prunes the unknown fields of a structural CRD before the webhook, they reach it
+	// with the CRDs preserving unknown fields.
+	StrictDecoding bool
+	// ObjectSelector scopes the webhooks to the applications of the operator instance, nil validates every
+	// application
+	ObjectSelector *metav1.LabelSelector
+	// SelfTest creates a dry-run application once the webhook is wired up and logs an error when the validating
+	// webhook did not deny it
+	SelfTest bool
+
+	// mapper is set as the webhook is wired up, the checks needing it are skipped without
+	mapper meta.RESTMapper
+	// reader lists the other applications, it is set as the webhook is wired up and the checks needing it are
+	// skipped without
+	reader client.Reader
+}
+
+// Options is populated from the command line before the webhook is wired up
+var Options = ValidatorOptions{
+	Warnings:                AllWarnings,
+	SelectorTermsWarning:    DefaultSelectorTermsWarning,
+	NormalizeComponentKinds: true,
+	NormalizeKeywords:       true,
+	AdmissionReviewVersions: DefaultAdmissionReviewVersions,
+}
+
+// DefaultAdmissionReviewVersions prefers v1 and keeps v1beta1 for the older API servers
+var DefaultAdmissionReviewVersions = []string{"v1", "v1beta1"}
+
+// ValidateAdmissionReviewVersions makes sure the AdmissionReview versions are known to the webhooks, without
+// duplicates
+func ValidateAdmissionReviewVersions(versions []string) error {
+	if len(versions) == 0 {
+		return fmt.Errorf("at least one admission review version is required, among %v", DefaultAdmissionReviewVersions)
+	}
+
+	seen := make(map[string]bool, len(versions))
+
+	for _, v := range versions {
+		if v != "v1" && v != "v1beta1" {
+			return fmt.Errorf("unsupported admission review version %q, expected v1 or v1beta1", v)
+		}
+
+		if seen[v] {
+			return fmt.Errorf("duplicated admission review version %q", v)
+		}
+
+		seen[v] = true
+	}
+
+	return nil
+}
+
+// ValidatorConfig describes the effective configuration of the application validating webhook
+type ValidatorConfig struct {
+	Port                    int      `json:"port"`
+	Path                    string   `json:"path"`
+	ServiceName             string   `json:"serviceName"`
+	ValidatorName           string   `json:"validatorName"`
+	FailurePolicy           string   `json:"failurePolicy"`
+	TimeoutSeconds          int32    `json:"timeoutSeconds"`
+	AdmissionReviewVersions []string `json:"admissionReviewVersions"`
+	Resources               []string `json:"resources"`
+	ObjectSelector          string   `json:"objectSelector,omitempty"`
+	Operations              []string `json:"operations"`
+	Checks                  []string `json:"checks"`
+	MaxSelectorTerms        int      `json:"maxSelectorTerms"`
+	SelectorTermsWarning    int      `json:"selectorTermsWarning"`
+	RequiredLabels          []string `json:"requiredLabels"`
+	MaxKeywords             int      `json:"maxKeywords"`
+	CELRules                []string `json:"celRules"`
+	Validators              []string `json:"validators"`
+	Warnings                []string `json:"warnings"`
+	MutatorPath             string   `json:"mutatorPath,omitempty"`
+	Mutations               []string `json:"mutations"`
+	LowercaseKeywords       bool     `json:"lowercaseKeywords"`
+}
+
+var webhookResources = []string{resourceName}
+
+// EffectiveConfig returns the configuration the webhook is currently running with
+func EffectiveConfig() ValidatorConfig {
+	return ValidatorConfig{
+		Port:                    WebhookPort,
+		Path:                    ValidatorPath,
+		ServiceName:             WebhookServiceName,
+		ValidatorName:           WebhookValidatorName,
+		FailurePolicy:           string(webhookFailurePolicy),
+		TimeoutSeconds:          webhookTimeoutSeconds,
+		AdmissionReviewVersions: Options.AdmissionReviewVersions,
+		Resources:               webhookResources,
+		ObjectSelector:          formatObjectSelector(),
+		Operations:              []string{string(admissionregistration.Create), string(admissionregistration.Update)},
+		Checks:                  effectiveChecks(),
+		MaxSelectorTerms:        Options.Validation.MaxSelectorTerms,
+		SelectorTermsWarning:    Options.SelectorTermsWarning,
+		RequiredLabels:          Options.Validation.RequiredLabels,
+		MaxKeywords:             Options.Validation.MaxKeywords,
+		CELRules:                Options.CELPolicy.Names(),
+		Validators:              registeredValidatorNames(),
+		Warnings:                Options.Warnings,
+		MutatorPath:             enabledMutatorPath(),
+		Mutations:               enabledMutations(),
+		LowercaseKeywords:       Options.NormalizeKeywords && Options.LowercaseKeywords,
+	}
+}
+
+// effectiveChecks lists the checks the validating webhook runs, in order
+func effectiveChecks() []string {
+	checks := append([]string{decodeCheckName(), "json-roundtrip"}, Options.Validation.enabledChecks()...)
+
+	for _, c := range transitionChecks {
+		checks = append(checks, c.name)
+	}
+
+	return checks
+}
+
+// formatObjectSelector renders the selector scoping the webhooks, empty when they validate every application
+func formatObjectSelector() string {
+	if Options.ObjectSelector == nil {
+		return ""
+	}
+
+	return metav1.FormatLabelSelector(Options.ObjectSelector)
+}
+
+// decodeCheckName names the decoding of the applications, strict when it rejects the unknown fields
+func decodeCheckName() string {
+	if Options.StrictDecoding {
+		return "strict-decode"
+	}
+
+	return "decode"
+}
+
+func enabledMutations() []string {
+	var mutations []string
+
+	if Options.NormalizeComponentKinds {
+		mutations = append(mutations, MutationNormalizeComponentKinds)
+	}
+
+	if Options.NormalizeKeywords {
+		mutations = append(mutations, MutationNormalizeKeywords)
+	}
+
+	return mutations
+}
+
+func enabledMutatorPath() string {
+	if len(enabledMutations()) == 0 {
+		return ""
+	}
+
+	return MutatorPath
+}
+
+// LogEffectiveConfig dumps the effective webhook configuration to the log
+func LogEffectiveConfig() {
+	cfg, err := json.Marshal(EffectiveConfig())
+	if err != nil {
+		log.Error(err, "failed to marshal webhook configuration")
+		return
+	}
+
+	log.Info("effective webhook configuration", "config", string(cfg))
+}
+
+type debugConfigHandler struct {
+	token string
+}
+
+// RegisterDebugHandler serves the effective webhook configuration as JSON on the manager's metrics server. The
+// metrics server listens on every interface of the pod, the requests must carry the token as a bearer token.
+func RegisterDebugHandler(mgr manager.Manager, token string) error {
+	if token == "" {
+		return errors.New("the webhook debug endpoint requires a token")
+	}
+
+	return mgr.AddMetricsExtraHandler(DebugConfigPath, &debugConfigHandler{token: token})
+}
+
+func (h *debugConfigHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", http.MethodGet)
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+
+		return
+	}
+
+	if subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), []byte("Bearer "+h.token)) != 1 {
+		http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	if err := json.NewEncoder(w).Encode(EffectiveConfig()); err != nil {
+		log.Error(err, "failed to write webhook configuration")
+	}
+}
@@ -0,0 +1,180 @@
+// Copyright 2019 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/stolostron/multicloud-operators-application/pkg/apis"
+	admissionregistration "k8s.io/api/admissionregistration/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	appv1beta1 "sigs.k8s.io/application/api/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+)
+
+func TestServeEffectiveConfig(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	h := &debugConfigHandler{token: "secret"}
+
+	get := func(method, authorization string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, DebugConfigPath, nil)
+		if authorization != "" {
+			req.Header.Set("Authorization", authorization)
+		}
+
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+
+		return rec
+	}
+
+	rec := get(http.MethodGet, "Bearer secret")
+	g.Expect(rec.Code).Should(Equal(http.StatusOK))
+
+	got := ValidatorConfig{}
+	g.Expect(json.Unmarshal(rec.Body.Bytes(), &got)).Should(Succeed())
+	g.Expect(got).Should(Equal(EffectiveConfig()))
+	g.Expect(got.Checks).Should(ContainElements("assembly-phase-transition", "component-kinds-not-emptied"))
+
+	g.Expect(get(http.MethodGet, "").Code).Should(Equal(http.StatusUnauthorized))
+	g.Expect(get(http.MethodGet, "Bearer other").Code).Should(Equal(http.StatusUnauthorized))
+	g.Expect(get(http.MethodPost, "Bearer secret").Code).Should(Equal(http.StatusMethodNotAllowed))
+
+	g.Expect(RegisterDebugHandler(nil, "")).ShouldNot(Succeed())
+}
+
+func TestValidateAdmissionReviewVersions(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	g.Expect(ValidateAdmissionReviewVersions(DefaultAdmissionReviewVersions)).Should(Succeed())
+	g.Expect(ValidateAdmissionReviewVersions([]string{"v1"})).Should(Succeed())
+	g.Expect(ValidateAdmissionReviewVersions(nil)).ShouldNot(Succeed())
+	g.Expect(ValidateAdmissionReviewVersions([]string{"v1", "v1"})).Should(MatchError(ContainSubstring("duplicated")))
+	g.Expect(ValidateAdmissionReviewVersions([]string{"v2"})).Should(MatchError(ContainSubstring("unsupported")))
+
+	cfg := newValidatingWebhookCfg("svc", "validator", "default", ValidatorPath, nil)
+	g.Expect(cfg.Webhooks[0].AdmissionReviewVersions).Should(Equal([]string{"v1", "v1beta1"}))
+}
+
+func TestServeAdmissionReviewVersions(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	s := runtime.NewScheme()
+	g.Expect(appv1beta1.AddToScheme(s)).Should(Succeed())
+
+	wh := &webhook.Admission{Handler: &AppValidator{}}
+	g.Expect(wh.InjectScheme(s)).Should(Succeed())
+	g.Expect(wh.InjectLogger(log)).Should(Succeed())
+
+	obj, err := json.Marshal(newTestApp(nil, metav1.GroupKind{Group: "apps", Kind: "Deployment"}))
+	g.Expect(err).ShouldNot(HaveOccurred())
+
+	// the response is written in the AdmissionReview version of the request
+	for _, version := range []string{"v1", "v1beta1"} {
+		body := fmt.Sprintf(`{"apiVersion":"admission.k8s.io/%s","kind":"AdmissionReview",`+
+			`"request":{"uid":"42","operation":"CREATE","object":%s}}`, version, obj)
+
+		req := httptest.NewRequest(http.MethodPost, ValidatorPath, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+
+		rec := httptest.NewRecorder()
+		wh.ServeHTTP(rec, req)
+
+		g.Expect(rec.Code).Should(Equal(http.StatusOK))
+
+		review := map[string]interface{}{}
+		g.Expect(json.Unmarshal(rec.Body.Bytes(), &review)).Should(Succeed())
+		g.Expect(review["apiVersion"]).Should(Equal("admission.k8s.io/" + version))
+
+		resp, ok := review["response"].(map[string]interface{})
+		g.Expect(ok).Should(BeTrue(), rec.Body.String())
+		g.Expect(resp["uid"]).Should(Equal("42"))
+		g.Expect(resp["allowed"]).Should(BeTrue(), rec.Body.String())
+	}
+}
+
+func TestCreateOrUpdateValidatingWebhook(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	defer func() { apis.ApplicationGroupVersion = appv1beta1.GroupVersion }()
+
+	existing := newValidatingWebhookCfg("svc", "validator", "old-namespace", ValidatorPath, []byte("old-ca"))
+	existing.Webhooks[0].TimeoutSeconds = nil
+	c := fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(existing).Build()
+
+	g.Expect(apis.SetApplicationGroupVersion("app.example.io/v1alpha1")).Should(Succeed())
+	g.Expect(createOrUpdateValiatingWebhook(c, "svc", "validator", "default", ValidatorPath, []byte("ca"))).Should(Succeed())
+
+	validator := &admissionregistration.ValidatingWebhookConfiguration{}
+	g.Expect(c.Get(context.TODO(), types.NamespacedName{Name: "validator"}, validator)).Should(Succeed())
+	g.Expect(validator.Webhooks).Should(HaveLen(1))
+	g.Expect(validator.Webhooks[0].Rules[0].APIGroups).Should(Equal([]string{"app.example.io"}))
+	g.Expect(validator.Webhooks[0].Rules[0].APIVersions).Should(Equal([]string{"v1alpha1"}))
+	g.Expect(validator.Webhooks[0].ClientConfig.Service.Namespace).Should(Equal("default"))
+	g.Expect(validator.Webhooks[0].ClientConfig.CABundle).Should(Equal([]byte("ca")))
+	g.Expect(*validator.Webhooks[0].TimeoutSeconds).Should(Equal(webhookTimeoutSeconds))
+}
+
+func TestEffectiveConfigThresholds(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	defer func(opts ValidatorOptions) { Options = opts }(Options)
+
+	Options.Validation.MaxSelectorTerms = 20
+	Options.SelectorTermsWarning = 8
+	Options.Validation.RequiredLabels = []string{"team", "cost-center"}
+	Options.Validation.MaxKeywords = 10
+	Options.LowercaseKeywords = true
+
+	cfg := EffectiveConfig()
+	g.Expect(cfg.MaxSelectorTerms).Should(Equal(20))
+	g.Expect(cfg.SelectorTermsWarning).Should(Equal(8))
+	g.Expect(cfg.RequiredLabels).Should(Equal([]string{"team", "cost-center"}))
+	g.Expect(cfg.MaxKeywords).Should(Equal(10))
+	g.Expect(cfg.LowercaseKeywords).Should(BeTrue())
+
+	// the keywords are only lowercased along their normalization
+	Options.NormalizeKeywords = false
+	g.Expect(EffectiveConfig().LowercaseKeywords).Should(BeFalse())
+}
+
+func TestObjectSelector(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	defer func() { Options.ObjectSelector = nil }()
+
+	g.Expect(EffectiveConfig().ObjectSelector).Should(BeEmpty())
+	g.Expect(newValidatingWebhookCfg("svc", "validator", "default", ValidatorPath, nil).Webhooks[0].ObjectSelector).Should(BeNil())
+
+	Options.ObjectSelector = &metav1.LabelSelector{MatchLabels: map[string]string{"operator": "team-a"}}
+
+	g.Expect(EffectiveConfig().ObjectSelector).Should(Equal("operator=team-a"))
+	g.Expect(newValidatingWebhookCfg("svc", "validator", "default", ValidatorPath, nil).Webhooks[0].ObjectSelector).
+		Should(Equal(Options.ObjectSelector))
+	g.Expect(newMutatingWebhookCfg("svc", "mutator", "default", MutatorPath, nil).Webhooks[0].ObjectSelector).
+		Should(Equal(Options.ObjectSelector))
+}
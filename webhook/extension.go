@@ -0,0 +1,97 @@
+// Copyright 2019 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webhook
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+
+	admissionregistration "k8s.io/api/admissionregistration/v1"
+	appv1beta1 "sigs.k8s.io/application/api/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// Validator is a custom admission rule run after the built-in checks, oldApp is nil on create.
+// A validator returning an error neither allows nor denies, the webhook failure policy decides.
+type Validator func(ctx context.Context, oldApp, newApp *appv1beta1.Application) (allowed bool, reason string, err error)
+
+type namedValidator struct {
+	name string
+	fn   Validator
+}
+
+var (
+	validatorsLock sync.RWMutex
+	validators     []namedValidator
+)
+
+// RegisterValidator appends a custom admission rule, the validators run in registration order and the first
+// deny wins. Builds extending the operator are expected to register their validators before the webhook is
+// wired up, registering a name again replaces the validator in place.
+func RegisterValidator(name string, fn Validator) {
+	validatorsLock.Lock()
+	defer validatorsLock.Unlock()
+
+	for i := range validators {
+		if validators[i].name == name {
+			validators[i].fn = fn
+			return
+		}
+	}
+
+	validators = append(validators, namedValidator{name: name, fn: fn})
+}
+
+func registeredValidatorNames() []string {
+	validatorsLock.RLock()
+	defer validatorsLock.RUnlock()
+
+	names := make([]string, 0, len(validators))
+	for _, v := range validators {
+		names = append(names, v.name)
+	}
+
+	return names
+}
+
+// runValidators runs the registered validators, it returns nil when all of them allow the application
+func runValidators(ctx context.Context, oldApp, newApp *appv1beta1.Application) *admission.Response {
+	validatorsLock.RLock()
+	registered := append([]namedValidator(nil), validators...)
+	validatorsLock.RUnlock()
+
+	for _, v := range registered {
+		allowed, reason, err := v.fn(ctx, oldApp, newApp)
+		if err != nil {
+			log.Error(err, "custom validator failed", "validator", v.name, "application", newApp.Namespace+"/"+newApp.Name)
+
+			if webhookFailurePolicy == admissionregistration.Fail {
+				resp := admission.Errored(http.StatusInternalServerError, fmt.Errorf("validator %s: %w", v.name, err))
+				return &resp
+			}
+
+			continue
+		}
+
+		if !allowed {
+			resp := admission.Denied(fmt.Sprintf("%s: %s", v.name, reason))
+			return &resp
+		}
+	}
+
+	return nil
+}
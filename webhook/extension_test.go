@@ -0,0 +1,57 @@
+// Copyright 2019 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webhook
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	appv1beta1 "sigs.k8s.io/application/api/v1beta1"
+)
+
+func TestRunValidators(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	defer func() { validators = nil }()
+
+	var calls []string
+
+	RegisterValidator("broken", func(ctx context.Context, oldApp, newApp *appv1beta1.Application) (bool, string, error) {
+		calls = append(calls, "broken")
+		return false, "", errors.New("policy backend unavailable")
+	})
+	RegisterValidator("owner", func(ctx context.Context, oldApp, newApp *appv1beta1.Application) (bool, string, error) {
+		calls = append(calls, "owner")
+		return newApp.Labels["owner"] != "", "the owner label is required", nil
+	})
+	RegisterValidator("never-reached", func(ctx context.Context, oldApp, newApp *appv1beta1.Application) (bool, string, error) {
+		calls = append(calls, "never-reached")
+		return true, "", nil
+	})
+
+	app := newTestApp(nil)
+
+	resp := runValidators(context.TODO(), nil, app)
+	g.Expect(resp).ShouldNot(BeNil())
+	g.Expect(resp.Allowed).Should(BeFalse())
+	g.Expect(string(resp.Result.Reason)).Should(ContainSubstring("the owner label is required"))
+	g.Expect(calls).Should(Equal([]string{"broken", "owner"}))
+
+	app.Labels = map[string]string{"owner": "team-a"}
+	g.Expect(runValidators(context.TODO(), nil, app)).Should(BeNil())
+	g.Expect(registeredValidatorNames()).Should(Equal([]string{"broken", "owner", "never-reached"}))
+}
@@ -0,0 +1,70 @@
+// Copyright 2019 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webhook
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/stolostron/multicloud-operators-application/utils"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	appv1beta1 "sigs.k8s.io/application/api/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// ApplicationHierarchyValidatorName is the name the application hierarchy validator is registered with
+const ApplicationHierarchyValidatorName = "application-hierarchy"
+
+// ApplicationHierarchyValidator keeps the application hierarchy two levels deep, which rules out the cycles: the
+// parent of an application cannot have a parent itself, and an application with children cannot have a parent.
+// A parent that does not exist yet is allowed, the children are rolled up once it is created.
+func ApplicationHierarchyValidator(c client.Reader) Validator {
+	return func(ctx context.Context, oldApp, newApp *appv1beta1.Application) (bool, string, error) {
+		// an invalid parent is denied by the built-in checks
+		parentName, err := utils.GetParent(newApp)
+		if err != nil || parentName == "" {
+			return true, "", nil
+		}
+
+		parent := &appv1beta1.Application{}
+
+		err = c.Get(ctx, types.NamespacedName{Namespace: newApp.Namespace, Name: parentName}, parent)
+		if err != nil && !errors.IsNotFound(err) {
+			return false, "", fmt.Errorf("failed to get the parent application %s: %w", parentName, err)
+		}
+
+		if err == nil {
+			if grandparent, _ := utils.GetParent(parent); grandparent != "" {
+				return false, fmt.Sprintf("the parent application %s has the parent %s, the application hierarchy is "+
+					"two levels deep", parentName, grandparent), nil
+			}
+		}
+
+		apps := &appv1beta1.ApplicationList{}
+		if err := c.List(ctx, apps, client.InNamespace(newApp.Namespace)); err != nil {
+			return false, "", fmt.Errorf("failed to list the applications in namespace %s: %w", newApp.Namespace, err)
+		}
+
+		for i := range apps.Items {
+			if child, _ := utils.GetParent(&apps.Items[i]); child == newApp.Name {
+				return false, fmt.Sprintf("the application is the parent of %s and cannot have a parent, the application "+
+					"hierarchy is two levels deep", apps.Items[i].Name), nil
+			}
+		}
+
+		return true, "", nil
+	}
+}
@@ -0,0 +1,62 @@
+// Copyright 2019 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webhook
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/stolostron/multicloud-operators-application/utils"
+	"k8s.io/apimachinery/pkg/runtime"
+	appv1beta1 "sigs.k8s.io/application/api/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestApplicationHierarchyValidator(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	s := runtime.NewScheme()
+	g.Expect(appv1beta1.AddToScheme(s)).Should(Succeed())
+
+	parent := newTestApp(nil)
+	parent.Name = "parent"
+
+	child := newTestApp(map[string]string{utils.AnnotationParent: "parent"})
+	child.Name = "child"
+
+	c := fake.NewClientBuilder().WithScheme(s).WithObjects(parent, child).Build()
+	validate := ApplicationHierarchyValidator(c)
+
+	g.Expect(validate(context.TODO(), nil, newTestApp(nil))).Should(BeTrue())
+	g.Expect(validate(context.TODO(), nil, newTestApp(map[string]string{utils.AnnotationParent: "parent"}))).Should(BeTrue())
+	g.Expect(validate(context.TODO(), nil, newTestApp(map[string]string{utils.AnnotationParent: "missing"}))).Should(BeTrue())
+
+	// the child is a parent already
+	allowed, reason, err := validate(context.TODO(), nil, newTestApp(map[string]string{utils.AnnotationParent: "child"}))
+	g.Expect(err).ShouldNot(HaveOccurred())
+	g.Expect(allowed).Should(BeFalse())
+	g.Expect(reason).Should(ContainSubstring("has the parent parent"))
+
+	// the parent would close a cycle through its child
+	cycle := parent.DeepCopy()
+	cycle.Annotations = map[string]string{utils.AnnotationParent: "child"}
+
+	allowed, _, err = validate(context.TODO(), parent, cycle)
+	g.Expect(err).ShouldNot(HaveOccurred())
+	g.Expect(allowed).Should(BeFalse())
+
+	g.Expect(validateParent(newTestApp(map[string]string{utils.AnnotationParent: "test-app"}))).ShouldNot(Succeed())
+}
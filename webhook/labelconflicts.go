@@ -0,0 +1,194 @@
+// Copyright 2019 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webhook
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/stolostron/multicloud-operators-application/utils"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/selection"
+	"k8s.io/apimachinery/pkg/util/sets"
+	appv1beta1 "sigs.k8s.io/application/api/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// propagatedLabelConflicts lists the other applications of the namespace propagating another value of a label
+// the application propagates, to a kind both list, with selectors that may match the same components. The
+// detection is conservative: the selectors overlap unless a label key they share cannot match the same value.
+func propagatedLabelConflicts(ctx context.Context, reader client.Reader, app *appv1beta1.Application) []string {
+	propagated, err := utils.GetPropagatedLabels(app)
+	if reader == nil || err != nil || propagated == nil {
+		return nil
+	}
+
+	apps := &appv1beta1.ApplicationList{}
+	if err := reader.List(ctx, apps, client.InNamespace(app.Namespace)); err != nil {
+		log.Error(err, "failed to list the applications to check the propagated labels against", "namespace", app.Namespace)
+		return nil
+	}
+
+	selectors := applicationSelectors(app)
+	kinds := componentKinds(app)
+
+	var conflicts []string
+
+	for i := range apps.Items {
+		other := &apps.Items[i]
+		if other.Name == app.Name {
+			continue
+		}
+
+		otherPropagated, err := utils.GetPropagatedLabels(other)
+		if err != nil || otherPropagated == nil || !selectorsOverlap(selectors, applicationSelectors(other)) {
+			continue
+		}
+
+		if conflict := labelConflict(propagated, otherPropagated, kinds, componentKinds(other)); conflict != "" {
+			conflicts = append(conflicts, fmt.Sprintf("%s, application %s", conflict, other.Name))
+		}
+	}
+
+	sort.Strings(conflicts)
+
+	return conflicts
+}
+
+// labelConflict describes the first label both propagate with different values to a kind both list, empty when
+// there is none
+func labelConflict(propagated, otherPropagated *utils.PropagatedLabels, kinds, otherKinds map[metav1.GroupKind]bool) string {
+	shared := make([]metav1.GroupKind, 0, len(kinds))
+
+	for gk := range kinds {
+		if otherKinds[gk] {
+			shared = append(shared, gk)
+		}
+	}
+
+	sort.Slice(shared, func(i, j int) bool { return shared[i].String() < shared[j].String() })
+
+	for _, gk := range shared {
+		mine := propagated.ForKind(schema.GroupKind{Group: gk.Group, Kind: gk.Kind})
+		theirs := otherPropagated.ForKind(schema.GroupKind{Group: gk.Group, Kind: gk.Kind})
+
+		for _, key := range sortedKeys(mine) {
+			if value, ok := theirs[key]; ok && value != mine[key] {
+				return fmt.Sprintf("%s=%s on the %s components against %s=%s", key, mine[key], gk.String(), key, value)
+			}
+		}
+	}
+
+	return ""
+}
+
+// applicationSelectors returns spec.selector and the fallback selectors of the application, spec.selector alone
+// when the fallback selectors are invalid
+func applicationSelectors(app *appv1beta1.Application) []*metav1.LabelSelector {
+	selectors, err := utils.GetSelectors(app)
+	if err != nil {
+		return []*metav1.LabelSelector{app.Spec.Selector}
+	}
+
+	return selectors
+}
+
+// selectorsOverlap returns true if any selector of one application may match the components of a selector of the
+// other
+func selectorsOverlap(selectors, otherSelectors []*metav1.LabelSelector) bool {
+	for _, a := range selectors {
+		for _, b := range otherSelectors {
+			if !selectorsDisjoint(a, b) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// selectorsDisjoint returns true if no set of labels can match both selectors, a nil selector matches everything
+// and an invalid one is assumed to overlap
+func selectorsDisjoint(a, b *metav1.LabelSelector) bool {
+	reqs := func(sel *metav1.LabelSelector) (labels.Requirements, bool) {
+		if sel == nil {
+			return nil, true
+		}
+
+		s, err := metav1.LabelSelectorAsSelector(sel)
+		if err != nil {
+			return nil, false
+		}
+
+		r, _ := s.Requirements()
+
+		return r, true
+	}
+
+	reqsA, okA := reqs(a)
+	reqsB, okB := reqs(b)
+
+	if !okA || !okB {
+		return false
+	}
+
+	for _, ra := range reqsA {
+		for _, rb := range reqsB {
+			if ra.Key() == rb.Key() && requirementsDisjoint(ra, rb) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// requirementsDisjoint returns true if no value of the label key meets both requirements
+func requirementsDisjoint(a, b labels.Requirement) bool {
+	valuesA, setA := allowedValues(a)
+	valuesB, setB := allowedValues(b)
+
+	switch {
+	case setA && setB:
+		return !valuesA.HasAny(valuesB.UnsortedList()...)
+	case setA && isExclusion(b):
+		return b.Values().IsSuperset(valuesA)
+	case setB && isExclusion(a):
+		return a.Values().IsSuperset(valuesB)
+	}
+
+	existsA := setA || a.Operator() == selection.Exists
+	existsB := setB || b.Operator() == selection.Exists
+
+	return (existsA && b.Operator() == selection.DoesNotExist) || (existsB && a.Operator() == selection.DoesNotExist)
+}
+
+// allowedValues returns the values the requirement restricts the label to, false when it does not restrict them
+func allowedValues(r labels.Requirement) (sets.String, bool) {
+	switch r.Operator() {
+	case selection.Equals, selection.DoubleEquals, selection.In:
+		return r.Values(), true
+	}
+
+	return nil, false
+}
+
+// isExclusion returns true if the requirement excludes some values of the label, the label may be missing
+func isExclusion(r labels.Requirement) bool {
+	return r.Operator() == selection.NotIn || r.Operator() == selection.NotEquals
+}
@@ -0,0 +1,93 @@
+// Copyright 2019 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webhook
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/stolostron/multicloud-operators-application/utils"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	appv1beta1 "sigs.k8s.io/application/api/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestPropagatedLabelConflicts(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	deployments := metav1.GroupKind{Group: "apps", Kind: "Deployment"}
+
+	newApp := func(name, selector, propagated string, gks ...metav1.GroupKind) *appv1beta1.Application {
+		app := newTestApp(map[string]string{utils.AnnotationPropagateLabels: propagated}, gks...)
+		app.Name = name
+
+		sel, err := metav1.ParseToLabelSelector(selector)
+		g.Expect(err).ShouldNot(HaveOccurred())
+
+		app.Spec.Selector = sel
+
+		return app
+	}
+
+	s := runtime.NewScheme()
+	g.Expect(appv1beta1.AddToScheme(s)).Should(Succeed())
+
+	c := fake.NewClientBuilder().WithScheme(s).WithObjects(
+		newApp("same-value", "tier=web", `{"team":"a"}`, deployments),
+		newApp("disjoint", "tier=db", `{"team":"b"}`, deployments),
+		newApp("excluded", "tier notin (web,api)", `{"team":"b"}`, deployments),
+		newApp("other-kind", "tier=web", `{"team":"b"}`, metav1.GroupKind{Kind: "ConfigMap"}),
+		newApp("overlapping", "app=shop", `{"team":"b"}`, deployments),
+	).Build()
+
+	app := newApp("test-app", "tier in (web,api)", `{"team":"a"}`, deployments)
+	g.Expect(propagatedLabelConflicts(context.TODO(), c, app)).Should(Equal([]string{
+		"team=a on the Deployment.apps components against team=b, application overlapping",
+	}))
+
+	v := &AppValidator{opts: ValidatorOptions{Warnings: []string{WarningLabelConflicts}, reader: c}}
+	g.Expect(v.warnings(context.TODO(), app)).Should(ConsistOf(ContainSubstring("application overlapping")))
+
+	v.opts.reader = nil
+	g.Expect(v.warnings(context.TODO(), app)).Should(BeEmpty())
+
+	g.Expect(propagatedLabelConflicts(context.TODO(), c, newApp("test-app", "tier=web", `{"owner":"x"}`, deployments))).Should(BeEmpty())
+}
+
+func TestSelectorsDisjoint(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	disjoint := func(a, b string) bool {
+		selA, err := metav1.ParseToLabelSelector(a)
+		g.Expect(err).ShouldNot(HaveOccurred())
+
+		selB, err := metav1.ParseToLabelSelector(b)
+		g.Expect(err).ShouldNot(HaveOccurred())
+
+		return selectorsDisjoint(selA, selB) && selectorsDisjoint(selB, selA)
+	}
+
+	g.Expect(disjoint("tier=web", "tier=db")).Should(BeTrue())
+	g.Expect(disjoint("tier in (web,api)", "tier notin (web,api)")).Should(BeTrue())
+	g.Expect(disjoint("tier", "!tier")).Should(BeTrue())
+	g.Expect(disjoint("tier=web", "!tier")).Should(BeTrue())
+
+	g.Expect(disjoint("tier=web", "app=shop")).Should(BeFalse())
+	g.Expect(disjoint("tier in (web,api)", "tier=api")).Should(BeFalse())
+	g.Expect(disjoint("tier", "tier notin (web)")).Should(BeFalse())
+	g.Expect(selectorsDisjoint(nil, &metav1.LabelSelector{MatchLabels: map[string]string{"tier": "web"}})).Should(BeFalse())
+}
@@ -0,0 +1,105 @@
+// Copyright 2019 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webhook
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/stolostron/multicloud-operators-application/utils"
+	appv1beta1 "sigs.k8s.io/application/api/v1beta1"
+)
+
+// ResolutionMode is a way the components of an application are resolved
+type ResolutionMode string
+
+const (
+	// ResolutionModeSelector lists the resources of the component kinds matching the selectors
+	ResolutionModeSelector ResolutionMode = "selector"
+	// ResolutionModeComponentsConfigMap resolves exactly the components listed in a ConfigMap
+	ResolutionModeComponentsConfigMap ResolutionMode = "components-configmap"
+)
+
+// allResolutionModes lists the modes in the order the conflicts are reported
+var allResolutionModes = []ResolutionMode{ResolutionModeSelector, ResolutionModeComponentsConfigMap}
+
+// resolutionModeFields are the fields setting each mode or only taking effect with it. spec.selector is left out,
+// many applications set it along the components ConfigMap, which is documented to replace it.
+var resolutionModeFields = map[ResolutionMode][]string{
+	ResolutionModeSelector: {
+		utils.AnnotationFallbackSelectors,
+		utils.AnnotationComponentImage,
+		utils.AnnotationComponentImageRegex,
+		utils.AnnotationComponentNamespaces,
+		utils.AnnotationHealthKinds,
+		utils.AnnotationDiagnoseSelector,
+	},
+	ResolutionModeComponentsConfigMap: {
+		utils.AnnotationComponentsConfigMap,
+	},
+}
+
+// resolutionModeCompatibility is the compatibility matrix of the modes, the pairs not listed cannot be set together
+var resolutionModeCompatibility = map[ResolutionMode]map[ResolutionMode]bool{
+	ResolutionModeSelector:            {ResolutionModeSelector: true},
+	ResolutionModeComponentsConfigMap: {ResolutionModeComponentsConfigMap: true},
+}
+
+// ResolutionModesCompatible returns true if the two resolution modes can be set on the same application
+func ResolutionModesCompatible(a, b ResolutionMode) bool {
+	return resolutionModeCompatibility[a][b] && resolutionModeCompatibility[b][a]
+}
+
+// resolutionModes returns the modes the application sets, in the order of allResolutionModes, and the fields
+// setting each of them
+func resolutionModes(app *appv1beta1.Application) ([]ResolutionMode, map[ResolutionMode][]string) {
+	var modes []ResolutionMode
+
+	fields := map[ResolutionMode][]string{}
+
+	for _, mode := range allResolutionModes {
+		for _, field := range resolutionModeFields[mode] {
+			if _, ok := app.GetAnnotations()[field]; ok {
+				fields[mode] = append(fields[mode], field)
+			}
+		}
+
+		if len(fields[mode]) > 0 {
+			modes = append(modes, mode)
+		}
+	}
+
+	return modes, fields
+}
+
+// validateResolutionModes denies the applications setting the fields of incompatible resolution modes, the
+// components would be resolved by one of them and the fields of the other silently ignored
+func validateResolutionModes(app *appv1beta1.Application) error {
+	modes, fields := resolutionModes(app)
+
+	for i := range modes {
+		for _, other := range modes[i+1:] {
+			if ResolutionModesCompatible(modes[i], other) {
+				continue
+			}
+
+			return fmt.Errorf("the %s resolution, set by the %s annotations, conflicts with the %s resolution, set by "+
+				"the %s annotations, keep the annotations of one of them", modes[i], strings.Join(fields[modes[i]], ", "),
+				other, strings.Join(fields[other], ", "))
+		}
+	}
+
+	return nil
+}
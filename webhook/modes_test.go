@@ -0,0 +1,56 @@
+// Copyright 2019 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webhook
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/stolostron/multicloud-operators-application/utils"
+)
+
+func TestResolutionModesCompatibility(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	for _, mode := range allResolutionModes {
+		g.Expect(resolutionModeFields).Should(HaveKey(mode))
+		g.Expect(ResolutionModesCompatible(mode, mode)).Should(BeTrue())
+	}
+
+	g.Expect(ResolutionModesCompatible(ResolutionModeSelector, ResolutionModeComponentsConfigMap)).Should(BeFalse())
+	g.Expect(ResolutionModesCompatible(ResolutionModeComponentsConfigMap, ResolutionModeSelector)).Should(BeFalse())
+}
+
+func TestValidateResolutionModes(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	g.Expect(validateResolutionModes(newTestApp(nil))).Should(Succeed())
+	g.Expect(validateResolutionModes(newTestApp(map[string]string{
+		utils.AnnotationFallbackSelectors:   `[{"matchLabels":{"app":"web"}}]`,
+		utils.AnnotationComponentImageRegex: "^quay.io/",
+	}))).Should(Succeed())
+
+	app := newTestApp(map[string]string{utils.AnnotationComponentsConfigMap: "components/list"})
+	app.Spec.Selector = nil
+	g.Expect(validateResolutionModes(app)).Should(Succeed())
+
+	app.Annotations[utils.AnnotationComponentImageRegex] = "^quay.io/"
+	app.Annotations[utils.AnnotationHealthKinds] = `[{"kind":"Pod"}]`
+
+	err := validateResolutionModes(app)
+	g.Expect(err).Should(HaveOccurred())
+	g.Expect(err.Error()).Should(ContainSubstring("the selector resolution, set by the " + utils.AnnotationComponentImageRegex +
+		", " + utils.AnnotationHealthKinds + " annotations, conflicts with the components-configmap resolution"))
+}
@@ -0,0 +1,146 @@
+// Copyright 2019 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	appv1beta1 "sigs.k8s.io/application/api/v1beta1"
+)
+
+const (
+	MutatorPath        = "/app-mutate"
+	WebhookMutatorName = "application-webhook-mutator"
+
+	mutatorWebhookName = "applications.apps.open-cluster-management.mutator"
+
+	// MutationNormalizeComponentKinds rewrites the component kinds to their canonical casing
+	MutationNormalizeComponentKinds = "normalize-component-kinds"
+	// MutationNormalizeKeywords trims, deduplicates and optionally lowercases the descriptor keywords
+	MutationNormalizeKeywords = "normalize-keywords"
+)
+
+// AppMutator rewrites the componentKinds entries of the applications to the kind and group the cluster serves,
+// "deployment" or "Deployment.Apps" become Deployment.apps, so the components of the kinds are resolved. The
+// kinds unknown to the mapper are left unchanged, the validating webhook warns about them. The kinds are not
+// rewritten without a mapper.
+// It also normalizes the descriptor keywords when keywords is set, so the search index sees each of them once.
+type AppMutator struct {
+	mapper            meta.RESTMapper
+	keywords          bool
+	lowercaseKeywords bool
+	decoder           *admission.Decoder
+}
+
+func (m *AppMutator) Handle(ctx context.Context, req admission.Request) admission.Response {
+	if req.SubResource != "" {
+		return admission.Allowed("subresource " + req.SubResource + " is not mutated")
+	}
+
+	app := &appv1beta1.Application{}
+	if err := m.decoder.Decode(req, app); err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+
+	changed := m.mapper != nil && normalizeComponentKinds(m.mapper, app)
+
+	if m.keywords && normalizeKeywords(app, m.lowercaseKeywords) {
+		changed = true
+	}
+
+	if !changed {
+		return admission.Allowed("")
+	}
+
+	marshaled, err := json.Marshal(app)
+	if err != nil {
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+
+	return admission.PatchResponseFromRaw(req.Object.Raw, marshaled)
+}
+
+// InjectDecoder injects the decoder.
+func (m *AppMutator) InjectDecoder(d *admission.Decoder) error {
+	m.decoder = d
+	return nil
+}
+
+// normalizeComponentKinds sets the component kinds to their canonical kind and group, it returns true if any changed
+func normalizeComponentKinds(mapper meta.RESTMapper, app *appv1beta1.Application) bool {
+	changed := false
+
+	for i, gk := range app.Spec.ComponentGroupKinds {
+		canonical, ok := canonicalGroupKind(mapper, gk.Group, gk.Kind)
+		if !ok || (canonical.Group == gk.Group && canonical.Kind == gk.Kind) {
+			continue
+		}
+
+		app.Spec.ComponentGroupKinds[i].Group = canonical.Group
+		app.Spec.ComponentGroupKinds[i].Kind = canonical.Kind
+		changed = true
+	}
+
+	return changed
+}
+
+// canonicalGroupKind looks the kind up case insensitively, as the singular resource name it lowercases to. A kind
+// matching no resource or several is not resolved.
+func canonicalGroupKind(mapper meta.RESTMapper, group, kind string) (schema.GroupKind, bool) {
+	group = strings.ToLower(appv1beta1.StripVersion(group))
+
+	gvk, err := mapper.KindFor(schema.GroupVersionResource{Group: group, Resource: strings.ToLower(kind)})
+	// the mapper matches any group for the core group, a kind of another group is not the one asked for
+	if err != nil || gvk.Group != group {
+		return schema.GroupKind{}, false
+	}
+
+	return gvk.GroupKind(), true
+}
+
+// normalizeKeywords trims the descriptor keywords, lowercases them when asked and drops their repetitions, it
+// returns true if any changed. The empty keywords are left for the validating webhook to deny.
+func normalizeKeywords(app *appv1beta1.Application, lowercase bool) bool {
+	keywords := app.Spec.Descriptor.Keywords
+	if len(keywords) == 0 {
+		return false
+	}
+
+	normalized := make([]string, len(keywords))
+
+	for i, keyword := range keywords {
+		normalized[i] = strings.TrimSpace(keyword)
+		if lowercase {
+			normalized[i] = strings.ToLower(normalized[i])
+		}
+	}
+
+	normalized = dedupeKeywords(normalized)
+	if reflect.DeepEqual(normalized, keywords) {
+		return false
+	}
+
+	app.Spec.Descriptor.Keywords = normalized
+
+	return true
+}
@@ -0,0 +1,131 @@
+// Copyright 2019 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	admissionv1 "k8s.io/api/admission/v1"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	appv1beta1 "sigs.k8s.io/application/api/v1beta1"
+)
+
+func newTestKindMapper() meta.RESTMapper {
+	mapper := meta.NewDefaultRESTMapper([]schema.GroupVersion{corev1.SchemeGroupVersion, appsv1.SchemeGroupVersion})
+	mapper.Add(corev1.SchemeGroupVersion.WithKind("ConfigMap"), meta.RESTScopeNamespace)
+	mapper.Add(appsv1.SchemeGroupVersion.WithKind("Deployment"), meta.RESTScopeNamespace)
+
+	return mapper
+}
+
+func TestNormalizeComponentKinds(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	mapper := newTestKindMapper()
+
+	app := newTestApp(nil,
+		metav1.GroupKind{Group: "Apps", Kind: "deployment"},
+		metav1.GroupKind{Kind: "configmap"},
+		metav1.GroupKind{Group: "example.com", Kind: "notinstalled"},
+		// a core kind is not looked up in the other groups
+		metav1.GroupKind{Kind: "deployment"},
+	)
+
+	g.Expect(normalizeComponentKinds(mapper, app)).Should(BeTrue())
+	g.Expect(app.Spec.ComponentGroupKinds).Should(Equal([]metav1.GroupKind{
+		{Group: "apps", Kind: "Deployment"},
+		{Kind: "ConfigMap"},
+		{Group: "example.com", Kind: "notinstalled"},
+		{Kind: "deployment"},
+	}))
+
+	g.Expect(normalizeComponentKinds(mapper, app)).Should(BeFalse())
+
+	warning := warningChecks[WarningUnknownKinds](context.TODO(), app, ValidatorOptions{mapper: mapper})
+	g.Expect(warning).Should(ContainSubstring("notinstalled.example.com, deployment"))
+	g.Expect(warningChecks[WarningUnknownKinds](context.TODO(), app, ValidatorOptions{})).Should(BeEmpty())
+}
+
+func TestNormalizeKeywords(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	app := newTestApp(nil)
+	g.Expect(normalizeKeywords(app, true)).Should(BeFalse())
+
+	app.Spec.Descriptor.Keywords = []string{"web", " Frontend", "web", "frontend"}
+	g.Expect(normalizeKeywords(app, false)).Should(BeTrue())
+	g.Expect(app.Spec.Descriptor.Keywords).Should(Equal([]string{"web", "Frontend", "frontend"}))
+	g.Expect(normalizeKeywords(app, false)).Should(BeFalse())
+
+	g.Expect(normalizeKeywords(app, true)).Should(BeTrue())
+	g.Expect(app.Spec.Descriptor.Keywords).Should(Equal([]string{"web", "frontend"}))
+}
+
+func TestAppMutatorHandle(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	s := runtime.NewScheme()
+	g.Expect(appv1beta1.AddToScheme(s)).Should(Succeed())
+
+	decoder, err := admission.NewDecoder(s)
+	g.Expect(err).ShouldNot(HaveOccurred())
+
+	mutator := &AppMutator{mapper: newTestKindMapper()}
+	g.Expect(mutator.InjectDecoder(decoder)).Should(Succeed())
+
+	request := func(app *appv1beta1.Application) admission.Request {
+		app.APIVersion = appv1beta1.GroupVersion.String()
+		app.Kind = "Application"
+
+		raw, err := json.Marshal(app)
+		g.Expect(err).ShouldNot(HaveOccurred())
+
+		return admission.Request{AdmissionRequest: admissionv1.AdmissionRequest{
+			Operation: admissionv1.Create,
+			Object:    runtime.RawExtension{Raw: raw},
+		}}
+	}
+
+	resp := mutator.Handle(context.TODO(), request(newTestApp(nil, metav1.GroupKind{Group: "apps", Kind: "Deployment"})))
+	g.Expect(resp.Allowed).Should(BeTrue())
+	g.Expect(resp.Patches).Should(BeEmpty())
+
+	resp = mutator.Handle(context.TODO(), request(newTestApp(nil, metav1.GroupKind{Group: "apps", Kind: "deployment"})))
+	g.Expect(resp.Allowed).Should(BeTrue())
+	g.Expect(resp.Patches).Should(HaveLen(1))
+	g.Expect(resp.Patches[0].Value).Should(Equal("Deployment"))
+
+	keywords := newTestApp(nil, metav1.GroupKind{Group: "apps", Kind: "Deployment"})
+	keywords.Spec.Descriptor.Keywords = []string{"web", "web"}
+
+	resp = mutator.Handle(context.TODO(), request(keywords.DeepCopy()))
+	g.Expect(resp.Allowed).Should(BeTrue())
+	g.Expect(resp.Patches).Should(BeEmpty())
+
+	mutator.keywords = true
+	resp = mutator.Handle(context.TODO(), request(keywords.DeepCopy()))
+	g.Expect(resp.Allowed).Should(BeTrue())
+	g.Expect(resp.Patches).Should(HaveLen(1))
+}
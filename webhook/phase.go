@@ -0,0 +1,69 @@
+// Copyright 2019 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webhook
+
+import (
+	"fmt"
+
+	"github.com/stolostron/multicloud-operators-application/utils"
+	appv1beta1 "sigs.k8s.io/application/api/v1beta1"
+)
+
+// assemblyPhaseTransitions are the phases each assembly phase may move to, staying in the same phase is
+// always allowed
+var assemblyPhaseTransitions = map[appv1beta1.ApplicationAssemblyPhase][]appv1beta1.ApplicationAssemblyPhase{
+	appv1beta1.Pending:   {appv1beta1.Succeeded, appv1beta1.Failed},
+	appv1beta1.Succeeded: {appv1beta1.Failed},
+	appv1beta1.Failed:    {appv1beta1.Pending, appv1beta1.Succeeded},
+}
+
+// effectiveAssemblyPhase maps the empty phase to Succeeded, as defined by the application API
+func effectiveAssemblyPhase(phase appv1beta1.ApplicationAssemblyPhase) appv1beta1.ApplicationAssemblyPhase {
+	if phase == "" {
+		return appv1beta1.Succeeded
+	}
+
+	return phase
+}
+
+// validateAssemblyPhaseTransition rejects the updates moving spec.assemblyPhase along a transition missing from
+// the transition graph, unless the new application carries the override annotation. oldApp is nil on create.
+func validateAssemblyPhaseTransition(oldApp, newApp *appv1beta1.Application) error {
+	if oldApp == nil || newApp.GetAnnotations()[utils.AnnotationAllowAssemblyPhaseTransition] == "true" {
+		return nil
+	}
+
+	from := effectiveAssemblyPhase(oldApp.Spec.AssemblyPhase)
+	to := effectiveAssemblyPhase(newApp.Spec.AssemblyPhase)
+
+	if from == to {
+		return nil
+	}
+
+	next, known := assemblyPhaseTransitions[from]
+	if !known {
+		// a phase outside of the graph is not a lifecycle this webhook knows about
+		return nil
+	}
+
+	for _, phase := range next {
+		if phase == to {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("spec.assemblyPhase cannot move from %s to %s, valid next phases are %v, set the %s annotation to \"true\" to force it",
+		from, to, next, utils.AnnotationAllowAssemblyPhaseTransition)
+}
@@ -0,0 +1,57 @@
+// Copyright 2019 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webhook
+
+import (
+	"context"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	appv1beta1 "sigs.k8s.io/application/api/v1beta1"
+)
+
+// NamespacedComponentKindsValidatorName is the name the namespaced component kinds validator is registered with
+const NamespacedComponentKindsValidatorName = "namespaced-component-kinds"
+
+// NamespacedComponentKindsValidator denies the applications listing a cluster-scoped kind in componentGroupKinds,
+// the components are resolved in a namespace and a cluster-scoped kind never matches any. The kinds unknown to
+// the mapper are allowed, their CRD may be installed after the application. The Namespace kind is allowed too,
+// listing it resolves the namespace of the application itself, never the other namespaces.
+func NamespacedComponentKindsValidator(mapper meta.RESTMapper) Validator {
+	return func(ctx context.Context, oldApp, newApp *appv1beta1.Application) (bool, string, error) {
+		for _, gk := range newApp.Spec.ComponentGroupKinds {
+			if gk.Group == "" && gk.Kind == "Namespace" {
+				continue
+			}
+
+			mapping, err := mapper.RESTMapping(schema.GroupKind{Group: gk.Group, Kind: gk.Kind})
+			if meta.IsNoMatchError(err) {
+				continue
+			}
+
+			if err != nil {
+				return false, "", fmt.Errorf("failed to get the scope of kind %s: %w", gk.String(), err)
+			}
+
+			if mapping.Scope.Name() != meta.RESTScopeNameNamespace {
+				return false, fmt.Sprintf("spec.componentKinds lists the cluster-scoped kind %s, the components are "+
+					"resolved in the application namespace and it never matches any", gk.String()), nil
+			}
+		}
+
+		return true, "", nil
+	}
+}
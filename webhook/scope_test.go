@@ -0,0 +1,50 @@
+// Copyright 2019 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webhook
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestNamespacedComponentKindsValidator(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	mapper := meta.NewDefaultRESTMapper([]schema.GroupVersion{corev1.SchemeGroupVersion, rbacv1.SchemeGroupVersion})
+	mapper.Add(corev1.SchemeGroupVersion.WithKind("ConfigMap"), meta.RESTScopeNamespace)
+	mapper.Add(rbacv1.SchemeGroupVersion.WithKind("ClusterRole"), meta.RESTScopeRoot)
+	mapper.Add(corev1.SchemeGroupVersion.WithKind("Namespace"), meta.RESTScopeRoot)
+
+	validate := NamespacedComponentKindsValidator(mapper)
+
+	app := newTestApp(nil, metav1.GroupKind{Kind: "ConfigMap"}, metav1.GroupKind{Group: "example.com", Kind: "NotInstalled"},
+		metav1.GroupKind{Kind: "Namespace"})
+	g.Expect(validate(context.TODO(), nil, app)).Should(BeTrue())
+
+	app.Spec.ComponentGroupKinds = append(app.Spec.ComponentGroupKinds,
+		metav1.GroupKind{Group: rbacv1.GroupName, Kind: "ClusterRole"})
+
+	allowed, reason, err := validate(context.TODO(), nil, app)
+	g.Expect(err).ShouldNot(HaveOccurred())
+	g.Expect(allowed).Should(BeFalse())
+	g.Expect(reason).Should(ContainSubstring("cluster-scoped kind ClusterRole.rbac.authorization.k8s.io"))
+}
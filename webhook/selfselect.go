@@ -0,0 +1,80 @@
+// Copyright 2019 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webhook
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/stolostron/multicloud-operators-application/utils"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	appv1beta1 "sigs.k8s.io/application/api/v1beta1"
+)
+
+// OperatorWorkloadsValidatorName is the name the operator workloads validator is registered with
+const OperatorWorkloadsValidatorName = "operator-workloads"
+
+// OperatorWorkloadsValidator denies the applications whose selectors match the workloads of the operator, labeled
+// app=<deployLabel> in its namespace, so an application never sets an owner reference on the operator deployment.
+// The applications outside the operator namespace are only checked for the kinds they resolve in it.
+func OperatorWorkloadsValidator(namespace, deployLabel string) Validator {
+	operatorLabels := labels.Set{deploySelectorName: deployLabel}
+
+	return func(ctx context.Context, oldApp, newApp *appv1beta1.Application) (bool, string, error) {
+		if !resolvesInNamespace(newApp, namespace) {
+			return true, "", nil
+		}
+
+		// the selectors failing to parse are denied by the built-in checks
+		selectors, err := utils.GetSelectors(newApp)
+		if err != nil {
+			return true, "", nil
+		}
+
+		for i, sel := range selectors {
+			selector, err := metav1.LabelSelectorAsSelector(sel)
+			if err != nil || !selector.Matches(operatorLabels) {
+				continue
+			}
+
+			name := "spec.selector"
+			if i > 0 {
+				name = fmt.Sprintf("%s annotation, selector %d", utils.AnnotationFallbackSelectors, i-1)
+			}
+
+			return false, fmt.Sprintf("%s matches the workloads of the application operator labeled %s in namespace %s, "+
+				"narrow the selector", name, operatorLabels, namespace), nil
+		}
+
+		return true, "", nil
+	}
+}
+
+// resolvesInNamespace returns true if any component kind of the application is resolved in the namespace
+func resolvesInNamespace(app *appv1beta1.Application, namespace string) bool {
+	if app.Namespace == namespace {
+		return true
+	}
+
+	namespaces, _ := utils.GetComponentNamespaces(app)
+	for _, ns := range namespaces {
+		if ns == namespace {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,53 @@
+// Copyright 2019 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webhook
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/stolostron/multicloud-operators-application/utils"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestOperatorWorkloadsValidator(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	validate := OperatorWorkloadsValidator("open-cluster-management", "multicluster-operators-application")
+
+	app := newTestApp(nil, metav1.GroupKind{Group: "apps", Kind: "Deployment"})
+	app.Namespace = "open-cluster-management"
+	app.Spec.Selector = &metav1.LabelSelector{MatchLabels: map[string]string{"app": "my-app"}}
+	g.Expect(validate(context.TODO(), nil, app)).Should(BeTrue())
+
+	app.Spec.Selector = &metav1.LabelSelector{MatchExpressions: []metav1.LabelSelectorRequirement{
+		{Key: "app", Operator: metav1.LabelSelectorOpExists},
+	}}
+
+	allowed, reason, err := validate(context.TODO(), nil, app)
+	g.Expect(err).ShouldNot(HaveOccurred())
+	g.Expect(allowed).Should(BeFalse())
+	g.Expect(reason).Should(ContainSubstring("spec.selector matches the workloads of the application operator"))
+
+	// the same selector is harmless in another namespace, unless a kind is resolved in the operator namespace
+	app.Namespace = "team-a"
+	g.Expect(validate(context.TODO(), nil, app)).Should(BeTrue())
+
+	app.Annotations = map[string]string{utils.AnnotationComponentNamespaces: `{"Deployment.apps":"open-cluster-management"}`}
+	allowed, _, err = validate(context.TODO(), nil, app)
+	g.Expect(err).ShouldNot(HaveOccurred())
+	g.Expect(allowed).Should(BeFalse())
+}
@@ -0,0 +1,98 @@
+// Copyright 2019 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webhook
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/stolostron/multicloud-operators-application/pkg/apis"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	appv1beta1 "sigs.k8s.io/application/api/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// selfTestAnnotation marks the dry-run application of the self-test, the validator always denies it
+	selfTestAnnotation = "apps.open-cluster-management.io/webhook-self-test"
+	selfTestDenial     = "webhook self-test"
+
+	selfTestInterval = 5 * time.Second
+	selfTestTimeout  = 2 * time.Minute
+)
+
+// isSelfTest returns true for the dry-run requests of the self-test, the others carrying the annotation are
+// validated as usual
+func isSelfTest(app *appv1beta1.Application, dryRun *bool) bool {
+	_, ok := app.GetAnnotations()[selfTestAnnotation]
+
+	return ok && dryRun != nil && *dryRun
+}
+
+// SelfTest creates a dry-run application in the namespace and makes sure the validating webhook denied it. An
+// application admitted without it means the API server fails open, the webhook service or its CA bundle is
+// misconfigured.
+func SelfTest(ctx context.Context, c client.Client, namespace string) error {
+	app := &appv1beta1.Application{
+		TypeMeta: metav1.TypeMeta{APIVersion: apis.ApplicationGroupVersion.String(), Kind: "Application"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "webhook-self-test",
+			Namespace:   namespace,
+			Annotations: map[string]string{selfTestAnnotation: "true"},
+		},
+	}
+
+	// the application is labeled into the scope of the webhooks, as far as the labels it must match go
+	if Options.ObjectSelector != nil {
+		app.Labels = Options.ObjectSelector.MatchLabels
+	}
+
+	err := c.Create(ctx, app, client.DryRunAll)
+	if err == nil {
+		return fmt.Errorf("a dry-run application was admitted without the %s webhook, check the %s service and the CA "+
+			"bundle of the %s configuration", webhookName, WebhookServiceName, WebhookValidatorName)
+	}
+
+	if !strings.Contains(err.Error(), selfTestDenial) {
+		return fmt.Errorf("the %s webhook did not handle the dry-run application: %w", webhookName, err)
+	}
+
+	return nil
+}
+
+// runSelfTest retries the self-test while the webhook server starts and the configuration propagates, and logs
+// the last failure once it times out
+func runSelfTest(ctx context.Context, c client.Client, namespace string) {
+	ctx, cancel := context.WithTimeout(ctx, selfTestTimeout)
+	defer cancel()
+
+	var lastErr error
+
+	err := wait.PollImmediateUntil(selfTestInterval, func() (bool, error) {
+		lastErr = SelfTest(ctx, c, namespace)
+
+		return lastErr == nil, nil
+	}, ctx.Done())
+
+	if err != nil {
+		log.Error(lastErr, "webhook self-test failed, the applications may be admitted without validation")
+		return
+	}
+
+	log.Info("webhook self-test passed")
+}
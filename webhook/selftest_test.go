@@ -0,0 +1,87 @@
+// Copyright 2019 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	admissionv1 "k8s.io/api/admission/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	appv1beta1 "sigs.k8s.io/application/api/v1beta1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+)
+
+// dryRunCreateClient answers the creates as the API server would with or without the webhook
+type dryRunCreateClient struct {
+	client.Client
+	err    error
+	dryRun bool
+}
+
+func (c *dryRunCreateClient) Create(ctx context.Context, obj client.Object, opts ...client.CreateOption) error {
+	createOpts := &client.CreateOptions{}
+	createOpts.ApplyOptions(opts)
+	c.dryRun = len(createOpts.DryRun) > 0
+
+	return c.err
+}
+
+func TestSelfTest(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	c := &dryRunCreateClient{err: errors.New(`admission webhook "` + webhookName + `" denied the request: ` + selfTestDenial)}
+	g.Expect(SelfTest(context.TODO(), c, "default")).Should(Succeed())
+	g.Expect(c.dryRun).Should(BeTrue())
+
+	c.err = nil
+	g.Expect(SelfTest(context.TODO(), c, "default")).Should(MatchError(ContainSubstring("admitted without")))
+
+	c.err = errors.New("failed calling webhook: connection refused")
+	g.Expect(SelfTest(context.TODO(), c, "default")).Should(MatchError(ContainSubstring("connection refused")))
+}
+
+func TestHandleSelfTest(t *testing.T) {
+	g := NewGomegaWithT(t)
+
+	s := runtime.NewScheme()
+	g.Expect(appv1beta1.AddToScheme(s)).Should(Succeed())
+
+	decoder, err := admission.NewDecoder(s)
+	g.Expect(err).ShouldNot(HaveOccurred())
+
+	v := &AppValidator{}
+	g.Expect(v.InjectDecoder(decoder)).Should(Succeed())
+
+	app := newTestApp(map[string]string{selfTestAnnotation: "true"})
+	app.APIVersion = appv1beta1.GroupVersion.String()
+	app.Kind = "Application"
+
+	raw, err := json.Marshal(app)
+	g.Expect(err).ShouldNot(HaveOccurred())
+
+	dryRun := true
+	resp := v.Handle(context.TODO(), admission.Request{AdmissionRequest: admissionv1.AdmissionRequest{
+		Operation: admissionv1.Create,
+		Object:    runtime.RawExtension{Raw: raw},
+		DryRun:    &dryRun,
+	}})
+	g.Expect(resp.Allowed).Should(BeFalse())
+	g.Expect(string(resp.Result.Reason)).Should(Equal(selfTestDenial))
+}
@@ -0,0 +1,580 @@
+// Copyright 2019 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webhook
+
+import (
+	"fmt"
+	"mime"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/stolostron/multicloud-operators-application/utils"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	appv1beta1 "sigs.k8s.io/application/api/v1beta1"
+)
+
+// Validation checks only look at the application manifest, they run without a cluster
+const (
+	CheckSelector             = "selector"
+	CheckDescriptorLinks      = "descriptor-links"
+	CheckDescriptorIcons      = "descriptor-icons"
+	CheckDescriptorKeywords   = "descriptor-keywords"
+	CheckInfo                 = "info"
+	CheckRequiredComponents   = "required-components"
+	CheckComponentsConfigMap  = "components-configmap"
+	CheckImageFilter          = "image-filter"
+	CheckHealthAggregation    = "health-aggregation"
+	CheckPropagateLabels      = "propagate-labels"
+	CheckComponentNamespaces  = "component-namespaces"
+	CheckComponentKinds       = "component-kinds"
+	CheckLabelCleanupPolicy   = "label-cleanup-policy"
+	CheckPropagateAnnotations = "propagate-annotations"
+	CheckReconcileInterval    = "reconcile-interval"
+	CheckParent               = "parent"
+	CheckResolutionModes      = "resolution-modes"
+	CheckPause                = "pause"
+	CheckResolveAs            = "resolve-as"
+	CheckHealthMaxComponents  = "health-max-components"
+	CheckHealthWeights        = "health-weights"
+	CheckTTLAfterSucceeded    = "ttl-after-succeeded"
+	CheckReservedLabels       = "reserved-labels"
+	CheckMode                 = "mode"
+)
+
+// AllValidationChecks lists every cluster independent validation check in the order they run
+var AllValidationChecks = []string{
+	CheckSelector,
+	CheckDescriptorLinks,
+	CheckDescriptorIcons,
+	CheckDescriptorKeywords,
+	CheckInfo,
+	CheckRequiredComponents,
+	CheckComponentsConfigMap,
+	CheckImageFilter,
+	CheckHealthAggregation,
+	CheckPropagateLabels,
+	CheckComponentNamespaces,
+	CheckComponentKinds,
+	CheckLabelCleanupPolicy,
+	CheckPropagateAnnotations,
+	CheckReconcileInterval,
+	CheckParent,
+	CheckResolutionModes,
+	CheckPause,
+	CheckResolveAs,
+	CheckHealthMaxComponents,
+	CheckHealthWeights,
+	CheckTTLAfterSucceeded,
+	CheckReservedLabels,
+	CheckMode,
+}
+
+// ValidationOptions tunes ValidateApplication
+type ValidationOptions struct {
+	// SkipChecks are the names of the validation checks not to run, see AllValidationChecks
+	SkipChecks []string
+	// MaxSelectorTerms denies the selectors with more label keys and expressions, 0 sets no limit
+	MaxSelectorTerms int
+	// RequiredLabels are the label keys every application must carry in its metadata, whatever its selector
+	RequiredLabels []string
+	// MaxKeywords denies the applications with more distinct spec.descriptor.keywords, 0 sets no limit
+	MaxKeywords int
+}
+
+func (o ValidationOptions) enabledChecks() []string {
+	skip := make(map[string]bool, len(o.SkipChecks))
+	for _, name := range o.SkipChecks {
+		skip[name] = true
+	}
+
+	var checks []string
+
+	for _, name := range AllValidationChecks {
+		if !skip[name] {
+			checks = append(checks, name)
+		}
+	}
+
+	return checks
+}
+
+var validationChecks = map[string]func(app *appv1beta1.Application) error{
+	CheckSelector:             validateSelector,
+	CheckDescriptorLinks:      validateDescriptorLinks,
+	CheckDescriptorIcons:      validateDescriptorIcons,
+	CheckDescriptorKeywords:   validateDescriptorKeywords,
+	CheckInfo:                 validateInfo,
+	CheckRequiredComponents:   validateRequiredComponents,
+	CheckComponentsConfigMap:  validateComponentsConfigMap,
+	CheckImageFilter:          validateImageFilter,
+	CheckHealthAggregation:    validateHealthAggregation,
+	CheckPropagateLabels:      validatePropagateLabels,
+	CheckComponentNamespaces:  validateComponentNamespaces,
+	CheckComponentKinds:       validateComponentKinds,
+	CheckLabelCleanupPolicy:   validateLabelCleanupPolicy,
+	CheckPropagateAnnotations: validatePropagateAnnotations,
+	CheckReconcileInterval:    validateReconcileInterval,
+	CheckParent:               validateParent,
+	CheckResolutionModes:      validateResolutionModes,
+	CheckPause:                validatePause,
+	CheckResolveAs:            validateResolveAs,
+	CheckHealthMaxComponents:  validateHealthMaxComponents,
+	CheckHealthWeights:        validateHealthWeights,
+	CheckTTLAfterSucceeded:    validateTTLAfterSucceeded,
+	CheckReservedLabels:       validateReservedLabels,
+	CheckMode:                 validateMode,
+}
+
+// ValidateApplication runs the validation checks of the webhook that do not need a cluster, so manifests can be
+// linted offline. The webhook denies the applications for which it returns errors.
+func ValidateApplication(app *appv1beta1.Application, opts ValidationOptions) []error {
+	var errs []error
+
+	for _, name := range opts.enabledChecks() {
+		if err := validationChecks[name](app); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if opts.MaxSelectorTerms > 0 {
+		if name, terms := largestSelector(app); terms > opts.MaxSelectorTerms {
+			errs = append(errs, fmt.Errorf("%s has %d label keys and expressions, more than the limit of %d, review the selector",
+				name, terms, opts.MaxSelectorTerms))
+		}
+	}
+
+	if opts.MaxKeywords > 0 {
+		if keywords := len(dedupeKeywords(app.Spec.Descriptor.Keywords)); keywords > opts.MaxKeywords {
+			errs = append(errs, fmt.Errorf("spec.descriptor.keywords has %d distinct keywords, more than the limit of %d",
+				keywords, opts.MaxKeywords))
+		}
+	}
+
+	if missing := missingLabels(app, opts.RequiredLabels); len(missing) > 0 {
+		errs = append(errs, fmt.Errorf("metadata.labels is missing the required labels %s", strings.Join(missing, ", ")))
+	}
+
+	return errs
+}
+
+// missingLabels returns the required label keys the application does not carry, in the order they are required
+func missingLabels(app *appv1beta1.Application, required []string) []string {
+	var missing []string
+
+	for _, key := range required {
+		if _, ok := app.Labels[key]; !ok {
+			missing = append(missing, key)
+		}
+	}
+
+	return missing
+}
+
+// largestSelector returns the selector of the application, spec.selector or a fallback selector, with the most
+// label keys and expressions along their count
+func largestSelector(app *appv1beta1.Application) (string, int) {
+	// an invalid fallback selectors annotation is denied by the selector check, spec.selector is still counted
+	selectors, err := utils.GetSelectors(app)
+	if err != nil {
+		selectors = []*metav1.LabelSelector{app.Spec.Selector}
+	}
+
+	name, largest := "", 0
+
+	for i, sel := range selectors {
+		if sel == nil {
+			continue
+		}
+
+		if terms := len(sel.MatchLabels) + len(sel.MatchExpressions); terms > largest {
+			largest = terms
+
+			name = "spec.selector"
+			if i > 0 {
+				name = fmt.Sprintf("%s annotation, selector %d", utils.AnnotationFallbackSelectors, i-1)
+			}
+		}
+	}
+
+	return name, largest
+}
+
+// validateSelector makes sure spec.selector and each fallback selector convert to a label selector
+func validateSelector(app *appv1beta1.Application) error {
+	selectors, err := utils.GetSelectors(app)
+	if err != nil {
+		return err
+	}
+
+	for i, sel := range selectors {
+		if _, err := metav1.LabelSelectorAsSelector(sel); err != nil {
+			if i == 0 {
+				return fmt.Errorf("spec.selector: %w", err)
+			}
+
+			return fmt.Errorf("%s annotation, selector %d: %w", utils.AnnotationFallbackSelectors, i-1, err)
+		}
+	}
+
+	return nil
+}
+
+// validateDescriptorLinks requires every spec.descriptor.links entry to be an absolute URL
+func validateDescriptorLinks(app *appv1beta1.Application) error {
+	for i, link := range app.Spec.Descriptor.Links {
+		if _, err := parseAbsoluteURL(link.URL); err != nil {
+			return fmt.Errorf("spec.descriptor.links[%d]: %w", i, err)
+		}
+	}
+
+	return nil
+}
+
+// validateDescriptorKeywords denies the empty keywords, they only clutter the search index
+func validateDescriptorKeywords(app *appv1beta1.Application) error {
+	for i, keyword := range app.Spec.Descriptor.Keywords {
+		if strings.TrimSpace(keyword) == "" {
+			return fmt.Errorf("spec.descriptor.keywords[%d]: must not be empty", i)
+		}
+	}
+
+	return nil
+}
+
+// dedupeKeywords returns the keywords without their repetitions, in the order they first appear
+func dedupeKeywords(keywords []string) []string {
+	seen := make(map[string]bool, len(keywords))
+	deduped := make([]string, 0, len(keywords))
+
+	for _, keyword := range keywords {
+		if !seen[keyword] {
+			seen[keyword] = true
+			deduped = append(deduped, keyword)
+		}
+	}
+
+	return deduped
+}
+
+// iconSizePattern is the WIDTHxHEIGHT size in pixels of an icon, such as 64x64
+var iconSizePattern = regexp.MustCompile(`^[1-9][0-9]*x[1-9][0-9]*$`)
+
+// validateDescriptorIcons makes sure every icon source is an absolute or a data URL, and the optional size and
+// type are well-formed
+func validateDescriptorIcons(app *appv1beta1.Application) error {
+	for i, icon := range app.Spec.Descriptor.Icons {
+		if strings.HasPrefix(icon.Source, "data:") {
+			if !strings.Contains(icon.Source, ",") {
+				return fmt.Errorf("spec.descriptor.icons[%d]: %q is not a valid data URL", i, icon.Source)
+			}
+		} else if _, err := parseAbsoluteURL(icon.Source); err != nil {
+			return fmt.Errorf("spec.descriptor.icons[%d]: %w", i, err)
+		}
+
+		if icon.Size != "" && !iconSizePattern.MatchString(icon.Size) {
+			return fmt.Errorf("spec.descriptor.icons[%d]: invalid size %q, expected WIDTHxHEIGHT in pixels such as 64x64", i, icon.Size)
+		}
+
+		if icon.Type != "" {
+			if _, _, err := mime.ParseMediaType(icon.Type); err != nil {
+				return fmt.Errorf("spec.descriptor.icons[%d]: invalid type %q: %w", i, icon.Type, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// parseAbsoluteURL parses the URL and makes sure it is absolute, with a host
+func parseAbsoluteURL(raw string) (*url.URL, error) {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	if !u.IsAbs() || u.Host == "" {
+		return nil, fmt.Errorf("%q is not an absolute URL", raw)
+	}
+
+	return u, nil
+}
+
+// validateInfo rejects duplicated spec.info names and entries carrying neither a value nor a valueFrom source
+func validateInfo(app *appv1beta1.Application) error {
+	names := make(map[string]bool, len(app.Spec.Info))
+
+	for i, info := range app.Spec.Info {
+		if names[info.Name] {
+			return fmt.Errorf("spec.info[%d]: duplicated name %q", i, info.Name)
+		}
+
+		names[info.Name] = true
+
+		if info.Value == "" && info.ValueFrom == nil {
+			return fmt.Errorf("spec.info[%d] %q: either value or valueFrom is required", i, info.Name)
+		}
+	}
+
+	return nil
+}
+
+// componentKinds returns the componentGroupKinds of the application without their version
+func componentKinds(app *appv1beta1.Application) map[metav1.GroupKind]bool {
+	kinds := make(map[metav1.GroupKind]bool, len(app.Spec.ComponentGroupKinds))
+	for _, gk := range app.Spec.ComponentGroupKinds {
+		kinds[metav1.GroupKind{Group: appv1beta1.StripVersion(gk.Group), Kind: gk.Kind}] = true
+	}
+
+	return kinds
+}
+
+// validateRequiredComponents makes sure every required component is of a kind listed in componentGroupKinds
+func validateRequiredComponents(app *appv1beta1.Application) error {
+	rcs, err := utils.GetRequiredComponents(app)
+	if err != nil {
+		return err
+	}
+
+	kinds := componentKinds(app)
+
+	for _, rc := range rcs {
+		if !kinds[metav1.GroupKind{Group: appv1beta1.StripVersion(rc.Group), Kind: rc.Kind}] {
+			return fmt.Errorf("required component %s is not of a kind listed in spec.componentKinds", rc.String())
+		}
+	}
+
+	return nil
+}
+
+func validateComponentsConfigMap(app *appv1beta1.Application) error {
+	_, _, err := utils.GetComponentsConfigMap(app)
+
+	return err
+}
+
+// validateImageFilter makes sure the image regex compiles
+func validateImageFilter(app *appv1beta1.Application) error {
+	_, err := utils.GetImageFilter(app)
+
+	return err
+}
+
+// validateHealthAggregation makes sure the health aggregation rule is known
+func validateHealthAggregation(app *appv1beta1.Application) error {
+	_, err := utils.GetHealthAggregation(app)
+
+	return err
+}
+
+// validateLabelCleanupPolicy makes sure the label cleanup policy is known
+func validateLabelCleanupPolicy(app *appv1beta1.Application) error {
+	_, err := utils.GetLabelCleanupPolicy(app)
+
+	return err
+}
+
+// validatePause makes sure the pause mode is known
+func validatePause(app *appv1beta1.Application) error {
+	_, err := utils.GetPauseMode(app)
+
+	return err
+}
+
+// validateMode makes sure the mode of the application is a known one
+func validateMode(app *appv1beta1.Application) error {
+	_, err := utils.GetMode(app)
+
+	return err
+}
+
+// validateResolveAs makes sure the ServiceAccount the components are resolved as is a valid name
+func validateResolveAs(app *appv1beta1.Application) error {
+	_, err := utils.GetResolveAs(app)
+
+	return err
+}
+
+// validatePropagateAnnotations makes sure the propagated annotation keys are valid and not reserved
+func validatePropagateAnnotations(app *appv1beta1.Application) error {
+	_, err := utils.GetPropagatedAnnotations(app)
+
+	return err
+}
+
+// validateReconcileInterval makes sure the reconcile interval is a positive duration
+func validateReconcileInterval(app *appv1beta1.Application) error {
+	_, _, err := utils.GetReconcileInterval(app)
+
+	return err
+}
+
+// validateHealthMaxComponents makes sure the component count the health is evaluated up to is not negative
+func validateHealthMaxComponents(app *appv1beta1.Application) error {
+	_, _, err := utils.GetHealthMaxComponents(app)
+
+	return err
+}
+
+// validateHealthWeights makes sure every health weight names a kind and is not negative
+func validateHealthWeights(app *appv1beta1.Application) error {
+	_, err := utils.GetHealthWeights(app)
+
+	return err
+}
+
+// validateTTLAfterSucceeded makes sure the TTL after succeeded is a number of seconds that is not negative
+func validateTTLAfterSucceeded(app *appv1beta1.Application) error {
+	_, _, err := utils.GetTTLAfterSucceeded(app)
+
+	return err
+}
+
+// validateReservedLabels denies the selectors and the propagated labels using a label key reserved for the
+// operator, the selectors and propagated labels failing to parse are denied by their own checks
+func validateReservedLabels(app *appv1beta1.Application) error {
+	reserved := func(name, key string) error {
+		return fmt.Errorf("%s uses the label key %s, the %s prefix is reserved for the operator", name, key,
+			utils.ReservedKeyPrefix)
+	}
+
+	selectors, err := utils.GetSelectors(app)
+	if err != nil {
+		selectors = []*metav1.LabelSelector{app.Spec.Selector}
+	}
+
+	for i, sel := range selectors {
+		if sel == nil {
+			continue
+		}
+
+		name := "spec.selector"
+		if i > 0 {
+			name = fmt.Sprintf("%s annotation, selector %d", utils.AnnotationFallbackSelectors, i-1)
+		}
+
+		for _, key := range sortedKeys(sel.MatchLabels) {
+			if utils.IsReservedLabel(key) {
+				return reserved(name, key)
+			}
+		}
+
+		for _, expr := range sel.MatchExpressions {
+			if utils.IsReservedLabel(expr.Key) {
+				return reserved(name, expr.Key)
+			}
+		}
+	}
+
+	propagated, err := utils.GetPropagatedLabels(app)
+	if err != nil || propagated == nil {
+		return nil
+	}
+
+	for _, key := range sortedKeys(propagated.All) {
+		if utils.IsReservedLabel(key) {
+			return reserved(utils.AnnotationPropagateLabels+" annotation", key)
+		}
+	}
+
+	kinds := make([]schema.GroupKind, 0, len(propagated.ByKind))
+	for gk := range propagated.ByKind {
+		kinds = append(kinds, gk)
+	}
+
+	sort.Slice(kinds, func(i, j int) bool { return kinds[i].String() < kinds[j].String() })
+
+	for _, gk := range kinds {
+		for _, key := range sortedKeys(propagated.ByKind[gk]) {
+			if utils.IsReservedLabel(key) {
+				return reserved(fmt.Sprintf("%s annotation, kind %s", utils.AnnotationPropagateKindLabels, gk.String()), key)
+			}
+		}
+	}
+
+	return nil
+}
+
+// sortedKeys returns the keys of the labels in order, so the first reserved key reported is always the same
+func sortedKeys(labels map[string]string) []string {
+	keys := make([]string, 0, len(labels))
+	for key := range labels {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
+
+// validateParent makes sure the parent is a valid application name other than the application itself
+func validateParent(app *appv1beta1.Application) error {
+	_, err := utils.GetParent(app)
+
+	return err
+}
+
+// validatePropagateLabels makes sure the labels are valid and the per kind labels target kinds listed in
+// componentGroupKinds
+func validatePropagateLabels(app *appv1beta1.Application) error {
+	propagated, err := utils.GetPropagatedLabels(app)
+	if err != nil || propagated == nil {
+		return err
+	}
+
+	kinds := componentKinds(app)
+
+	for gk := range propagated.ByKind {
+		if !kinds[metav1.GroupKind{Group: gk.Group, Kind: gk.Kind}] {
+			return fmt.Errorf("%s annotation: %s is not a kind listed in spec.componentKinds", utils.AnnotationPropagateKindLabels, gk.String())
+		}
+	}
+
+	return nil
+}
+
+// validateComponentNamespaces makes sure the namespaces are well formed and target kinds listed in
+// componentGroupKinds, the namespaces are not required to exist
+func validateComponentNamespaces(app *appv1beta1.Application) error {
+	namespaces, err := utils.GetComponentNamespaces(app)
+	if err != nil {
+		return err
+	}
+
+	kinds := componentKinds(app)
+
+	for gk := range namespaces {
+		if !kinds[metav1.GroupKind{Group: gk.Group, Kind: gk.Kind}] {
+			return fmt.Errorf("%s annotation: %s is not a kind listed in spec.componentKinds", utils.AnnotationComponentNamespaces, gk.String())
+		}
+	}
+
+	return nil
+}
+
+// validateComponentKinds makes sure every entry of componentGroupKinds and of the health kinds has a kind
+func validateComponentKinds(app *appv1beta1.Application) error {
+	for i, gk := range app.Spec.ComponentGroupKinds {
+		if gk.Kind == "" {
+			return fmt.Errorf("spec.componentKinds entry %d requires a kind", i)
+		}
+	}
+
+	_, err := utils.GetHealthKinds(app)
+
+	return err
+}
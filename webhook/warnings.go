@@ -0,0 +1,171 @@
+// Copyright 2019 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webhook
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+
+	appv1beta1 "sigs.k8s.io/application/api/v1beta1"
+)
+
+// Warning checks flag suspicious applications without denying them
+const (
+	WarningEmptyDescriptor  = "empty-descriptor"
+	WarningBroadSelector    = "broad-selector"
+	WarningNoComponentKinds = "no-component-kinds"
+	WarningLargeSelector    = "large-selector"
+	WarningUnknownKinds     = "unknown-component-kinds"
+	WarningOwnerRefRemoval  = "owner-ref-removal"
+	WarningLabelConflicts   = "propagated-label-conflicts"
+)
+
+// AllWarnings lists every warning check, all of them are enabled by default
+var AllWarnings = []string{WarningEmptyDescriptor, WarningBroadSelector, WarningNoComponentKinds, WarningLargeSelector,
+	WarningUnknownKinds, WarningOwnerRefRemoval, WarningLabelConflicts}
+
+// DefaultSelectorTermsWarning is the number of selector terms past which the applications are warned about
+const DefaultSelectorTermsWarning = 10
+
+var warningChecks = map[string]func(ctx context.Context, app *appv1beta1.Application, opts ValidatorOptions) string{
+	WarningEmptyDescriptor: func(ctx context.Context, app *appv1beta1.Application, opts ValidatorOptions) string {
+		if reflect.DeepEqual(app.Spec.Descriptor, appv1beta1.Descriptor{}) {
+			return "spec.descriptor is empty, consider describing the application type and version"
+		}
+
+		return ""
+	},
+	WarningBroadSelector: func(ctx context.Context, app *appv1beta1.Application, opts ValidatorOptions) string {
+		sel := app.Spec.Selector
+		if sel == nil || (len(sel.MatchLabels) == 0 && len(sel.MatchExpressions) == 0) {
+			return "spec.selector is empty and matches every resource of the component kinds in the namespace"
+		}
+
+		return ""
+	},
+	WarningNoComponentKinds: func(ctx context.Context, app *appv1beta1.Application, opts ValidatorOptions) string {
+		if len(app.Spec.ComponentGroupKinds) == 0 {
+			return "spec.componentKinds is empty, the application will not resolve any component"
+		}
+
+		return ""
+	},
+	WarningLargeSelector: func(ctx context.Context, app *appv1beta1.Application, opts ValidatorOptions) string {
+		if opts.SelectorTermsWarning <= 0 {
+			return ""
+		}
+
+		if name, terms := largestSelector(app); terms > opts.SelectorTermsWarning {
+			return fmt.Sprintf("%s has %d label keys and expressions, a component rarely carries that many labels, "+
+				"review the selector for copy-paste errors", name, terms)
+		}
+
+		return ""
+	},
+	WarningUnknownKinds: func(ctx context.Context, app *appv1beta1.Application, opts ValidatorOptions) string {
+		if opts.mapper == nil {
+			return ""
+		}
+
+		var unknown []string
+
+		for _, gk := range app.Spec.ComponentGroupKinds {
+			if _, ok := canonicalGroupKind(opts.mapper, gk.Group, gk.Kind); !ok {
+				unknown = append(unknown, gk.String())
+			}
+		}
+
+		if len(unknown) == 0 {
+			return ""
+		}
+
+		return fmt.Sprintf("spec.componentKinds lists kinds the cluster does not serve: %s, check their spelling, "+
+			"no component of those kinds is resolved until their CRD is installed", strings.Join(unknown, ", "))
+	},
+	WarningLabelConflicts: func(ctx context.Context, app *appv1beta1.Application, opts ValidatorOptions) string {
+		conflicts := propagatedLabelConflicts(ctx, opts.reader, app)
+		if len(conflicts) == 0 {
+			return ""
+		}
+
+		return "the propagated labels conflict with the applications that may select the same components, they " +
+			"would overwrite each other's values: " + strings.Join(conflicts, "; ")
+	},
+}
+
+// transitionWarningChecks flag the suspicious updates of an application
+var transitionWarningChecks = map[string]func(oldApp, newApp *appv1beta1.Application) string{
+	WarningOwnerRefRemoval: func(oldApp, newApp *appv1beta1.Application) string {
+		if oldApp.Spec.AddOwnerRef && !newApp.Spec.AddOwnerRef {
+			return "spec.addOwnerRef turned false, the controller removes the owner references of the application from " +
+				"its components, they are no longer garbage collected with it"
+		}
+
+		return ""
+	},
+}
+
+func validateWarningNames(names []string) error {
+	for _, name := range names {
+		_, ok := warningChecks[name]
+		if _, transition := transitionWarningChecks[name]; !ok && !transition {
+			return fmt.Errorf("unknown webhook warning check %q, valid checks are %v", name, AllWarnings)
+		}
+	}
+
+	return nil
+}
+
+// warnings runs the enabled warning checks against the application
+func (v *AppValidator) warnings(ctx context.Context, app *appv1beta1.Application) []string {
+	var warnings []string
+
+	for _, name := range v.opts.Warnings {
+		check, ok := warningChecks[name]
+		if !ok {
+			continue
+		}
+
+		if msg := check(ctx, app, v.opts); msg != "" {
+			warnings = append(warnings, msg)
+		}
+	}
+
+	return warnings
+}
+
+// transitionWarnings runs the enabled warning checks against the update of an application, none on create
+func (v *AppValidator) transitionWarnings(oldApp, newApp *appv1beta1.Application) []string {
+	if oldApp == nil {
+		return nil
+	}
+
+	var warnings []string
+
+	for _, name := range v.opts.Warnings {
+		check, ok := transitionWarningChecks[name]
+		if !ok {
+			continue
+		}
+
+		if msg := check(oldApp, newApp); msg != "" {
+			warnings = append(warnings, msg)
+		}
+	}
+
+	return warnings
+}
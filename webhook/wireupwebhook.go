@@ -20,6 +20,7 @@ import (
 	"os"
 
 	gerr "github.com/pkg/errors"
+	"github.com/stolostron/multicloud-operators-application/pkg/apis"
 
 	appsv1 "k8s.io/api/apps/v1"
 
@@ -35,13 +36,12 @@ import (
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
 	"sigs.k8s.io/controller-runtime/pkg/webhook"
-
-	appv1beta1 "sigs.k8s.io/application/api/v1beta1"
 )
 
 const (
 	tlsCrt = "tls.crt"
 	tlsKey = "tls.key"
+	caCrt  = "ca.crt"
 
 	WebhookPort          = 9442
 	ValidatorPath        = "/app-validate"
@@ -65,8 +65,27 @@ func WireUpWebhook(clt client.Client, mgr manager.Manager, whk *webhook.Server,
 	whk.Port = WebhookPort
 	whk.CertDir = certDir
 
+	if err := validateWarningNames(Options.Warnings); err != nil {
+		return nil, err
+	}
+
+	opts := Options
+	opts.mapper = mgr.GetRESTMapper()
+	opts.reader = mgr.GetClient()
+
 	log.Info("registering webhooks to the webhook server")
-	whk.Register(ValidatorPath, &webhook.Admission{Handler: &AppValidator{Client: mgr.GetClient()}})
+	whk.Register(ValidatorPath, &webhook.Admission{Handler: &AppValidator{Client: mgr.GetClient(), opts: opts}})
+
+	if len(enabledMutations()) > 0 {
+		mutator := &AppMutator{keywords: Options.NormalizeKeywords, lowercaseKeywords: Options.LowercaseKeywords}
+		if Options.NormalizeComponentKinds {
+			mutator.mapper = mgr.GetRESTMapper()
+		}
+
+		whk.Register(MutatorPath, &webhook.Admission{Handler: mutator})
+	}
+
+	LogEffectiveConfig()
 
 	return GenerateWebhookCerts(clt, certDir)
 }
@@ -99,6 +118,15 @@ func WireUpWebhookSupplymentryResource(ctx context.Context, mgr manager.Manager,
 		log.Error(err, "failed to wire up webhook with kube")
 		os.Exit(1)
 	}
+
+	if err := syncMutatingWebhook(clt, wbhSvcName, WebhookMutatorName, podNs, MutatorPath, caCert); err != nil {
+		log.Error(err, "failed to wire up webhook with kube")
+		os.Exit(1)
+	}
+
+	if Options.SelfTest {
+		runSelfTest(ctx, clt, podNs)
+	}
 }
 
 func findEnvVariable(envName string) (string, error) {
@@ -156,16 +184,14 @@ func createOrUpdateValiatingWebhook(c client.Client, wbhSvcName, validatorName,
 
 			return nil
 		}
-	}
-
-	validator.Webhooks[0].ClientConfig.Service.Namespace = namespace
-	validator.Webhooks[0].ClientConfig.CABundle = ca
 
-	ignore := admissionregistration.Ignore
-	timeoutSeconds := int32(30)
+		return gerr.Wrap(err, fmt.Sprintf("Failed to get validating webhook %s", validatorName))
+	}
 
-	validator.Webhooks[0].FailurePolicy = &ignore
-	validator.Webhooks[0].TimeoutSeconds = &timeoutSeconds
+	// the webhooks are replaced as a whole, so the rules follow the application group version the operator
+	// is configured with after an upgrade
+	desired := newValidatingWebhookCfg(wbhSvcName, validatorName, namespace, path, ca)
+	validator.Webhooks = desired.Webhooks
 
 	if err := c.Update(context.TODO(), validator); err != nil {
 		return gerr.Wrap(err, fmt.Sprintf("Failed to update validating webhook %s", validatorName))
@@ -176,6 +202,56 @@ func createOrUpdateValiatingWebhook(c client.Client, wbhSvcName, validatorName,
 	return nil
 }
 
+// syncMutatingWebhook creates or updates the mutating webhook, and deletes it when no mutation is enabled
+func syncMutatingWebhook(c client.Client, wbhSvcName, mutatorName, namespace, path string, ca []byte) error {
+	mutator := &admissionregistration.MutatingWebhookConfiguration{}
+	key := types.NamespacedName{Name: mutatorName}
+
+	err := c.Get(context.TODO(), key, mutator)
+	if err != nil && !errors.IsNotFound(err) {
+		return gerr.Wrap(err, fmt.Sprintf("Failed to get mutating webhook %s", mutatorName))
+	}
+
+	if len(enabledMutations()) == 0 {
+		if err != nil {
+			return nil
+		}
+
+		if err := c.Delete(context.TODO(), mutator); err != nil && !errors.IsNotFound(err) {
+			return gerr.Wrap(err, fmt.Sprintf("Failed to delete mutating webhook %s", mutatorName))
+		}
+
+		log.Info(fmt.Sprintf("Delete mutating webhook %s", mutatorName))
+
+		return nil
+	}
+
+	if err != nil {
+		cfg := newMutatingWebhookCfg(wbhSvcName, mutatorName, namespace, path, ca)
+
+		setOwnerReferences(c, namespace, cfg)
+
+		if err := c.Create(context.TODO(), cfg); err != nil {
+			return gerr.Wrap(err, fmt.Sprintf("Failed to create mutating webhook %s", mutatorName))
+		}
+
+		log.Info(fmt.Sprintf("Create mutating webhook %s", mutatorName))
+
+		return nil
+	}
+
+	desired := newMutatingWebhookCfg(wbhSvcName, mutatorName, namespace, path, ca)
+	mutator.Webhooks = desired.Webhooks
+
+	if err := c.Update(context.TODO(), mutator); err != nil {
+		return gerr.Wrap(err, fmt.Sprintf("Failed to update mutating webhook %s", mutatorName))
+	}
+
+	log.Info(fmt.Sprintf("Update mutating webhook %s", mutatorName))
+
+	return nil
+}
+
 func setOwnerReferences(c client.Client, namespace string, obj metav1.Object) {
 	deployLabel, err := findEnvVariable(deployLabelEnvVar)
 	if err != nil {
@@ -218,9 +294,9 @@ func newWebhookService(wbhSvcName, namespace string) (*corev1.Service, error) {
 }
 
 func newValidatingWebhookCfg(wbhSvcName, validatorName, namespace, path string, ca []byte) *admissionregistration.ValidatingWebhookConfiguration {
-	ignore := admissionregistration.Ignore
+	failurePolicy := webhookFailurePolicy
 	side := admissionregistration.SideEffectClassNone
-	timeoutSeconds := int32(30)
+	timeoutSeconds := webhookTimeoutSeconds
 
 	return &admissionregistration.ValidatingWebhookConfiguration{
 		ObjectMeta: metav1.ObjectMeta{
@@ -229,10 +305,55 @@ func newValidatingWebhookCfg(wbhSvcName, validatorName, namespace, path string,
 
 		Webhooks: []admissionregistration.ValidatingWebhook{{
 			Name:                    webhookName,
-			AdmissionReviewVersions: []string{"v1beta1"},
+			AdmissionReviewVersions: Options.AdmissionReviewVersions,
+			SideEffects:             &side,
+			FailurePolicy:           &failurePolicy,
+			TimeoutSeconds:          &timeoutSeconds,
+			ObjectSelector:          Options.ObjectSelector,
+			ClientConfig: admissionregistration.WebhookClientConfig{
+				Service: &admissionregistration.ServiceReference{
+					Name:      wbhSvcName,
+					Namespace: namespace,
+					Path:      &path,
+				},
+				CABundle: ca,
+			},
+			Rules: []admissionregistration.RuleWithOperations{{
+				Rule: admissionregistration.Rule{
+					APIGroups:   []string{apis.ApplicationGroupVersion.Group},
+					APIVersions: []string{apis.ApplicationGroupVersion.Version},
+					// the base resource only, "applications/status" and other subresources are not validated
+					Resources: webhookResources,
+				},
+				Operations: []admissionregistration.OperationType{
+					admissionregistration.Create,
+					admissionregistration.Update,
+				},
+			}},
+		}},
+	}
+}
+
+func newMutatingWebhookCfg(wbhSvcName, mutatorName, namespace, path string, ca []byte) *admissionregistration.MutatingWebhookConfiguration {
+	failurePolicy := webhookFailurePolicy
+	side := admissionregistration.SideEffectClassNone
+	timeoutSeconds := webhookTimeoutSeconds
+	// the validating webhook sees the normalized kinds, the mutation is not repeated after other mutations
+	reinvocation := admissionregistration.NeverReinvocationPolicy
+
+	return &admissionregistration.MutatingWebhookConfiguration{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: mutatorName,
+		},
+
+		Webhooks: []admissionregistration.MutatingWebhook{{
+			Name:                    mutatorWebhookName,
+			AdmissionReviewVersions: Options.AdmissionReviewVersions,
 			SideEffects:             &side,
-			FailurePolicy:           &ignore,
+			FailurePolicy:           &failurePolicy,
 			TimeoutSeconds:          &timeoutSeconds,
+			ObjectSelector:          Options.ObjectSelector,
+			ReinvocationPolicy:      &reinvocation,
 			ClientConfig: admissionregistration.WebhookClientConfig{
 				Service: &admissionregistration.ServiceReference{
 					Name:      wbhSvcName,
@@ -243,9 +364,9 @@ func newValidatingWebhookCfg(wbhSvcName, validatorName, namespace, path string,
 			},
 			Rules: []admissionregistration.RuleWithOperations{{
 				Rule: admissionregistration.Rule{
-					APIGroups:   []string{appv1beta1.GroupVersion.Group},
-					APIVersions: []string{appv1beta1.GroupVersion.Version},
-					Resources:   []string{resourceName},
+					APIGroups:   []string{apis.ApplicationGroupVersion.Group},
+					APIVersions: []string{apis.ApplicationGroupVersion.Version},
+					Resources:   webhookResources,
 				},
 				Operations: []admissionregistration.OperationType{
 					admissionregistration.Create,